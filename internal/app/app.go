@@ -2,6 +2,7 @@ package app
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
@@ -11,11 +12,18 @@ import (
 	"github.com/charmbracelet/lipgloss"
 
 	"github.com/hwanchang/tsk/internal/config"
+	"github.com/hwanchang/tsk/internal/dates"
+	"github.com/hwanchang/tsk/internal/filter"
 	"github.com/hwanchang/tsk/internal/model"
+	"github.com/hwanchang/tsk/internal/quickadd"
+	"github.com/hwanchang/tsk/internal/search"
 	"github.com/hwanchang/tsk/internal/store"
 	"github.com/hwanchang/tsk/internal/styles"
 )
 
+// sortPresets are the orders the sort key cycles through
+var sortPresets = []string{"", "priority,due", "due,priority", "urgency", "created", "title", "project,priority"}
+
 type ViewType int
 
 const (
@@ -28,7 +36,9 @@ type InputMode int
 const (
 	InputNone InputMode = iota
 	InputAdd
+	InputAddSubtask
 	InputSearch
+	InputFilter
 	InputEdit
 	InputAddProject
 	InputAddTag
@@ -51,6 +61,7 @@ const (
 	OverlayConfirmDeleteTag
 	OverlayConfirmDeleteProject
 	OverlayRecurrenceSelect
+	OverlayRecurrenceCustom
 	OverlayThemeSelect
 )
 
@@ -61,6 +72,7 @@ type Model struct {
 	tasks    []model.Task
 	projects []model.Project
 	tags     []model.Tag
+	views    []model.View
 
 	// UI state
 	activeView    ViewType
@@ -83,7 +95,10 @@ type Model struct {
 	// Filter
 	currentProject     *int64
 	currentProjectName string
+	currentView        string // saved view shown instead of a project
+	sortBy             string // task order, see store.ParseSort; empty for manual
 	searchQuery        string
+	filterQuery        string
 
 	// Done section (list view)
 	doneCollapsed bool // true = collapsed, false = expanded
@@ -92,6 +107,11 @@ type Model struct {
 	activeTasks   []model.Task
 	doneTasksList []model.Task
 
+	// Subtasks (list view)
+	subtasks  map[int64][]model.Task // loaded subtasks by parent ID
+	expanded  map[int64]bool         // tasks whose subtasks are shown
+	taskDepth map[int64]int          // nesting depth of each list row
+
 	// Stats
 	totalTasks    int
 	doneTaskCount int
@@ -107,6 +127,14 @@ type Model struct {
 	// Due date custom form
 	dueDateFormValue string
 
+	// Custom recurrence form
+	recurrenceFormValue string
+
+	// Running timer
+	timer        *model.TimeEntry
+	timerTask    string // title of the timed task
+	timerTicking bool
+
 	// Status
 	statusText  string
 	statusError bool
@@ -125,15 +153,20 @@ func New(st *store.SQLiteStore) *Model {
 		store:              st,
 		textInput:          ti,
 		currentProjectName: "All",
+		sortBy:             config.GetSort(),
 		doneCollapsed:      false, // done section expanded by default
+		subtasks:           make(map[int64][]model.Task),
+		expanded:           make(map[int64]bool),
+		taskDepth:          make(map[int64]int),
 	}
 }
 
 func (m Model) Init() tea.Cmd {
 	return tea.Batch(
-		loadTasks(m.store, store.TaskFilter{}),
+		m.reloadTasks(),
 		loadProjects(m.store),
 		loadTags(m.store),
+		loadViews(m.store),
 	)
 }
 
@@ -178,10 +211,21 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.inputMode = InputAdd
 			m.inputPrompt = "New task: "
 			m.textInput.SetValue("")
-			m.textInput.Placeholder = "Enter task title..."
+			m.textInput.Placeholder = "Title +project #tag !high due:tomorrow every:1w"
 			m.textInput.Focus()
 			return m, textinput.Blink
 
+		case key.Matches(msg, Keys.AddSubtask):
+			if task := m.selectedTask(); task != nil {
+				m.inputMode = InputAddSubtask
+				m.inputPrompt = fmt.Sprintf("Subtask of #%d: ", task.ID)
+				m.textInput.SetValue("")
+				m.textInput.Placeholder = "Enter subtask title..."
+				m.editTaskID = task.ID
+				m.textInput.Focus()
+				return m, textinput.Blink
+			}
+
 		case key.Matches(msg, Keys.Edit):
 			if task := m.selectedTask(); task != nil {
 				m.inputMode = InputEdit
@@ -193,11 +237,56 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, textinput.Blink
 			}
 
+		case key.Matches(msg, Keys.EditExternal):
+			if task := m.selectedTask(); task != nil {
+				return m, editTaskInEditor(m.store, task.ID, nil)
+			}
+
+		case key.Matches(msg, Keys.Timer):
+			if task := m.selectedTask(); task != nil {
+				return m, toggleTimer(m.store, task.ID)
+			}
+
+		case key.Matches(msg, Keys.Undo):
+			return m, undo(m.store)
+
+		case key.Matches(msg, Keys.Redo):
+			return m, redo(m.store)
+
 		case key.Matches(msg, Keys.Search):
 			m.inputMode = InputSearch
 			m.inputPrompt = "Search: "
 			m.textInput.SetValue(m.searchQuery)
-			m.textInput.Placeholder = "Search tasks..."
+			m.textInput.Placeholder = "Search tasks (e.g. meet, \"exact phrase\", a OR b, -word, tag:work)"
+			m.textInput.Focus()
+			return m, textinput.Blink
+
+		case key.Matches(msg, Keys.Sort):
+			// Cycle through the sort orders and remember the choice
+			next := 0
+			for i, preset := range sortPresets {
+				if preset == m.sortBy {
+					next = (i + 1) % len(sortPresets)
+				}
+			}
+			m.sortBy = sortPresets[next]
+			config.SetSort(m.sortBy)
+			if err := config.Save(); err != nil {
+				m.statusText = "Sorted, but couldn't save the setting: " + err.Error()
+				m.statusError = true
+				return m, tea.Batch(m.reloadTasks(), clearStatusAfter(5*time.Second))
+			}
+			m.statusText = "Sorted by " + m.sortBy
+			if m.sortBy == "" {
+				m.statusText = "Manual order"
+			}
+			return m, tea.Batch(m.reloadTasks(), clearStatusAfter(1500*time.Millisecond))
+
+		case key.Matches(msg, Keys.Filter):
+			m.inputMode = InputFilter
+			m.inputPrompt = "Filter: "
+			m.textInput.SetValue(m.filterQuery)
+			m.textInput.Placeholder = "status:todo,doing tag:work due<+7d"
 			m.textInput.Focus()
 			return m, textinput.Blink
 
@@ -235,9 +324,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 
 		case msg.String() == "c":
-			// Clear search
-			if m.searchQuery != "" {
+			// Clear search and filter
+			if m.searchQuery != "" || m.filterQuery != "" {
 				m.searchQuery = ""
+				m.filterQuery = ""
 				return m, m.reloadTasks()
 			}
 
@@ -274,20 +364,23 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 	case TasksLoadedMsg:
-		m.tasks = msg.Tasks
-		m.totalTasks = len(msg.Tasks)
-
-		// Separate tasks into active (todo+doing) and done
-		m.activeTasks = nil
-		m.doneTasksList = nil
+		// Split off subtasks; tasks whose parent isn't loaded (e.g. filtered
+		// out by a search) are shown at the top level
+		loaded := make(map[int64]bool, len(msg.Tasks))
 		for _, t := range msg.Tasks {
-			if t.Status == model.StatusDone {
-				m.doneTasksList = append(m.doneTasksList, t)
+			loaded[t.ID] = true
+		}
+		m.tasks = nil
+		m.subtasks = make(map[int64][]model.Task)
+		for _, t := range msg.Tasks {
+			if t.ParentID != nil && loaded[*t.ParentID] {
+				m.subtasks[*t.ParentID] = append(m.subtasks[*t.ParentID], t)
 			} else {
-				m.activeTasks = append(m.activeTasks, t)
+				m.tasks = append(m.tasks, t)
 			}
 		}
-		m.doneTaskCount = len(m.doneTasksList)
+		m.totalTasks = len(m.tasks)
+		m.buildListRows()
 
 		m.clampCursor()
 		m.clampDoneCursor()
@@ -298,6 +391,22 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case TagsLoadedMsg:
 		m.tags = msg.Tags
 
+	case ViewsLoadedMsg:
+		m.views = msg.Views
+		if m.overlayMode == OverlayProjectSelect {
+			m.overlayCursor = min(m.overlayCursor, len(m.projects)+len(m.views))
+		}
+		if m.currentView != "" && !slices.ContainsFunc(m.views, func(v model.View) bool { return strings.EqualFold(v.Name, m.currentView) }) {
+			// The view was deleted
+			m.currentView = ""
+			m.currentProjectName = "All"
+			cmds = append(cmds, m.reloadTasks())
+		}
+
+	case ViewDeletedMsg:
+		m.statusText = fmt.Sprintf("✓ Deleted view @%s (u to undo)", msg.Name)
+		cmds = append(cmds, loadViews(m.store), clearStatusAfter(1500*time.Millisecond))
+
 	case TaskCreatedMsg:
 		m.statusText = fmt.Sprintf("✓ Created: %s", msg.Task.Title)
 		cmds = append(cmds, m.reloadTasks(), loadProjects(m.store), clearStatusAfter(1500*time.Millisecond))
@@ -306,8 +415,17 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.statusText = "✓ Updated"
 		cmds = append(cmds, m.reloadTasks(), loadProjects(m.store), clearStatusAfter(1500*time.Millisecond))
 
+	case TaskStartedMsg:
+		if msg.Task.Blocked {
+			m.statusText = "⚠ Started, but still blocked by unfinished tasks"
+			m.statusError = true
+		} else {
+			m.statusText = "✓ Started"
+		}
+		cmds = append(cmds, m.reloadTasks(), loadProjects(m.store), clearStatusAfter(1500*time.Millisecond))
+
 	case TaskDeletedMsg:
-		m.statusText = "✓ Deleted"
+		m.statusText = "✓ Moved to trash"
 		cmds = append(cmds, m.reloadTasks(), loadProjects(m.store), clearStatusAfter(1500*time.Millisecond))
 
 	case ProjectCreatedMsg:
@@ -319,11 +437,11 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		cmds = append(cmds, loadTags(m.store), m.reloadTasks(), clearStatusAfter(1500*time.Millisecond))
 
 	case TagDeletedMsg:
-		m.statusText = "✓ Tag deleted"
+		m.statusText = "✓ Tag moved to trash"
 		cmds = append(cmds, loadTags(m.store), m.reloadTasks(), clearStatusAfter(1500*time.Millisecond))
 
 	case ProjectDeletedMsg:
-		m.statusText = "✓ Project deleted (tasks moved to Inbox)"
+		m.statusText = "✓ Project moved to trash (tasks moved to Inbox)"
 		cmds = append(cmds, loadProjects(m.store), m.reloadTasks(), clearStatusAfter(1500*time.Millisecond))
 
 	case RecurrenceSetMsg:
@@ -334,6 +452,50 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.statusText = "✓ Recurrence removed"
 		cmds = append(cmds, m.reloadTasks(), clearStatusAfter(1500*time.Millisecond))
 
+	case TimerLoadedMsg:
+		m.timer = msg.Entry
+		m.timerTask = ""
+		if msg.Task != nil {
+			m.timerTask = msg.Task.Title
+		}
+		if m.timer != nil && !m.timerTicking {
+			m.timerTicking = true
+			cmds = append(cmds, tickTimer())
+		}
+
+	case TimerTickMsg:
+		// Keep ticking only while a timer runs
+		if m.timer != nil {
+			cmds = append(cmds, tickTimer())
+		} else {
+			m.timerTicking = false
+		}
+
+	case TimerToggledMsg:
+		if msg.Started {
+			m.statusText = "⏱ Timer started"
+		} else {
+			m.statusText = "⏹ Timer stopped: " + model.FormatDuration(msg.Entry.Duration(time.Now()))
+		}
+		cmds = append(cmds, loadTimer(m.store), clearStatusAfter(1500*time.Millisecond))
+
+	case UndoneMsg:
+		if msg.Redo {
+			m.statusText = "↷ Redid: " + msg.Label
+		} else {
+			m.statusText = "↶ Undid: " + msg.Label
+		}
+		cmds = append(cmds, m.reloadTasks(), loadProjects(m.store), loadTags(m.store), loadViews(m.store), clearStatusAfter(1500*time.Millisecond))
+
+	case EditorParseErrorMsg:
+		// Re-open the editor so the user can fix the document
+		cmds = append(cmds, editTaskInEditor(m.store, msg.TaskID, msg.Content))
+
+	case StatusMsg:
+		m.statusText = msg.Text
+		m.statusError = msg.IsError
+		cmds = append(cmds, clearStatusAfter(1500*time.Millisecond))
+
 	case ErrorMsg:
 		m.statusText = msg.Err.Error()
 		m.statusError = true
@@ -366,20 +528,27 @@ func (m Model) handleOverlay(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			}
 
 		case key.Matches(msg, Keys.Down):
-			if m.overlayCursor < len(m.projects) {
+			if m.overlayCursor < len(m.projects)+len(m.views) {
 				m.overlayCursor++
 			}
 
 		case key.Matches(msg, Keys.Select):
 			m.overlayMode = OverlayNone
+			m.currentView = ""
 			if m.overlayCursor == 0 {
 				// "All" selected
 				m.currentProject = nil
 				m.currentProjectName = "All"
-			} else {
+			} else if m.overlayCursor <= len(m.projects) {
 				proj := m.projects[m.overlayCursor-1]
 				m.currentProject = &proj.ID
 				m.currentProjectName = proj.Name
+			} else {
+				// Views show matching tasks from every project
+				view := m.views[m.overlayCursor-len(m.projects)-1]
+				m.currentProject = nil
+				m.currentView = view.Name
+				m.currentProjectName = "@" + view.Name
 			}
 			return m, m.reloadTasks()
 
@@ -398,6 +567,9 @@ func (m Model) handleOverlay(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				m.statusError = true
 				return m, clearStatusAfter(2 * time.Second)
 			}
+			if m.overlayCursor > len(m.projects) {
+				return m, deleteView(m.store, m.views[m.overlayCursor-len(m.projects)-1].Name)
+			}
 			proj := m.projects[m.overlayCursor-1]
 			if proj.ID == 1 {
 				m.statusText = "Cannot delete Inbox project"
@@ -614,14 +786,12 @@ func (m Model) handleOverlay(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				m.overlayMode = OverlayNone
 				return m, nil
 			}
-			// Parse date
-			dueDate, err := time.Parse("2006-01-02", dateStr)
+			dueDate, err := dates.Parse(dateStr)
 			if err != nil {
-				m.statusText = "Invalid date format (use YYYY-MM-DD)"
+				m.statusText = "Invalid date (try fri, tomorrow 9am, in 2 weeks, or YYYY-MM-DD)"
 				m.statusError = true
 				return m, clearStatusAfter(2 * time.Second)
 			}
-			dueDate = time.Date(dueDate.Year(), dueDate.Month(), dueDate.Day(), 23, 59, 0, 0, time.Local)
 			task.DueDate = &dueDate
 			m.overlayMode = OverlayNone
 			return m, updateTask(m.store, task)
@@ -633,6 +803,10 @@ func (m Model) handleOverlay(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 		case msg.Type == tea.KeyRunes:
 			m.dueDateFormValue += string(msg.Runes)
+
+		case msg.Type == tea.KeySpace:
+			// Natural dates have spaces ("next monday")
+			m.dueDateFormValue += " "
 		}
 
 	case OverlayProjectCreate:
@@ -683,7 +857,7 @@ func (m Model) handleOverlay(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 
 	case OverlayRecurrenceSelect:
-		// Options: Daily, Weekly, Monthly, Yearly, Remove (if has recurrence)
+		// Options: the presets, Custom, and Remove (if has recurrence)
 		task := m.selectedTask()
 		if task == nil {
 			m.overlayMode = OverlayNone
@@ -695,9 +869,9 @@ func (m Model) handleOverlay(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			hasRecurrence = true
 		}
 
-		maxCursor := 3 // Daily, Weekly, Monthly, Yearly (0-3)
+		maxCursor := len(recurrencePresets) // presets + Custom
 		if hasRecurrence {
-			maxCursor = 4 // + Remove option
+			maxCursor++ // + Remove option
 		}
 
 		switch {
@@ -717,21 +891,53 @@ func (m Model) handleOverlay(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 		case key.Matches(msg, Keys.Select):
 			m.overlayMode = OverlayNone
-			switch m.overlayCursor {
-			case 0:
-				return m, setRecurrence(m.store, task.ID, model.Daily, 1)
-			case 1:
-				return m, setRecurrence(m.store, task.ID, model.Weekly, 1)
-			case 2:
-				return m, setRecurrence(m.store, task.ID, model.Monthly, 1)
-			case 3:
-				return m, setRecurrence(m.store, task.ID, model.Yearly, 1)
-			case 4:
+			switch {
+			case m.overlayCursor < len(recurrencePresets):
+				return m, setRecurrence(m.store, task.ID, recurrencePresets[m.overlayCursor].rule)
+			case m.overlayCursor == len(recurrencePresets):
+				// Custom - open custom recurrence overlay
+				m.overlayMode = OverlayRecurrenceCustom
+				m.recurrenceFormValue = ""
+				return m, nil
+			default:
 				// Remove recurrence
 				return m, deleteRecurrence(m.store, task.ID)
 			}
 		}
 
+	case OverlayRecurrenceCustom:
+		switch {
+		case key.Matches(msg, Keys.Cancel):
+			m.overlayMode = OverlayRecurrenceSelect
+			return m, nil
+
+		case msg.Type == tea.KeyEnter:
+			task := m.selectedTask()
+			if task == nil {
+				m.overlayMode = OverlayNone
+				return m, nil
+			}
+			rule, err := quickadd.ParseRepeat(m.recurrenceFormValue)
+			if err != nil {
+				m.statusText = "Invalid recurrence (try mon,wed,fri, 2w, last fri, or 15th)"
+				m.statusError = true
+				return m, clearStatusAfter(2 * time.Second)
+			}
+			m.overlayMode = OverlayNone
+			return m, setRecurrence(m.store, task.ID, rule)
+
+		case msg.Type == tea.KeyBackspace:
+			if len(m.recurrenceFormValue) > 0 {
+				m.recurrenceFormValue = truncateRunes(m.recurrenceFormValue, 1)
+			}
+
+		case msg.Type == tea.KeyRunes:
+			m.recurrenceFormValue += string(msg.Runes)
+
+		case msg.Type == tea.KeySpace:
+			m.recurrenceFormValue += " "
+		}
+
 	case OverlayTaskDetail:
 		// Any key closes the detail overlay
 		m.overlayMode = OverlayNone
@@ -757,9 +963,13 @@ func (m Model) handleOverlay(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		case key.Matches(msg, Keys.Select):
 			themeName := styles.ThemeNames[m.overlayCursor]
 			config.SetTheme(themeName)
-			config.Save()
 			styles.ApplyTheme(themeName)
 			m.overlayMode = OverlayNone
+			if err := config.Save(); err != nil {
+				m.statusText = "Theme applied, but couldn't save the setting: " + err.Error()
+				m.statusError = true
+				return m, clearStatusAfter(5 * time.Second)
+			}
 			m.statusText = "Theme: " + styles.Themes[themeName].Name
 			return m, clearStatusAfter(2 * time.Second)
 		}
@@ -786,15 +996,45 @@ func (m Model) handleInputMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case tea.KeyEnter:
 		value := strings.TrimSpace(m.textInput.Value())
 
+		// Parse quick-add attributes; keep the input open to fix mistakes
+		var q *quickadd.Result
+		if value != "" && (m.inputMode == InputAdd || m.inputMode == InputAddSubtask) {
+			var err error
+			if q, err = quickadd.Parse(value); err != nil {
+				m.statusText = err.Error()
+				m.statusError = true
+				return m, clearStatusAfter(2 * time.Second)
+			}
+		}
+		if m.inputMode == InputFilter {
+			if _, err := filter.Parse(value); err != nil {
+				m.statusText = err.Error()
+				m.statusError = true
+				return m, clearStatusAfter(5 * time.Second)
+			}
+		}
+
 		var cmd tea.Cmd
 		switch m.inputMode {
 		case InputAdd:
-			if value != "" {
-				cmd = createTask(m.store, value, m.currentProject)
+			if q != nil {
+				cmd = createTask(m.store, q, m.currentProject, nil)
+			}
+		case InputAddSubtask:
+			if q != nil && m.editTaskID > 0 {
+				parent, _ := m.store.GetTask(m.editTaskID)
+				if parent != nil {
+					// Show the new subtask under its parent
+					m.expanded[parent.ID] = true
+					cmd = createTask(m.store, q, parent.ProjectID, &parent.ID)
+				}
 			}
 		case InputSearch:
 			m.searchQuery = value
 			cmd = m.reloadTasks()
+		case InputFilter:
+			m.filterQuery = value
+			cmd = m.reloadTasks()
 		case InputEdit:
 			if value != "" && m.editTaskID > 0 {
 				task, _ := m.store.GetTask(m.editTaskID)
@@ -815,14 +1055,12 @@ func (m Model) handleInputMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			if value != "" {
 				task := m.selectedTask()
 				if task != nil {
-					// Parse date
-					t, err := time.Parse("2006-01-02", value)
+					dueDate, err := dates.Parse(value)
 					if err != nil {
-						m.statusText = "Invalid date format (use YYYY-MM-DD)"
+						m.statusText = "Invalid date (try fri, tomorrow 9am, in 2 weeks, or YYYY-MM-DD)"
 						m.statusError = true
 						cmd = clearStatusAfter(2 * time.Second)
 					} else {
-						dueDate := time.Date(t.Year(), t.Month(), t.Day(), 23, 59, 0, 0, time.Local)
 						task.DueDate = &dueDate
 						cmd = updateTask(m.store, task)
 					}
@@ -872,13 +1110,30 @@ func (m *Model) updateListView(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			}
 		}
 
+	case key.Matches(msg, Keys.Right):
+		// Expand subtasks
+		if task := m.selectedTask(); task != nil && len(m.subtasks[task.ID]) > 0 && !m.expanded[task.ID] {
+			m.expanded[task.ID] = true
+			m.buildListRows()
+		}
+
+	case key.Matches(msg, Keys.Left):
+		// Collapse subtasks, or jump to the parent task
+		if task := m.selectedTask(); task != nil {
+			if m.expanded[task.ID] {
+				delete(m.expanded, task.ID)
+				m.buildListRows()
+			} else if task.ParentID != nil && m.taskDepth[task.ID] > 0 {
+				m.selectListTask(*task.ParentID)
+			}
+		}
+
 	case key.Matches(msg, Keys.Select):
 		// Enter: forward status (todo → doing → done)
 		if task := m.selectedTask(); task != nil {
 			switch task.Status {
 			case model.StatusTodo:
-				task.Status = model.StatusDoing
-				return m, updateTask(m.store, task)
+				return m, startTask(m.store, task)
 			case model.StatusDoing:
 				return m, completeTask(m.store, task.ID)
 			}
@@ -889,9 +1144,7 @@ func (m *Model) updateListView(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		if task := m.selectedTask(); task != nil {
 			switch task.Status {
 			case model.StatusDone:
-				task.Status = model.StatusDoing
-				task.CompletedAt = nil
-				return m, updateTask(m.store, task)
+				return m, startTask(m.store, task)
 			case model.StatusDoing:
 				task.Status = model.StatusTodo
 				return m, updateTask(m.store, task)
@@ -979,8 +1232,7 @@ func (m *Model) updateBoardView(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		if task := m.selectedBoardTask(columns); task != nil {
 			switch task.Status {
 			case model.StatusTodo:
-				task.Status = model.StatusDoing
-				return m, updateTask(m.store, task)
+				return m, startTask(m.store, task)
 			case model.StatusDoing:
 				return m, completeTask(m.store, task.ID)
 			}
@@ -991,9 +1243,7 @@ func (m *Model) updateBoardView(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		if task := m.selectedBoardTask(columns); task != nil {
 			switch task.Status {
 			case model.StatusDone:
-				task.Status = model.StatusDoing
-				task.CompletedAt = nil
-				return m, updateTask(m.store, task)
+				return m, startTask(m.store, task)
 			case model.StatusDoing:
 				task.Status = model.StatusTodo
 				return m, updateTask(m.store, task)
@@ -1075,7 +1325,7 @@ func (m Model) View() string {
 			BorderForeground(styles.Primary).
 			Padding(0, 1).
 			Width(innerWidth - 4).
-			Render(styles.InputPrompt.Render(m.inputPrompt) + m.textInput.View())
+			Render(styles.InputPrompt.Render(m.inputPrompt) + m.textInput.View() + m.renderQuickAddPreview())
 		b.WriteString(inputBox)
 		b.WriteString("\n\n")
 	}
@@ -1152,6 +1402,8 @@ func (m Model) renderOverlay() string {
 		return m.renderTagCreateOverlay()
 	case OverlayRecurrenceSelect:
 		return m.renderRecurrenceSelectOverlay()
+	case OverlayRecurrenceCustom:
+		return m.renderRecurrenceCustomOverlay()
 	case OverlayTaskDetail:
 		return m.renderTaskDetailOverlay()
 	case OverlayThemeSelect:
@@ -1169,6 +1421,7 @@ func (m Model) renderHelpOverlay() string {
 		styles.HelpKey.Render("Navigation"),
 		"  ↑/k, ↓/j    Move up/down",
 		"  ←/h, →/l    Move between columns (board)",
+		"              Collapse/expand subtasks (list)",
 		"  Tab         Switch view (List/Board)",
 		"",
 		styles.HelpKey.Render("Status"),
@@ -1177,9 +1430,13 @@ func (m Model) renderHelpOverlay() string {
 		"",
 		styles.HelpKey.Render("Actions"),
 		"  a           Add new task",
+		"  s           Add subtask to selected task",
 		"  e           Edit task title",
+		"  E           Edit task in $EDITOR",
 		"  D           Toggle done",
 		"  x           Delete task",
+		"  w           Start/stop timer",
+		"  u / Ctrl+R  Undo / redo",
 		"  d           Set due date",
 		"  t           Set tags",
 		"  r           Set recurrence",
@@ -1188,9 +1445,11 @@ func (m Model) renderHelpOverlay() string {
 		"",
 		styles.HelpKey.Render("Filter & Search"),
 		"  /           Search tasks",
-		"  p           Select project",
+		"  f           Filter tasks (e.g. tag:work due<+7d)",
+		"  p           Select project or saved view",
+		"  o           Cycle sort order",
 		"  A           Toggle Done section",
-		"  c           Clear search",
+		"  c           Clear search and filter",
 		"",
 		styles.HelpKey.Render("General"),
 		"  ?           Show this help",
@@ -1209,7 +1468,7 @@ func (m Model) renderHelpOverlay() string {
 }
 
 func (m Model) renderProjectSelectOverlay() string {
-	title := styles.Header.Render("Select Project")
+	title := styles.Header.Render("Select Project or View")
 
 	var items []string
 	items = append(items, title, "")
@@ -1231,6 +1490,23 @@ func (m Model) renderProjectSelectOverlay() string {
 		items = append(items, style.Render(text))
 	}
 
+	// Saved views
+	if len(m.views) > 0 {
+		items = append(items, "", styles.MutedStyle.Render("Views"))
+	}
+	for i, view := range m.views {
+		style := styles.TaskItem
+		if m.overlayCursor == len(m.projects)+i+1 {
+			style = styles.TaskItemSelected
+		}
+		query := view.Query
+		if n := len([]rune(query)) - 28; n > 0 {
+			query = truncateRunes(query, n+1) + "…"
+		}
+		text := "@" + view.Name + "  " + styles.MutedStyle.Render(query)
+		items = append(items, style.Render(text))
+	}
+
 	items = append(items, "", styles.MutedStyle.Render("Enter: select  n: new  x: delete  Esc: cancel"))
 
 	content := strings.Join(items, "\n")
@@ -1296,11 +1572,13 @@ func (m Model) renderConfirmDeleteOverlay() string {
 
 	title := styles.Header.Render("Delete Task?")
 	taskTitle := styles.TaskTitle.Render(task.Title)
+	note := styles.MutedStyle.Render("It can be restored with tsk trash restore.")
 
 	content := strings.Join([]string{
 		title,
 		"",
 		taskTitle,
+		note,
 		"",
 		styles.MutedStyle.Render("y: yes  n: no"),
 	}, "\n")
@@ -1348,7 +1626,7 @@ func (m Model) renderDueDateCustomOverlay() string {
 		Border(lipgloss.NormalBorder()).
 		BorderForeground(styles.Primary).
 		Padding(0, 1).
-		Width(20)
+		Width(28)
 
 	var inputField string
 	if m.dueDateFormValue == "" {
@@ -1359,12 +1637,28 @@ func (m Model) renderDueDateCustomOverlay() string {
 		inputField = inputStyle.Render(m.dueDateFormValue + "▌")
 	}
 
+	// Preview of the parsed date
+	preview := " "
+	if value := strings.TrimSpace(m.dueDateFormValue); value != "" {
+		if due, err := dates.Parse(value); err == nil {
+			layout := "Mon Jan 2, 2006"
+			if dates.HasTime(due) {
+				layout += " 15:04"
+			}
+			preview = styles.AccentStyle.Render("→ " + due.Format(layout))
+		} else {
+			preview = styles.MutedStyle.Render("→ ?")
+		}
+	}
+
 	content := strings.Join([]string{
 		title,
 		"",
-		"Format: YYYY-MM-DD",
+		"e.g. fri, next monday, tomorrow 9am,",
+		"in 2 weeks, eom, w43, YYYY-MM-DD",
 		"",
 		inputField,
+		preview,
 		"",
 		styles.MutedStyle.Render("Tab: autocomplete  Enter: confirm  Esc: back"),
 	}, "\n")
@@ -1526,7 +1820,11 @@ func (m Model) renderTaskDetailOverlay() string {
 	// Due Date
 	lines = append(lines, styles.HelpKey.Render("Due Date"))
 	if task.DueDate != nil {
-		lines = append(lines, "  "+task.DueDate.Format("2006-01-02 (Mon)"))
+		layout := "2006-01-02 (Mon)"
+		if dates.HasTime(*task.DueDate) {
+			layout = "2006-01-02 15:04 (Mon)"
+		}
+		lines = append(lines, "  "+task.DueDate.Format(layout))
 	} else {
 		lines = append(lines, "  Not set")
 	}
@@ -1566,10 +1864,68 @@ func (m Model) renderTaskDetailOverlay() string {
 	}
 	lines = append(lines, "")
 
+	// Blocked by
+	if blockers, err := m.store.GetBlockers(task.ID); err == nil && len(blockers) > 0 {
+		lines = append(lines, styles.HelpKey.Render("Blocked By"))
+		for _, b := range blockers {
+			text := fmt.Sprintf("  #%d %s", b.ID, b.Title)
+			if b.Status == model.StatusDone {
+				text = styles.MutedStyle.Render(text + " ✓")
+			}
+			lines = append(lines, text)
+		}
+		lines = append(lines, "")
+	}
+
+	// Time tracked
+	if tracked, err := m.store.TrackedTime(task.ID); err == nil && tracked > 0 {
+		lines = append(lines, styles.HelpKey.Render("Time Tracked"))
+		text := "  " + model.FormatDuration(tracked)
+		if m.timer != nil && m.timer.TaskID == task.ID {
+			text += styles.MutedStyle.Render(" (running)")
+		}
+		lines = append(lines, text, "")
+	}
+
+	// Pending reminders
+	if reminders, err := m.store.ListReminders(task.ID); err == nil {
+		var pending []string
+		for _, r := range reminders {
+			if r.FiredAt != nil {
+				continue
+			}
+			text := "  " + r.Describe()
+			if at, ok := r.Time(task.DueDate); ok && r.At == nil {
+				text += styles.MutedStyle.Render(" (" + at.Local().Format("Mon 15:04") + ")")
+			}
+			pending = append(pending, text)
+		}
+		if len(pending) > 0 {
+			lines = append(lines, styles.HelpKey.Render("Reminders"))
+			lines = append(lines, pending...)
+			lines = append(lines, "")
+		}
+	}
+
 	// Created At
 	lines = append(lines, styles.HelpKey.Render("Created At"))
 	lines = append(lines, "  "+task.CreatedAt.Format("2006-01-02 15:04"), "")
 
+	// History (most recent events)
+	if events, err := m.store.GetTaskEvents(task.ID); err == nil && len(events) > 0 {
+		lines = append(lines, styles.HelpKey.Render("History"))
+		const maxEvents = 5
+		if len(events) > maxEvents {
+			lines = append(lines, styles.MutedStyle.Render(fmt.Sprintf("  … %d earlier (tsk log %d)", len(events)-maxEvents, task.ID)))
+			events = events[len(events)-maxEvents:]
+		}
+		for _, e := range events {
+			when := styles.MutedStyle.Render(e.CreatedAt.Local().Format("01-02 15:04"))
+			lines = append(lines, lipgloss.NewStyle().Width(56).Render("  "+when+" "+e.Describe()))
+		}
+		lines = append(lines, "")
+	}
+
 	lines = append(lines, styles.MutedStyle.Render("Press any key to close"))
 
 	content := strings.Join(lines, "\n")
@@ -1590,7 +1946,7 @@ func (m Model) renderConfirmDeleteTagOverlay() string {
 
 	title := styles.Header.Render("Delete Tag?")
 	tagName := styles.Tag.Render(tag.Name)
-	warning := styles.MutedStyle.Render("This will remove the tag from all tasks.")
+	warning := styles.MutedStyle.Render("The tag will be hidden from all tasks until restored.")
 
 	content := strings.Join([]string{
 		title,
@@ -1634,6 +1990,18 @@ func (m Model) renderConfirmDeleteProjectOverlay() string {
 		Render(content)
 }
 
+// recurrencePresets are the rules offered by the recurrence overlay
+var recurrencePresets = []struct {
+	name string
+	rule model.RRule
+}{
+	{"Daily", model.RRule{Freq: model.Daily, Interval: 1}},
+	{"Weekdays", model.RRule{Freq: model.Weekly, Interval: 1, ByDay: model.Weekdays}},
+	{"Weekly", model.RRule{Freq: model.Weekly, Interval: 1}},
+	{"Monthly", model.RRule{Freq: model.Monthly, Interval: 1}},
+	{"Yearly", model.RRule{Freq: model.Yearly, Interval: 1}},
+}
+
 func (m Model) renderRecurrenceSelectOverlay() string {
 	task := m.selectedTask()
 	if task == nil {
@@ -1649,7 +2017,11 @@ func (m Model) renderRecurrenceSelectOverlay() string {
 		currentPattern = rec.PatternString()
 	}
 
-	options := []string{"Daily", "Weekly", "Monthly", "Yearly"}
+	var options []string
+	for _, p := range recurrencePresets {
+		options = append(options, p.name)
+	}
+	options = append(options, "Custom...")
 	if hasRecurrence {
 		options = append(options, "Remove")
 	}
@@ -1668,7 +2040,7 @@ func (m Model) renderRecurrenceSelectOverlay() string {
 		}
 
 		prefix := "  "
-		if i == 4 {
+		if i == len(recurrencePresets)+1 {
 			// Remove option
 			items = append(items, styles.MutedStyle.Render("─────────"))
 			prefix = "  "
@@ -1687,6 +2059,53 @@ func (m Model) renderRecurrenceSelectOverlay() string {
 		Render(content)
 }
 
+func (m Model) renderRecurrenceCustomOverlay() string {
+	title := styles.Header.Render("Custom Recurrence")
+
+	inputStyle := lipgloss.NewStyle().
+		Border(lipgloss.NormalBorder()).
+		BorderForeground(styles.Primary).
+		Padding(0, 1).
+		Width(32)
+	inputField := inputStyle.Render(m.recurrenceFormValue + "▌")
+
+	// Preview of the parsed rule and its next occurrence
+	preview := " "
+	if value := strings.TrimSpace(m.recurrenceFormValue); value != "" {
+		if rule, err := quickadd.ParseRepeat(value); err == nil {
+			text := "→ " + rule.Describe()
+			if task := m.selectedTask(); task != nil {
+				rec := model.NewRecurrence(task.ID, rule)
+				rec.Schedule(task.DueDate)
+				text += " (next " + rec.NextDue.Format("Mon Jan 2") + ")"
+			}
+			preview = styles.AccentStyle.Render(text)
+		} else {
+			preview = styles.MutedStyle.Render("→ ?")
+		}
+	}
+
+	content := strings.Join([]string{
+		title,
+		"",
+		"e.g. mon,wed,fri, 2w, last fri, 15th,",
+		"last weekday, every 2 months on 1st mon,",
+		"weekly until dec 31, daily 10 times,",
+		"FREQ=MONTHLY;BYDAY=-1FR",
+		"",
+		inputField,
+		preview,
+		"",
+		styles.MutedStyle.Render("Enter: confirm  Esc: back"),
+	}, "\n")
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(styles.Primary).
+		Padding(1, 2).
+		Render(content)
+}
+
 func (m Model) renderThemeSelectOverlay() string {
 	title := styles.Header.Render("Select Theme")
 
@@ -1767,10 +2186,13 @@ func (m Model) renderHeaderWithWidth(width int) string {
 	if m.searchQuery != "" {
 		searchBadge = " " + styles.SearchBadge.Render("/" + m.searchQuery)
 	}
+	if m.filterQuery != "" {
+		searchBadge += " " + styles.SearchBadge.Render("≡ " + m.filterQuery)
+	}
 
 	// Task count
 	var taskCount string
-	activeCount := len(m.activeTasks)
+	activeCount := m.totalTasks - m.doneTaskCount
 	if m.doneTaskCount > 0 {
 		taskCount = styles.MutedStyle.Render(fmt.Sprintf(" %d tasks, %d done", activeCount, m.doneTaskCount))
 	} else {
@@ -1778,7 +2200,13 @@ func (m Model) renderHeaderWithWidth(width int) string {
 	}
 
 	left := lipgloss.JoinHorizontal(lipgloss.Center, title, "  ", tabs)
-	right := lipgloss.JoinHorizontal(lipgloss.Center, projectBadge, searchBadge, taskCount)
+	// Sort indicator
+	sortBadge := ""
+	if m.sortBy != "" {
+		sortBadge = styles.MutedStyle.Render(" ↕ " + m.sortBy)
+	}
+
+	right := lipgloss.JoinHorizontal(lipgloss.Center, projectBadge, searchBadge, sortBadge, taskCount)
 
 	// Create header bar
 	gap := width - lipgloss.Width(left) - lipgloss.Width(right)
@@ -1796,7 +2224,7 @@ func (m Model) renderListView() string {
 func (m Model) renderListViewWithSize(width, height int) string {
 	if len(m.activeTasks) == 0 && len(m.doneTasksList) == 0 {
 		msg := "No tasks. Press 'a' to add one."
-		if m.searchQuery != "" {
+		if m.searchQuery != "" || m.filterQuery != "" {
 			msg = "No tasks match your search. Press 'c' to clear."
 		}
 		return styles.Empty.Width(width).Render(msg)
@@ -2059,6 +2487,7 @@ func (m Model) renderTaskItemWithWidth(task model.Task, selected bool, width int
 	if task.Status == model.StatusDone {
 		titleStyle = styles.TaskTitleDone
 	}
+	blocked := task.Blocked && task.Status != model.StatusDone
 
 	maxTitleLen := width - 20
 	if maxTitleLen < 20 {
@@ -2069,7 +2498,7 @@ func (m Model) renderTaskItemWithWidth(task model.Task, selected bool, width int
 	if len(titleRunes) > maxTitleLen {
 		title = string(titleRunes[:maxTitleLen-3]) + "..."
 	}
-	title = titleStyle.Render(title)
+	title = m.renderTitle(title, titleStyle)
 
 	var due string
 	if task.DueDate != nil && task.Status != model.StatusDone {
@@ -2086,8 +2515,28 @@ func (m Model) renderTaskItemWithWidth(task model.Task, selected bool, width int
 		tags += styles.Tag.Render(tag.Name)
 	}
 
-	// Build suffix (due/completed/tags)
+	// Tree prefix: indentation by depth and an expand marker for tasks
+	// with subtasks (only when any subtasks are loaded)
+	var tree string
+	if len(m.subtasks) > 0 {
+		marker := " "
+		if len(m.subtasks[task.ID]) > 0 {
+			marker = "▸"
+			if m.expanded[task.ID] {
+				marker = "▾"
+			}
+		}
+		tree = strings.Repeat("  ", m.taskDepth[task.ID]) + styles.MutedStyle.Render(marker) + " "
+	}
+
+	// Build suffix (progress/blocked/due/completed/tags)
 	var suffix string
+	if task.SubtaskCount > 0 {
+		suffix += " " + styles.MutedStyle.Render(fmt.Sprintf("%d/%d", task.SubtasksDone, task.SubtaskCount))
+	}
+	if blocked {
+		suffix += " " + styles.MutedStyle.Render("⊘ blocked")
+	}
 	if due != "" {
 		suffix += " " + due
 	}
@@ -2099,10 +2548,10 @@ func (m Model) renderTaskItemWithWidth(task model.Task, selected bool, width int
 	}
 
 	// Rebuild if line too long
-	line := fmt.Sprintf("%s %s %s%s", statusIcon, priority, title, suffix)
+	line := fmt.Sprintf("%s%s %s %s%s", tree, statusIcon, priority, title, suffix)
 	if lipgloss.Width(line) > width {
 		// Recalculate title length
-		prefixLen := 5 // status + priority + spaces
+		prefixLen := 5 + lipgloss.Width(tree) // tree + status + priority + spaces
 		suffixLen := lipgloss.Width(suffix)
 		availableForTitle := width - prefixLen - suffixLen - 3
 		if availableForTitle < 10 {
@@ -2112,8 +2561,8 @@ func (m Model) renderTaskItemWithWidth(task model.Task, selected bool, width int
 		for lipgloss.Width(title) > availableForTitle && len([]rune(title)) > 0 {
 			title = truncateRunes(title, 1)
 		}
-		title = titleStyle.Render(title + "...")
-		line = fmt.Sprintf("%s %s %s%s", statusIcon, priority, title, suffix)
+		title = m.renderTitle(title+"...", titleStyle)
+		line = fmt.Sprintf("%s%s %s %s%s", tree, statusIcon, priority, title, suffix)
 	}
 
 	if selected {
@@ -2122,6 +2571,34 @@ func (m Model) renderTaskItemWithWidth(task model.Task, selected bool, width int
 	return styles.TaskItem.Render(line)
 }
 
+// renderTitle renders a task title, highlighting the words matched by the
+// current search
+func (m Model) renderTitle(title string, style lipgloss.Style) string {
+	if m.searchQuery == "" {
+		return style.Render(title)
+	}
+	mark := func(s string) string { return search.MarkStart + s + search.MarkEnd }
+	marked := search.Highlight(title, search.Terms(m.searchQuery), mark)
+
+	var b strings.Builder
+	for {
+		start := strings.Index(marked, search.MarkStart)
+		if start < 0 {
+			break
+		}
+		end := strings.Index(marked, search.MarkEnd)
+		if start > 0 {
+			b.WriteString(style.Render(marked[:start]))
+		}
+		b.WriteString(styles.SearchMatch.Render(marked[start+len(search.MarkStart) : end]))
+		marked = marked[end+len(search.MarkEnd):]
+	}
+	if marked != "" {
+		b.WriteString(style.Render(marked))
+	}
+	return b.String()
+}
+
 func (m Model) renderBoardTaskItem(task model.Task, selected bool, maxWidth int) string {
 	// Build single line: priority + title + due/completed
 	var priority string
@@ -2145,6 +2622,14 @@ func (m Model) renderBoardTaskItem(task model.Task, selected bool, maxWidth int)
 		}
 	}
 
+	if task.Blocked && task.Status != model.StatusDone {
+		if selected {
+			priority += "⊘ "
+		} else {
+			priority += styles.TaskTitleBlocked.Render("⊘") + " "
+		}
+	}
+
 	// Due/completed suffix
 	var suffix string
 	if task.Status == model.StatusDone {
@@ -2177,6 +2662,15 @@ func (m Model) renderBoardTaskItem(task model.Task, selected bool, maxWidth int)
 		}
 	}
 
+	// Subtask progress
+	if task.SubtaskCount > 0 {
+		progress := fmt.Sprintf("%d/%d", task.SubtasksDone, task.SubtaskCount)
+		if !selected {
+			progress = styles.MutedStyle.Render(progress)
+		}
+		suffix = " " + progress + suffix
+	}
+
 	// Calculate available width for title
 	// Priority takes ~2 chars, suffix varies
 	suffixLen := lipgloss.Width(suffix)
@@ -2199,6 +2693,8 @@ func (m Model) renderBoardTaskItem(task model.Task, selected bool, maxWidth int)
 		titleText = title
 	} else if task.Status == model.StatusDone {
 		titleText = styles.TaskTitleDone.Render(title)
+	} else if task.Blocked {
+		titleText = styles.TaskTitleBlocked.Render(title)
 	} else {
 		titleText = styles.TaskTitle.Render(title)
 	}
@@ -2221,6 +2717,8 @@ func (m Model) renderBoardTaskItem(task model.Task, selected bool, maxWidth int)
 			titleText = title
 		} else if task.Status == model.StatusDone {
 			titleText = styles.TaskTitleDone.Render(title)
+		} else if task.Blocked {
+			titleText = styles.TaskTitleBlocked.Render(title)
 		} else {
 			titleText = styles.TaskTitle.Render(title)
 		}
@@ -2265,6 +2763,9 @@ func (m Model) formatDue(task model.Task) string {
 		text = due.Format("06/1/2")
 		dueStyle = styles.DueNormal
 	}
+	if dates.HasTime(due) {
+		text += due.Format(" 15:04")
+	}
 
 	return dueStyle.Render("by " + text)
 }
@@ -2306,6 +2807,17 @@ func (m Model) renderStatusBar() string {
 	}
 	helpText := strings.Join(help, "  ")
 
+	// Running timer
+	if m.timer != nil {
+		title := m.timerTask
+		if len([]rune(title)) > 24 {
+			title = string([]rune(title)[:21]) + "..."
+		}
+		elapsed := m.timer.Duration(time.Now()) / time.Second
+		helpText += "  " + styles.AccentStyle.Render(fmt.Sprintf("⏱ %s %d:%02d:%02d",
+			title, elapsed/3600, elapsed/60%60, elapsed%60))
+	}
+
 	if m.statusText != "" {
 		// Show help on left, status message on right
 		var statusStyled string
@@ -2320,6 +2832,55 @@ func (m Model) renderStatusBar() string {
 	return styles.StatusBar.Render(helpText)
 }
 
+// renderQuickAddPreview shows the attributes parsed from the text input
+// while adding a task, on a line below it
+func (m Model) renderQuickAddPreview() string {
+	if m.inputMode != InputAdd && m.inputMode != InputAddSubtask {
+		return ""
+	}
+	value := strings.TrimSpace(m.textInput.Value())
+	if value == "" {
+		return ""
+	}
+
+	q, err := quickadd.Parse(value)
+	if err != nil {
+		return "\n" + lipgloss.NewStyle().Foreground(styles.Danger).Render("⚠ "+err.Error())
+	}
+
+	parts := []string{styles.MutedStyle.Render("→ ") + q.Title}
+	if q.Project != "" {
+		found := false
+		for _, p := range m.projects {
+			if strings.EqualFold(p.Name, q.Project) {
+				found = true
+				break
+			}
+		}
+		if found {
+			parts = append(parts, styles.AccentStyle.Render("+"+q.Project))
+		} else {
+			parts = append(parts, lipgloss.NewStyle().Foreground(styles.Danger).Render("+"+q.Project+" (not found)"))
+		}
+	}
+	for _, tag := range q.Tags {
+		parts = append(parts, styles.Tag.Render(tag))
+	}
+	if q.Priority != nil && *q.Priority != model.PriorityNone {
+		parts = append(parts, styles.MutedStyle.Render(q.Priority.Icon()+" "+q.Priority.String()))
+	}
+	if q.Due != nil {
+		parts = append(parts, styles.MutedStyle.Render("due "+q.Due.Format("Mon Jan 2")))
+	}
+	if q.Repeat != nil {
+		parts = append(parts, styles.MutedStyle.Render("↻ "+q.Repeat.PatternString()))
+	}
+	if q.ParentID != 0 {
+		parts = append(parts, styles.MutedStyle.Render(fmt.Sprintf("subtask of #%d", q.ParentID)))
+	}
+	return "\n" + strings.Join(parts, "  ")
+}
+
 // Helper methods
 
 // truncateRunes removes count runes from the end of string, preserving UTF-8 characters
@@ -2396,6 +2957,52 @@ func (m *Model) clampDoneCursor() {
 	}
 }
 
+// buildListRows flattens top-level tasks and their expanded subtasks into the
+// active and done sections of the list view
+func (m *Model) buildListRows() {
+	m.activeTasks = nil
+	m.doneTasksList = nil
+	m.doneTaskCount = 0
+	m.taskDepth = make(map[int64]int)
+	for _, t := range m.tasks {
+		if t.Status == model.StatusDone {
+			m.doneTasksList = m.appendTaskRows(m.doneTasksList, t, 0)
+			m.doneTaskCount++
+		} else {
+			m.activeTasks = m.appendTaskRows(m.activeTasks, t, 0)
+		}
+	}
+}
+
+func (m *Model) appendTaskRows(rows []model.Task, t model.Task, depth int) []model.Task {
+	rows = append(rows, t)
+	m.taskDepth[t.ID] = depth
+	if m.expanded[t.ID] {
+		for _, sub := range m.subtasks[t.ID] {
+			rows = m.appendTaskRows(rows, sub, depth+1)
+		}
+	}
+	return rows
+}
+
+// selectListTask moves the list cursor to the given task if it is visible
+func (m *Model) selectListTask(id int64) {
+	for i, t := range m.activeTasks {
+		if t.ID == id {
+			m.inDoneSection = false
+			m.cursor = i
+			return
+		}
+	}
+	for i, t := range m.doneTasksList {
+		if t.ID == id {
+			m.inDoneSection = true
+			m.doneCursor = i
+			return
+		}
+	}
+}
+
 func (m *Model) clampBoardCursor(columns [3][]model.Task) {
 	col := columns[m.boardCol]
 	if m.boardCursors[m.boardCol] >= len(col) {
@@ -2427,9 +3034,29 @@ func (m *Model) updateBoardScroll() {
 }
 
 func (m Model) reloadTasks() tea.Cmd {
-	filter := store.TaskFilter{
+	taskFilter := store.TaskFilter{
 		ProjectID: m.currentProject,
 		Search:    m.searchQuery,
+		AllLevels: true,
+	}
+	// A sort saved by an older or newer version may not parse
+	taskFilter.Sort, _ = store.ParseSort(m.sortBy)
+
+	// Searches with field conditions such as tag:work are filters, parsed
+	// on each load so relative dates stay current
+	var query filter.And
+	if m.currentView != "" {
+		query = append(query, filter.View{Name: m.currentView})
+	}
+	if e, err := filter.Parse(m.searchQuery); err == nil && filter.Mentions(e, filter.Fields()...) {
+		query = append(query, e)
+		taskFilter.Search = ""
+	}
+	if e, err := filter.Parse(m.filterQuery); err == nil && e != nil {
+		query = append(query, e)
+	}
+	if len(query) > 0 {
+		taskFilter.Query = query
 	}
-	return loadTasks(m.store, filter)
+	return tea.Batch(loadTasks(m.store, taskFilter), loadTimer(m.store))
 }
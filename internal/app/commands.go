@@ -1,12 +1,18 @@
 package app
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 
 	"github.com/hwanchang/tsk/internal/model"
+	"github.com/hwanchang/tsk/internal/quickadd"
 	"github.com/hwanchang/tsk/internal/store"
+	"github.com/hwanchang/tsk/internal/taskdoc"
 )
 
 func loadTasks(st *store.SQLiteStore, filter store.TaskFilter) tea.Cmd {
@@ -29,6 +35,16 @@ func loadProjects(st *store.SQLiteStore) tea.Cmd {
 	}
 }
 
+func loadViews(st *store.SQLiteStore) tea.Cmd {
+	return func() tea.Msg {
+		views, err := st.ListViews()
+		if err != nil {
+			return ErrorMsg{Err: err}
+		}
+		return ViewsLoadedMsg{Views: views}
+	}
+}
+
 func loadTags(st *store.SQLiteStore) tea.Cmd {
 	return func() tea.Msg {
 		tags, err := st.ListTags()
@@ -39,11 +55,61 @@ func loadTags(st *store.SQLiteStore) tea.Cmd {
 	}
 }
 
-func createTask(st *store.SQLiteStore, title string, projectID *int64) tea.Cmd {
+// createTask creates a task from quick-add input. Inline +project and >parent
+// attributes override projectID and parentID; subtasks default to their
+// parent's project.
+func createTask(st *store.SQLiteStore, q *quickadd.Result, projectID, parentID *int64) tea.Cmd {
 	return func() tea.Msg {
-		task := model.NewTask(title)
+		task := model.NewTask(q.Title)
 		task.ProjectID = projectID
-		if err := st.CreateTask(task); err != nil {
+		task.ParentID = parentID
+		task.DueDate = q.Due
+		if q.Priority != nil {
+			task.Priority = *q.Priority
+		}
+
+		err := st.WithTx(func(tx store.Store) error {
+			if q.ParentID != 0 {
+				parent, err := tx.GetTask(q.ParentID)
+				if err != nil {
+					return err
+				}
+				task.ParentID = &parent.ID
+				task.ProjectID = parent.ProjectID
+			}
+			if q.Project != "" {
+				project, err := tx.GetProjectByName(q.Project)
+				if err != nil {
+					return err
+				}
+				task.ProjectID = &project.ID
+			}
+
+			if err := tx.CreateTask(task); err != nil {
+				return err
+			}
+
+			for _, name := range q.Tags {
+				tag, err := tx.GetOrCreateTag(name)
+				if err != nil {
+					return err
+				}
+				if err := tx.AddTagToTask(task.ID, tag.ID); err != nil {
+					return err
+				}
+			}
+
+			if q.Repeat != nil {
+				rec := *q.Repeat
+				rec.TaskID = task.ID
+				rec.Schedule(task.DueDate)
+				if err := tx.SetRecurrence(&rec); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
 			return ErrorMsg{Err: err}
 		}
 		return TaskCreatedMsg{Task: task}
@@ -59,6 +125,75 @@ func updateTask(st *store.SQLiteStore, task *model.Task) tea.Cmd {
 	}
 }
 
+// startTask moves a task to doing, even if it's blocked; the caller warns
+// about that when handling TaskStartedMsg
+func startTask(st *store.SQLiteStore, task *model.Task) tea.Cmd {
+	return func() tea.Msg {
+		task.MarkDoing()
+		if err := st.UpdateTask(task); err != nil {
+			return ErrorMsg{Err: err}
+		}
+		return TaskStartedMsg{Task: task}
+	}
+}
+
+// editTaskInEditor suspends the TUI and opens the task in $EDITOR.
+// content is the document to edit, or nil to load it from the store.
+func editTaskInEditor(st *store.SQLiteStore, taskID int64, content []byte) tea.Cmd {
+	return func() tea.Msg {
+		text, original := content, []byte(nil)
+		if text == nil {
+			doc, err := taskdoc.Load(st, taskID)
+			if err != nil {
+				return ErrorMsg{Err: err}
+			}
+			original = doc.Marshal()
+			text = original
+		}
+
+		path, err := taskdoc.WriteTemp(text)
+		if err != nil {
+			return ErrorMsg{Err: err}
+		}
+		return tea.ExecProcess(taskdoc.EditorCmd(path), editorDone(st, taskID, path, original))()
+	}
+}
+
+// editorDone applies the document saved at path once the editor exits
+func editorDone(st *store.SQLiteStore, taskID int64, path string, original []byte) tea.ExecCallback {
+	return func(err error) tea.Msg {
+		defer os.Remove(path)
+		if err != nil {
+			return ErrorMsg{Err: fmt.Errorf("run editor: %w", err)}
+		}
+
+		edited, err := os.ReadFile(path)
+		if err != nil {
+			return ErrorMsg{Err: err}
+		}
+		if len(bytes.TrimSpace(edited)) == 0 {
+			return StatusMsg{Text: "Edit cancelled"}
+		}
+		if original != nil && bytes.Equal(edited, original) {
+			return StatusMsg{Text: "No changes"}
+		}
+
+		doc, err := taskdoc.Parse(edited)
+		if err == nil {
+			doc.TaskID = taskID
+			err = taskdoc.Apply(st, doc)
+		}
+		var parseErr *taskdoc.ParseError
+		if errors.As(err, &parseErr) {
+			return EditorParseErrorMsg{TaskID: taskID, Content: taskdoc.Annotate(edited, err)}
+		}
+		if err != nil {
+			return ErrorMsg{Err: err}
+		}
+		return TaskUpdatedMsg{Task: nil}
+	}
+}
+
 func completeTask(st *store.SQLiteStore, taskID int64) tea.Cmd {
 	return func() tea.Msg {
 		if err := st.CompleteTaskWithRecurrence(taskID); err != nil {
@@ -77,6 +212,78 @@ func deleteTask(st *store.SQLiteStore, id int64) tea.Cmd {
 	}
 }
 
+func loadTimer(st *store.SQLiteStore) tea.Cmd {
+	return func() tea.Msg {
+		entry, err := st.RunningTimer()
+		if err != nil {
+			return ErrorMsg{Err: err}
+		}
+		if entry == nil {
+			return TimerLoadedMsg{}
+		}
+		task, err := st.GetTask(entry.TaskID)
+		if err != nil {
+			return ErrorMsg{Err: err}
+		}
+		return TimerLoadedMsg{Entry: entry, Task: task}
+	}
+}
+
+// toggleTimer stops the task's timer if it's running, otherwise starts it
+// (stopping any other running timer)
+func toggleTimer(st *store.SQLiteStore, taskID int64) tea.Cmd {
+	return func() tea.Msg {
+		running, err := st.RunningTimer()
+		if err != nil {
+			return ErrorMsg{Err: err}
+		}
+		if running != nil && running.TaskID == taskID {
+			entry, err := st.StopTimer()
+			if err != nil {
+				return ErrorMsg{Err: err}
+			}
+			return TimerToggledMsg{Entry: entry}
+		}
+		entry, err := st.StartTimer(taskID)
+		if err != nil {
+			return ErrorMsg{Err: err}
+		}
+		return TimerToggledMsg{Entry: entry, Started: true}
+	}
+}
+
+func tickTimer() tea.Cmd {
+	return tea.Tick(time.Second, func(t time.Time) tea.Msg {
+		return TimerTickMsg{}
+	})
+}
+
+func undo(st *store.SQLiteStore) tea.Cmd {
+	return func() tea.Msg {
+		label, err := st.Undo()
+		if errors.Is(err, store.ErrNothingToUndo) {
+			return StatusMsg{Text: "Nothing to undo"}
+		}
+		if err != nil {
+			return ErrorMsg{Err: err}
+		}
+		return UndoneMsg{Label: label}
+	}
+}
+
+func redo(st *store.SQLiteStore) tea.Cmd {
+	return func() tea.Msg {
+		label, err := st.Redo()
+		if errors.Is(err, store.ErrNothingToRedo) {
+			return StatusMsg{Text: "Nothing to redo"}
+		}
+		if err != nil {
+			return ErrorMsg{Err: err}
+		}
+		return UndoneMsg{Label: label, Redo: true}
+	}
+}
+
 func clearStatusAfter(d time.Duration) tea.Cmd {
 	return tea.Tick(d, func(t time.Time) tea.Msg {
 		return ClearStatusMsg{}
@@ -134,23 +341,16 @@ func createTag(st *store.SQLiteStore, name string) tea.Cmd {
 
 func createTagAndAddToTask(st *store.SQLiteStore, name string, taskID int64) tea.Cmd {
 	return func() tea.Msg {
-		// Check if tag exists
-		existing, err := st.GetTagByName(name)
-		if err != nil {
-			return ErrorMsg{Err: err}
-		}
-
 		var tag *model.Tag
-		if existing != nil {
-			tag = existing
-		} else {
-			tag = model.NewTag(name)
-			if err := st.CreateTag(tag); err != nil {
-				return ErrorMsg{Err: err}
+		err := st.WithTx(func(tx store.Store) error {
+			var err error
+			tag, err = tx.GetOrCreateTag(name)
+			if err != nil {
+				return err
 			}
-		}
-
-		if err := st.AddTagToTask(taskID, tag.ID); err != nil {
+			return tx.AddTagToTask(taskID, tag.ID)
+		})
+		if err != nil {
 			return ErrorMsg{Err: err}
 		}
 		return TagCreatedMsg{Tag: tag}
@@ -175,19 +375,24 @@ func deleteProject(st *store.SQLiteStore, id int64) tea.Cmd {
 	}
 }
 
-func setRecurrence(st *store.SQLiteStore, taskID int64, pattern model.RecurrencePattern, interval int) tea.Cmd {
+func deleteView(st *store.SQLiteStore, name string) tea.Cmd {
 	return func() tea.Msg {
-		rec := model.NewRecurrence(taskID, pattern, interval)
+		if err := st.DeleteView(name); err != nil {
+			return ErrorMsg{Err: err}
+		}
+		return ViewDeletedMsg{Name: name}
+	}
+}
+
+func setRecurrence(st *store.SQLiteStore, taskID int64, rule model.RRule) tea.Cmd {
+	return func() tea.Msg {
+		rec := model.NewRecurrence(taskID, rule)
 		// Get task to determine next due date
 		task, err := st.GetTask(taskID)
 		if err != nil {
 			return ErrorMsg{Err: err}
 		}
-		if task.DueDate != nil {
-			rec.NextDue = rec.CalculateNextDue(*task.DueDate)
-		} else {
-			rec.NextDue = rec.CalculateNextDue(time.Now())
-		}
+		rec.Schedule(task.DueDate)
 		if err := st.SetRecurrence(rec); err != nil {
 			return ErrorMsg{Err: err}
 		}
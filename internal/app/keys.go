@@ -10,10 +10,15 @@ type KeyMap struct {
 	Right key.Binding
 
 	// Actions
-	Add    key.Binding
-	Edit   key.Binding
-	Done   key.Binding
-	Delete key.Binding
+	Add          key.Binding
+	AddSubtask   key.Binding
+	Edit         key.Binding
+	EditExternal key.Binding
+	Done         key.Binding
+	Delete       key.Binding
+	Timer        key.Binding
+	Undo         key.Binding
+	Redo         key.Binding
 
 	// View
 	ToggleView key.Binding
@@ -22,6 +27,7 @@ type KeyMap struct {
 	// Filter
 	Search  key.Binding
 	Filter  key.Binding
+	Sort    key.Binding
 	Project key.Binding
 
 	// Settings
@@ -54,10 +60,18 @@ var Keys = KeyMap{
 		key.WithKeys("a"),
 		key.WithHelp("a", "add"),
 	),
+	AddSubtask: key.NewBinding(
+		key.WithKeys("s"),
+		key.WithHelp("s", "add subtask"),
+	),
 	Edit: key.NewBinding(
 		key.WithKeys("e"),
 		key.WithHelp("e", "edit"),
 	),
+	EditExternal: key.NewBinding(
+		key.WithKeys("E"),
+		key.WithHelp("E", "edit in $EDITOR"),
+	),
 	Done: key.NewBinding(
 		key.WithKeys("D"),
 		key.WithHelp("D", "done"),
@@ -66,6 +80,18 @@ var Keys = KeyMap{
 		key.WithKeys("x"),
 		key.WithHelp("x", "delete"),
 	),
+	Timer: key.NewBinding(
+		key.WithKeys("w"),
+		key.WithHelp("w", "start/stop timer"),
+	),
+	Undo: key.NewBinding(
+		key.WithKeys("u"),
+		key.WithHelp("u", "undo"),
+	),
+	Redo: key.NewBinding(
+		key.WithKeys("ctrl+r"),
+		key.WithHelp("ctrl+r", "redo"),
+	),
 	ToggleView: key.NewBinding(
 		key.WithKeys("tab"),
 		key.WithHelp("tab", "switch view"),
@@ -82,6 +108,10 @@ var Keys = KeyMap{
 		key.WithKeys("f"),
 		key.WithHelp("f", "filter"),
 	),
+	Sort: key.NewBinding(
+		key.WithKeys("o"),
+		key.WithHelp("o", "sort"),
+	),
 	Project: key.NewBinding(
 		key.WithKeys("p"),
 		key.WithHelp("p", "project"),
@@ -111,8 +141,8 @@ func (k KeyMap) ShortHelp() []key.Binding {
 func (k KeyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
 		{k.Up, k.Down, k.Left, k.Right},
-		{k.Add, k.Edit, k.Done, k.Delete},
-		{k.ToggleView, k.Search, k.Project},
+		{k.Add, k.AddSubtask, k.Edit, k.EditExternal, k.Done, k.Delete, k.Timer, k.Undo, k.Redo},
+		{k.ToggleView, k.Search, k.Filter, k.Sort, k.Project},
 		{k.Help, k.Cancel, k.Quit},
 	}
 }
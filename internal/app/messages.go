@@ -21,6 +21,11 @@ type TagsLoadedMsg struct {
 	Tags []model.Tag
 }
 
+// ViewsLoadedMsg is sent when saved views are loaded
+type ViewsLoadedMsg struct {
+	Views []model.View
+}
+
 // TaskCreatedMsg is sent when a new task is created
 type TaskCreatedMsg struct {
 	Task *model.Task
@@ -31,6 +36,11 @@ type TaskUpdatedMsg struct {
 	Task *model.Task
 }
 
+// TaskStartedMsg is sent when a task is moved to doing
+type TaskStartedMsg struct {
+	Task *model.Task
+}
+
 // TaskDeletedMsg is sent when a task is deleted
 type TaskDeletedMsg struct {
 	ID int64
@@ -51,6 +61,11 @@ type TagDeletedMsg struct {
 	ID int64
 }
 
+// ViewDeletedMsg is sent when a saved view is deleted
+type ViewDeletedMsg struct {
+	Name string
+}
+
 // ProjectDeletedMsg is sent when a project is deleted
 type ProjectDeletedMsg struct {
 	ID int64
@@ -66,6 +81,35 @@ type RecurrenceDeletedMsg struct {
 	TaskID int64
 }
 
+// UndoneMsg is sent after an undo or redo; Label describes the change
+type UndoneMsg struct {
+	Label string
+	Redo  bool
+}
+
+// TimerLoadedMsg is sent with the running timer and its task, or nils if no
+// timer is running
+type TimerLoadedMsg struct {
+	Entry *model.TimeEntry
+	Task  *model.Task
+}
+
+// TimerToggledMsg is sent after a timer is started or stopped from the TUI
+type TimerToggledMsg struct {
+	Entry   *model.TimeEntry
+	Started bool
+}
+
+// TimerTickMsg redraws the running timer
+type TimerTickMsg struct{}
+
+// EditorParseErrorMsg is sent when a task edited in $EDITOR can't be applied;
+// Content is the edited document annotated with the error
+type EditorParseErrorMsg struct {
+	TaskID  int64
+	Content []byte
+}
+
 // ErrorMsg is sent when an error occurs
 type ErrorMsg struct {
 	Err error
@@ -2,13 +2,14 @@ package cli
 
 import (
 	"fmt"
-	"strconv"
 	"strings"
-	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/hwanchang/tsk/internal/dates"
 	"github.com/hwanchang/tsk/internal/model"
+	"github.com/hwanchang/tsk/internal/quickadd"
+	"github.com/hwanchang/tsk/internal/store"
 )
 
 func newAddCmd() *cobra.Command {
@@ -18,90 +19,136 @@ func newAddCmd() *cobra.Command {
 		priority    string
 		dueDate     string
 		repeat      string
+		blockedBy   []int64
+		parentID    int64
 	)
 
 	cmd := &cobra.Command{
 		Use:   "add <title>",
 		Short: "Add a new task",
-		Long:  `Add a new task with optional project, tags, priority, and due date.`,
-		Args:  cobra.MinimumNArgs(1),
+		Long: `Add a new task with optional project, tags, priority, and due date.
+
+These can also be given inline in the title:
+
+  +project     project (quote names with spaces: +"side project")
+  #tag         tag (can be repeated)
+  !high, !!    priority (!low, !med, !high; !! is high)
+  due:friday   due date
+  every:2w     recurrence (daily, weekly, 3d, 2w, 1m, ...)
+  >12          subtask of task #12
+
+Prefix a token with \ to keep it in the title, as in \#1. Quote the title,
+since the shell treats # and ! specially. Flags override inline attributes.`,
+		Args: cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			title := strings.Join(args, " ")
+			q, err := quickadd.Parse(strings.Join(args, " "))
+			if err != nil {
+				return err
+			}
 
-			task := model.NewTask(title)
+			task := model.NewTask(q.Title)
+			task.DueDate = q.Due
+			if q.Priority != nil {
+				task.Priority = *q.Priority
+			}
+			if parentID == 0 {
+				parentID = q.ParentID
+			}
+			if projectName == "" {
+				projectName = q.Project
+			}
+			tagNames = append(tagNames, q.Tags...)
+
+			// Set parent; subtasks go in the parent's project by default
+			if parentID != 0 {
+				parent, err := st.GetTask(parentID)
+				if err != nil {
+					return err
+				}
+				task.ParentID = &parent.ID
+				task.ProjectID = parent.ProjectID
+			}
 
 			// Set project
 			if projectName != "" {
-				projects, err := st.ListProjects()
+				project, err := st.GetProjectByName(projectName)
 				if err != nil {
 					return err
 				}
-				for _, p := range projects {
-					if strings.EqualFold(p.Name, projectName) {
-						task.ProjectID = &p.ID
-						break
-					}
-				}
-				if task.ProjectID == nil {
-					return fmt.Errorf("project not found: %s", projectName)
-				}
+				task.ProjectID = &project.ID
 			}
 
 			// Set priority
 			if priority != "" {
-				task.Priority = model.ParsePriority(priority)
+				p, err := model.ParsePriorityName(priority)
+				if err != nil {
+					return err
+				}
+				task.Priority = p
 			}
 
 			// Set due date
 			if dueDate != "" {
-				due, err := parseDate(dueDate)
+				due, err := dates.Parse(dueDate)
 				if err != nil {
 					return fmt.Errorf("invalid date: %w", err)
 				}
 				task.DueDate = &due
 			}
 
-			// Create task
-			if err := st.CreateTask(task); err != nil {
-				return err
+			// Set recurrence; --repeat overrides an inline every:
+			rec := q.Repeat
+			if repeat != "" {
+				rule, err := quickadd.ParseRepeat(repeat)
+				if err != nil {
+					return err
+				}
+				rec = model.NewRecurrence(0, rule)
 			}
 
-			// Add tags
-			for _, tagName := range tagNames {
-				tag, err := st.GetTagByName(tagName)
-				if err != nil {
+			// Create task with its tags and recurrence
+			err = st.WithTx(func(tx store.Store) error {
+				if err := tx.CreateTask(task); err != nil {
 					return err
 				}
-				if tag == nil {
-					// Create tag if not exists
-					tag = model.NewTag(tagName)
-					if err := st.CreateTag(tag); err != nil {
+
+				// Add tags
+				for _, tagName := range tagNames {
+					tag, err := tx.GetOrCreateTag(tagName)
+					if err != nil {
+						return err
+					}
+					if err := tx.AddTagToTask(task.ID, tag.ID); err != nil {
 						return err
 					}
 				}
-				if err := st.AddTagToTask(task.ID, tag.ID); err != nil {
-					return err
-				}
-			}
 
-			// Set recurrence
-			if repeat != "" {
-				pattern, interval := parseRepeat(repeat)
-				rec := model.NewRecurrence(task.ID, pattern, interval)
-				// Set next due based on task's due date or today
-				if task.DueDate != nil {
-					rec.NextDue = rec.CalculateNextDue(*task.DueDate)
-				} else {
-					rec.NextDue = rec.CalculateNextDue(time.Now())
+				if rec != nil {
+					rec.TaskID = task.ID
+					rec.Schedule(task.DueDate)
+					if err := tx.SetRecurrence(rec); err != nil {
+						return err
+					}
 				}
-				if err := st.SetRecurrence(rec); err != nil {
-					return err
+
+				for _, id := range blockedBy {
+					if err := tx.AddDependency(task.ID, id); err != nil {
+						return err
+					}
 				}
+				return nil
+			})
+			if err != nil {
+				return err
 			}
 
-			fmt.Printf("Created task #%d: %s\n", task.ID, task.Title)
-			if repeat != "" {
-				fmt.Printf("  Recurrence: %s\n", repeat)
+			if task.ParentID != nil {
+				fmt.Printf("Created subtask #%d of #%d: %s\n", task.ID, *task.ParentID, task.Title)
+			} else {
+				fmt.Printf("Created task #%d: %s\n", task.ID, task.Title)
+			}
+			if rec != nil {
+				fmt.Printf("  Recurrence: %s\n", rec.PatternString())
 			}
 			return nil
 		},
@@ -109,71 +156,11 @@ func newAddCmd() *cobra.Command {
 
 	cmd.Flags().StringVarP(&projectName, "project", "p", "", "project name")
 	cmd.Flags().StringSliceVarP(&tagNames, "tag", "t", nil, "tags (can be repeated)")
-	cmd.Flags().StringVar(&priority, "priority", "", "priority (low/medium/high)")
-	cmd.Flags().StringVarP(&dueDate, "due", "d", "", "due date (today/tomorrow/YYYY-MM-DD)")
-	cmd.Flags().StringVarP(&repeat, "repeat", "r", "", "recurrence pattern (daily/weekly/monthly/yearly or daily:2 for every 2 days)")
+	cmd.Flags().StringVar(&priority, "priority", "", "priority (none/low/medium/high)")
+	cmd.Flags().StringVarP(&dueDate, "due", "d", "", "due date (e.g. fri, tomorrow 9am, in 2 weeks, eom, YYYY-MM-DD)")
+	cmd.Flags().StringVarP(&repeat, "repeat", "r", "", "recurrence (e.g. daily, 2w, mon,wed,fri, last fri, 15th, or an RRULE)")
+	cmd.Flags().Int64SliceVar(&blockedBy, "blocked-by", nil, "IDs of tasks that must be done first (can be repeated)")
+	cmd.Flags().Int64Var(&parentID, "parent", 0, "add as a subtask of this task")
 
 	return cmd
 }
-
-func parseDate(s string) (time.Time, error) {
-	now := time.Now()
-	today := time.Date(now.Year(), now.Month(), now.Day(), 23, 59, 59, 0, now.Location())
-
-	switch strings.ToLower(s) {
-	case "today":
-		return today, nil
-	case "tomorrow":
-		return today.AddDate(0, 0, 1), nil
-	case "next week", "nextweek":
-		return today.AddDate(0, 0, 7), nil
-	}
-
-	// Try parsing as relative days (e.g., "3d" for 3 days)
-	if strings.HasSuffix(s, "d") {
-		days, err := strconv.Atoi(strings.TrimSuffix(s, "d"))
-		if err == nil {
-			return today.AddDate(0, 0, days), nil
-		}
-	}
-
-	// Try parsing as date
-	formats := []string{
-		"2006-01-02",
-		"01-02",
-		"01/02",
-		"Jan 2",
-		"January 2",
-	}
-
-	for _, format := range formats {
-		if t, err := time.Parse(format, s); err == nil {
-			// If year not specified, use current year
-			if t.Year() == 0 {
-				t = time.Date(now.Year(), t.Month(), t.Day(), 23, 59, 59, 0, now.Location())
-				// If date has passed, use next year
-				if t.Before(now) {
-					t = t.AddDate(1, 0, 0)
-				}
-			}
-			return t, nil
-		}
-	}
-
-	return time.Time{}, fmt.Errorf("unrecognized date format: %s", s)
-}
-
-// parseRepeat parses a recurrence pattern string like "daily" or "daily:2"
-func parseRepeat(s string) (model.RecurrencePattern, int) {
-	parts := strings.Split(s, ":")
-	pattern := model.ParseRecurrencePattern(parts[0])
-	interval := 1
-
-	if len(parts) > 1 {
-		if n, err := strconv.Atoi(parts[1]); err == nil && n > 0 {
-			interval = n
-		}
-	}
-
-	return pattern, interval
-}
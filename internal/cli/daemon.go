@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hwanchang/tsk/internal/notify"
+)
+
+func newDaemonCmd() *cobra.Command {
+	var (
+		interval time.Duration
+		backends []string
+		command  string
+		file     string
+		socket   string
+		once     bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "daemon",
+		Short: "Fire task reminders in the background",
+		Long: `Poll the database and fire reminders as they come due. Reminders are
+delivered through one or more --notify backends:
+
+  stdout   print a line per reminder (the default)
+  command  run --command with sh -c; the reminder is in $TSK_TASK_ID,
+           $TSK_TITLE, $TSK_DUE, and $TSK_MESSAGE
+  file     append a JSON line per reminder to --file
+  socket   send a JSON line per reminder to the Unix socket --socket
+
+A reminder that fails to deliver is retried on the next poll.`,
+		Example: `  tsk daemon
+  tsk daemon --notify command --command 'notify-send tsk "$TSK_MESSAGE"'`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if interval <= 0 {
+				return fmt.Errorf("--interval must be positive")
+			}
+			var notifier notify.Multi
+			for _, name := range backends {
+				switch name {
+				case "stdout":
+					notifier = append(notifier, notify.Stdout{W: os.Stdout})
+				case "command":
+					if command == "" {
+						return fmt.Errorf("--notify command requires --command")
+					}
+					notifier = append(notifier, notify.Command{Shell: command})
+				case "file":
+					if file == "" {
+						return fmt.Errorf("--notify file requires --file")
+					}
+					notifier = append(notifier, notify.File{Path: file})
+				case "socket":
+					if socket == "" {
+						return fmt.Errorf("--notify socket requires --socket")
+					}
+					notifier = append(notifier, notify.Socket{Path: socket})
+				default:
+					return fmt.Errorf("invalid notifier %q (use stdout, command, file, or socket)", name)
+				}
+			}
+
+			if once {
+				return fireReminders(notifier)
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				if err := fireReminders(notifier); err != nil {
+					fmt.Fprintln(os.Stderr, "Error:", err)
+				}
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-ticker.C:
+				}
+			}
+		},
+	}
+
+	cmd.Flags().DurationVar(&interval, "interval", 30*time.Second, "how often to check for due reminders")
+	cmd.Flags().StringSliceVar(&backends, "notify", []string{"stdout"}, "notification backend: stdout, command, file, or socket (repeatable)")
+	cmd.Flags().StringVar(&command, "command", "", "shell command for the command backend")
+	cmd.Flags().StringVar(&file, "file", "", "file for the file backend")
+	cmd.Flags().StringVar(&socket, "socket", "", "Unix socket for the socket backend")
+	cmd.Flags().BoolVar(&once, "once", false, "fire due reminders once and exit")
+
+	return cmd
+}
+
+// fireReminders delivers the reminders that are due and marks them fired.
+// Reminders that fail to deliver stay pending.
+func fireReminders(notifier notify.Notifier) error {
+	now := time.Now()
+	reminders, tasks, err := st.DueReminders(now)
+	if err != nil {
+		return err
+	}
+	for i, r := range reminders {
+		n := notify.Notification{TaskID: tasks[i].ID, Title: tasks[i].Title, Due: tasks[i].DueDate, At: now}
+		if err := notifier.Notify(n); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: reminder %d on task #%d: %v\n", r.ID, r.TaskID, err)
+			continue
+		}
+		if err := st.MarkReminderFired(r.ID, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
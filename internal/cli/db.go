@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hwanchang/tsk/internal/db"
+)
+
+// rawDB is the unmigrated database used by the db subcommands
+var rawDB *db.DB
+
+func newDBCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "db",
+		Short: "Inspect the database",
+		// Open without migrating so outdated or newer databases can be inspected
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			database, err := db.New(resolveDBPath())
+			if err != nil {
+				return fmt.Errorf("open database: %w", err)
+			}
+			rawDB = database
+			return nil
+		},
+		PersistentPostRun: func(cmd *cobra.Command, args []string) {
+			if rawDB != nil {
+				rawDB.Close()
+			}
+		},
+	}
+
+	cmd.AddCommand(newDBStatusCmd())
+
+	return cmd
+}
+
+func newDBStatusCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "List applied and pending schema migrations",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			version, migrations, err := rawDB.MigrationStatus()
+			if err != nil {
+				return err
+			}
+
+			latest := 0
+			if len(migrations) > 0 {
+				latest = migrations[len(migrations)-1].Version
+			}
+
+			fmt.Printf("Database: %s\n", resolveDBPath())
+			fmt.Printf("Schema version: %d (latest: %d)\n", version, latest)
+			if version > latest {
+				fmt.Println("This database was created by a newer version of tsk; please upgrade.")
+			}
+			fmt.Println()
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "VERSION\tNAME\tSTATUS")
+			for _, m := range migrations {
+				status := "pending"
+				if m.Applied {
+					status = "applied"
+				}
+				fmt.Fprintf(w, "%d\t%s\t%s\n", m.Version, m.Name, status)
+			}
+			return w.Flush()
+		},
+	}
+}
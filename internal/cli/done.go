@@ -3,15 +3,24 @@ package cli
 import (
 	"fmt"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+
+	"github.com/hwanchang/tsk/internal/model"
 )
 
 func newDoneCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "done <id>",
 		Short: "Mark a task as done",
-		Args:  cobra.ExactArgs(1),
+		Long: `Mark a task as done. Recurring tasks get their next occurrence created,
+and a timer running on the task is stopped.
+
+With "auto_complete_parent": true in ~/.config/tsk/config.json, completing
+the last open subtask of a task also completes the task.`,
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			id, err := strconv.ParseInt(args[0], 10, 64)
 			if err != nil {
@@ -23,14 +32,34 @@ func newDoneCmd() *cobra.Command {
 				return err
 			}
 
+			var parent *model.Task
+			if task.ParentID != nil {
+				if parent, err = st.GetTask(*task.ParentID); err != nil {
+					return err
+				}
+			}
+
+			timer, err := st.RunningTimer()
+			if err != nil {
+				return err
+			}
+
 			// Check if task has recurrence
 			rec, _ := st.GetRecurrence(id)
 			if rec != nil {
 				// Complete with recurrence handling
+				created := len(rec.Advance(task.DueDate, time.Now()))
 				if err := st.CompleteTaskWithRecurrence(id); err != nil {
 					return err
 				}
-				fmt.Printf("Completed task #%d: %s (next occurrence created)\n", task.ID, task.Title)
+				switch created {
+				case 0:
+					fmt.Printf("Completed task #%d: %s (last occurrence)\n", task.ID, task.Title)
+				case 1:
+					fmt.Printf("Completed task #%d: %s (next occurrence created)\n", task.ID, task.Title)
+				default:
+					fmt.Printf("Completed task #%d: %s (%d occurrences created)\n", task.ID, task.Title, created)
+				}
 			} else {
 				task.MarkDone()
 				if err := st.UpdateTask(task); err != nil {
@@ -39,6 +68,16 @@ func newDoneCmd() *cobra.Command {
 				fmt.Printf("Completed task #%d: %s\n", task.ID, task.Title)
 			}
 
+			if timer != nil && timer.TaskID == task.ID {
+				fmt.Printf("Stopped timer (%s)\n", model.FormatDuration(timer.Duration(time.Now())))
+			}
+
+			// Report a parent completed along with its last subtask
+			if parent != nil && parent.Status != model.StatusDone {
+				if p, err := st.GetTask(parent.ID); err == nil && p.Status == model.StatusDone {
+					fmt.Printf("Completed parent task #%d: %s (all subtasks done)\n", p.ID, p.Title)
+				}
+			}
 			return nil
 		},
 	}
@@ -47,6 +86,8 @@ func newDoneCmd() *cobra.Command {
 }
 
 func newDoingCmd() *cobra.Command {
+	var start bool
+
 	cmd := &cobra.Command{
 		Use:   "doing <id>",
 		Short: "Mark a task as in progress",
@@ -68,9 +109,36 @@ func newDoingCmd() *cobra.Command {
 			}
 
 			fmt.Printf("Started task #%d: %s\n", task.ID, task.Title)
+			if start {
+				if err := startTimer(task.ID); err != nil {
+					return err
+				}
+			}
+			if task.Blocked {
+				return warnBlocked(task)
+			}
 			return nil
 		},
 	}
 
+	cmd.Flags().BoolVarP(&start, "start", "s", false, "also start a timer on the task")
+
 	return cmd
 }
+
+// warnBlocked prints the unfinished tasks that a task is still waiting on
+func warnBlocked(task *model.Task) error {
+	blockers, err := st.GetBlockers(task.ID)
+	if err != nil {
+		return err
+	}
+
+	var pending []string
+	for _, b := range blockers {
+		if b.Status != model.StatusDone {
+			pending = append(pending, fmt.Sprintf("#%d %s", b.ID, b.Title))
+		}
+	}
+	fmt.Printf("Warning: task #%d is blocked by %s\n", task.ID, strings.Join(pending, ", "))
+	return nil
+}
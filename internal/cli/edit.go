@@ -0,0 +1,285 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hwanchang/tsk/internal/dates"
+	"github.com/hwanchang/tsk/internal/model"
+	"github.com/hwanchang/tsk/internal/quickadd"
+	"github.com/hwanchang/tsk/internal/store"
+	"github.com/hwanchang/tsk/internal/taskdoc"
+)
+
+func newEditCmd() *cobra.Command {
+	var (
+		title       string
+		description string
+		projectName string
+		tagNames    []string
+		addTags     []string
+		rmTags      []string
+		priority    string
+		dueDate     string
+		clearDue    bool
+		repeat      string
+		blockedBy   []int64
+		unblockedBy []int64
+		useEditor   bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "edit <id>",
+		Short: "Edit an existing task",
+		Long: `Edit a task's title, description, project, tags, priority, due date, or recurrence.
+
+Only the given flags are changed. --tag replaces all tags on the task,
+while --add-tag and --rm-tag add or remove individual tags.
+Use --priority none to clear the priority and --repeat none to remove recurrence.
+--blocked-by and --rm-blocked-by add or remove dependencies on other tasks.
+
+With --editor, the task is opened in $EDITOR as a document with a front-matter
+header, a Markdown description, and a subtask checklist. Subtasks are moved to
+the trash by marking them "- [-]"; deleting their lines is an error.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := strconv.ParseInt(args[0], 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid task id: %s", args[0])
+			}
+
+			flags := cmd.Flags()
+			changed := false
+			for _, name := range []string{"title", "desc", "project", "tag", "add-tag", "rm-tag", "priority", "due", "clear-due", "repeat", "blocked-by", "rm-blocked-by"} {
+				if flags.Changed(name) {
+					changed = true
+					break
+				}
+			}
+			if useEditor {
+				if changed {
+					return fmt.Errorf("--editor cannot be combined with other flags")
+				}
+				return editInEditor(id)
+			}
+			if !changed {
+				return fmt.Errorf("nothing to edit (see tsk edit --help)")
+			}
+
+			task, err := st.GetTask(id)
+			if err != nil {
+				return err
+			}
+
+			var rec *model.Recurrence
+			if flags.Changed("repeat") {
+				switch strings.ToLower(repeat) {
+				case "none", "off", "":
+				default:
+					rule, err := quickadd.ParseRepeat(repeat)
+					if err != nil {
+						return err
+					}
+					rec = model.NewRecurrence(task.ID, rule)
+				}
+			}
+
+			// Update fields
+			if flags.Changed("title") {
+				title = strings.TrimSpace(title)
+				if title == "" {
+					return fmt.Errorf("title cannot be empty")
+				}
+				task.Title = title
+			}
+
+			if flags.Changed("desc") {
+				task.Description = description
+			}
+
+			if flags.Changed("project") {
+				project, err := st.GetProjectByName(projectName)
+				if err != nil {
+					return err
+				}
+				task.ProjectID = &project.ID
+			}
+
+			if flags.Changed("priority") {
+				p, err := model.ParsePriorityName(priority)
+				if err != nil {
+					return err
+				}
+				task.Priority = p
+			}
+
+			if flags.Changed("due") {
+				due, err := dates.Parse(dueDate)
+				if err != nil {
+					return fmt.Errorf("invalid date: %w", err)
+				}
+				task.DueDate = &due
+			}
+			if clearDue {
+				task.DueDate = nil
+			}
+
+			err = st.WithTx(func(tx store.Store) error {
+				if err := tx.UpdateTask(task); err != nil {
+					return err
+				}
+
+				// Replace tags
+				if flags.Changed("tag") {
+					for _, tag := range task.Tags {
+						if err := tx.RemoveTagFromTask(task.ID, tag.ID); err != nil {
+							return err
+						}
+					}
+					addTags = tagNames
+				}
+
+				for _, tagName := range addTags {
+					tag, err := tx.GetOrCreateTag(tagName)
+					if err != nil {
+						return err
+					}
+					if err := tx.AddTagToTask(task.ID, tag.ID); err != nil {
+						return err
+					}
+				}
+
+				for _, tagName := range rmTags {
+					tag, err := tx.GetTagByName(tagName)
+					if err != nil {
+						return err
+					}
+					if tag == nil {
+						return fmt.Errorf("tag not found: %s", tagName)
+					}
+					if err := tx.RemoveTagFromTask(task.ID, tag.ID); err != nil {
+						return err
+					}
+				}
+
+				// Set or remove recurrence
+				if rec != nil {
+					rec.Schedule(task.DueDate)
+					if err := tx.SetRecurrence(rec); err != nil {
+						return err
+					}
+				} else if flags.Changed("repeat") {
+					if err := tx.DeleteRecurrence(task.ID); err != nil {
+						return err
+					}
+				}
+
+				for _, id := range blockedBy {
+					if err := tx.AddDependency(task.ID, id); err != nil {
+						return err
+					}
+				}
+				for _, id := range unblockedBy {
+					if err := tx.RemoveDependency(task.ID, id); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Updated task #%d: %s\n", task.ID, task.Title)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&title, "title", "", "new title")
+	cmd.Flags().StringVar(&description, "desc", "", "new description")
+	cmd.Flags().StringVarP(&projectName, "project", "p", "", "move to project")
+	cmd.Flags().StringSliceVarP(&tagNames, "tag", "t", nil, "replace all tags (can be repeated)")
+	cmd.Flags().StringSliceVar(&addTags, "add-tag", nil, "add tags (can be repeated)")
+	cmd.Flags().StringSliceVar(&rmTags, "rm-tag", nil, "remove tags (can be repeated)")
+	cmd.Flags().StringVar(&priority, "priority", "", "priority (none/low/medium/high)")
+	cmd.Flags().StringVarP(&dueDate, "due", "d", "", "due date (e.g. fri, tomorrow 9am, in 2 weeks, eom, YYYY-MM-DD)")
+	cmd.Flags().BoolVar(&clearDue, "clear-due", false, "remove the due date")
+	cmd.Flags().StringVarP(&repeat, "repeat", "r", "", "recurrence (e.g. daily, 2w, mon,wed,fri, last fri, an RRULE, or none)")
+	cmd.Flags().Int64SliceVar(&blockedBy, "blocked-by", nil, "add tasks that must be done first (can be repeated)")
+	cmd.Flags().Int64SliceVar(&unblockedBy, "rm-blocked-by", nil, "remove dependencies (can be repeated)")
+
+	cmd.Flags().BoolVarP(&useEditor, "editor", "e", false, "edit the task in $EDITOR")
+
+	cmd.MarkFlagsMutuallyExclusive("due", "clear-due")
+	cmd.MarkFlagsMutuallyExclusive("tag", "add-tag")
+	cmd.MarkFlagsMutuallyExclusive("tag", "rm-tag")
+
+	return cmd
+}
+
+// editInEditor opens a task in $EDITOR and applies the result. If the edited
+// document can't be applied, the editor is re-opened with the error annotated.
+func editInEditor(id int64) error {
+	doc, err := taskdoc.Load(st, id)
+	if err != nil {
+		return err
+	}
+
+	original := doc.Marshal()
+	content := original
+	for {
+		edited, err := runEditor(content)
+		if err != nil {
+			return err
+		}
+		if len(bytes.TrimSpace(edited)) == 0 {
+			fmt.Println("Cancelled.")
+			return nil
+		}
+		if bytes.Equal(edited, original) {
+			fmt.Println("No changes.")
+			return nil
+		}
+
+		parsed, err := taskdoc.Parse(edited)
+		if err == nil {
+			parsed.TaskID = id
+			err = taskdoc.Apply(st, parsed)
+		}
+		var parseErr *taskdoc.ParseError
+		if errors.As(err, &parseErr) {
+			content = taskdoc.Annotate(edited, err)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Updated task #%d: %s\n", id, parsed.Title)
+		return nil
+	}
+}
+
+func runEditor(content []byte) ([]byte, error) {
+	path, err := taskdoc.WriteTemp(content)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(path)
+
+	cmd := taskdoc.EditorCmd(path)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("run editor: %w", err)
+	}
+
+	return os.ReadFile(path)
+}
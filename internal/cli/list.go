@@ -1,7 +1,6 @@
 package cli
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 	"strings"
@@ -10,6 +9,8 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/hwanchang/tsk/internal/config"
+	"github.com/hwanchang/tsk/internal/dates"
 	"github.com/hwanchang/tsk/internal/model"
 	"github.com/hwanchang/tsk/internal/store"
 )
@@ -20,15 +21,48 @@ func newListCmd() *cobra.Command {
 		projectName string
 		tagName     string
 		all         bool
+		tree        bool
 		format      string
+		sortBy      string
 	)
 
 	cmd := &cobra.Command{
-		Use:     "list",
+		Use:     "list [query]",
 		Aliases: []string{"ls"},
 		Short:   "List tasks",
+		Long: `List tasks, optionally matching a filter query.
+
+A query is a list of conditions that must all match. Conditions are
+field:value, where comma-separated values match any of them, or comparisons
+such as due<+3d. Combine them with "or", "not" (or a leading -), and
+parentheses; other words search the task text. @name matches a saved view
+(see "tsk view").
+
+  status:todo,doing,done,open     project:Work       tag:urgent, tag:none
+  priority>=medium                due<+3d, due:none  created>2026-01-01
+  completed>=-1w                  id:12              parent:none
+  is:blocked,overdue,recurring,actionable,subtask    title:word, desc:word
+
+Dates are those accepted by --due, or offsets like +3d and -2w. A date
+covers its whole day: due<fri is due before Friday, due<=fri by its end.`,
+		Example: `  tsk list status:todo,doing project:Work
+  tsk list 'tag:urgent and not tag:later due<+3d'
+  tsk list 'priority>=medium (due<=today or is:overdue)'
+  tsk list @today`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			filter := store.TaskFilter{}
+			query, err := parseQuery(strings.Join(args, " "))
+			if err != nil {
+				return err
+			}
+			sort, err := store.ParseSort(sortBy)
+			if err != nil {
+				return err
+			}
+			filter := store.TaskFilter{Query: query, Sort: sort}
+			if query != nil && !tree {
+				// Match subtasks too, which the tree would list under their parents
+				filter.AllLevels = true
+			}
 
 			// Status filter
 			if status != "" {
@@ -72,23 +106,33 @@ func newListCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
+			matchesDone := queriesDone(query)
 
 			// Filter out done tasks unless -a flag or specific status
-			if !all && status == "" {
-				var filtered []model.Task
-				for _, t := range tasks {
-					if t.Status != model.StatusDone {
-						filtered = append(filtered, t)
-					}
+			keep := func(t model.Task) bool {
+				if filter.Status != nil {
+					return t.Status == *filter.Status
+				}
+				return all || t.Status != model.StatusDone || filter.Query != nil && matchesDone
+			}
+			var filtered []model.Task
+			for _, t := range tasks {
+				if keep(t) {
+					filtered = append(filtered, t)
 				}
-				tasks = filtered
 			}
+			tasks = filtered
 
-			if format == "json" {
-				return printJSON(tasks)
+			if tree {
+				if err := loadSubtaskTree(tasks, keep); err != nil {
+					return err
+				}
 			}
 
-			return printTable(tasks)
+			if format == "table" {
+				return printTable(tasks)
+			}
+			return printTasksAs(format, tasks)
 		},
 	}
 
@@ -96,7 +140,9 @@ func newListCmd() *cobra.Command {
 	cmd.Flags().StringVarP(&projectName, "project", "p", "", "filter by project")
 	cmd.Flags().StringVarP(&tagName, "tag", "t", "", "filter by tag")
 	cmd.Flags().BoolVarP(&all, "all", "a", false, "show all tasks including done")
-	cmd.Flags().StringVarP(&format, "format", "f", "table", "output format (table/json)")
+	cmd.Flags().BoolVar(&tree, "tree", false, "show subtasks indented under their parents")
+	cmd.Flags().StringVarP(&format, "format", "f", "table", "output format (table/json/todotxt/csv)")
+	cmd.Flags().StringVar(&sortBy, "sort", "", "sort by fields, e.g. priority,due or title+ (priority/due/created/completed/title/project/urgency)")
 
 	return cmd
 }
@@ -108,31 +154,66 @@ func printTable(tasks []model.Task) error {
 	}
 
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "ID\tSTATUS\tPRIORITY\tTITLE\tDUE\tTAGS")
+	fmt.Fprintln(w, "ID\tSTATUS\tPRIORITY\tTITLE\tDUE\tURGENCY\tTAGS")
+	printRows(w, tasks, 0, config.GetUrgency(), time.Now())
+	return w.Flush()
+}
 
+// printRows writes a table row per task, followed by its loaded subtasks
+func printRows(w *tabwriter.Writer, tasks []model.Task, depth int, coeffs model.UrgencyCoefficients, now time.Time) {
 	for _, t := range tasks {
 		status := statusIcon(t.Status)
 		priority := t.Priority.Icon()
 		due := formatDue(t.DueDate)
 		tags := formatTags(t.Tags)
+		urgency := "-"
+		if t.Status != model.StatusDone {
+			urgency = fmt.Sprintf("%.1f", t.Urgency(coeffs, now))
+		}
 
 		title := t.Title
 		titleRunes := []rune(title)
 		if len(titleRunes) > 40 {
 			title = string(titleRunes[:37]) + "..."
 		}
+		if depth > 0 {
+			title = strings.Repeat("  ", depth-1) + "└ " + title
+		}
+		if t.SubtaskCount > 0 {
+			title += fmt.Sprintf(" [%d/%d]", t.SubtasksDone, t.SubtaskCount)
+		}
+		if t.Blocked && t.Status != model.StatusDone {
+			title += " (blocked)"
+		}
 
-		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n",
-			t.ID, status, priority, title, due, tags)
-	}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			t.ID, status, priority, title, due, urgency, tags)
 
-	return w.Flush()
+		printRows(w, t.Subtasks, depth+1, coeffs, now)
+	}
 }
 
-func printJSON(tasks []model.Task) error {
-	enc := json.NewEncoder(os.Stdout)
-	enc.SetIndent("", "  ")
-	return enc.Encode(tasks)
+// loadSubtaskTree recursively fills in the subtasks of each task, keeping
+// only those that match keep
+func loadSubtaskTree(tasks []model.Task, keep func(model.Task) bool) error {
+	for i := range tasks {
+		subtasks, err := st.GetSubtasks(tasks[i].ID)
+		if err != nil {
+			return err
+		}
+
+		var kept []model.Task
+		for _, s := range subtasks {
+			if keep(s) {
+				kept = append(kept, s)
+			}
+		}
+		if err := loadSubtaskTree(kept, keep); err != nil {
+			return err
+		}
+		tasks[i].Subtasks = kept
+	}
+	return nil
 }
 
 func statusIcon(s model.Status) string {
@@ -159,17 +240,22 @@ func formatDue(due *time.Time) string {
 
 	diff := dueDay.Sub(today).Hours() / 24
 
+	var clock string
+	if dates.HasTime(*due) {
+		clock = due.Format(" 15:04")
+	}
+
 	switch {
 	case diff < 0:
-		return fmt.Sprintf("OVERDUE (%s)", due.Format("Jan 2"))
+		return fmt.Sprintf("OVERDUE (%s)", due.Format("Jan 2")+clock)
 	case diff == 0:
-		return "Today"
+		return "Today" + clock
 	case diff == 1:
-		return "Tomorrow"
+		return "Tomorrow" + clock
 	case diff <= 7:
-		return due.Format("Mon")
+		return due.Format("Mon") + clock
 	default:
-		return due.Format("Jan 2")
+		return due.Format("Jan 2") + clock
 	}
 }
 
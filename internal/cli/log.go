@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hwanchang/tsk/internal/model"
+)
+
+func newLogCmd() *cobra.Command {
+	var limit int
+
+	cmd := &cobra.Command{
+		Use:     "log [<id>]",
+		Aliases: []string{"history"},
+		Short:   "Show task history",
+		Long:    `Show the history of a single task, or the most recent changes across all tasks.`,
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var events []model.TaskEvent
+
+			if len(args) == 1 {
+				id, err := strconv.ParseInt(args[0], 10, 64)
+				if err != nil {
+					return fmt.Errorf("invalid task id: %s", args[0])
+				}
+				events, err = st.GetTaskEvents(id)
+				if err != nil {
+					return err
+				}
+				if len(events) == 0 {
+					return fmt.Errorf("no history for task #%d", id)
+				}
+			} else {
+				var err error
+				events, err = st.ListEvents(limit)
+				if err != nil {
+					return err
+				}
+				if len(events) == 0 {
+					fmt.Println("No history yet.")
+					return nil
+				}
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "TIME\tTASK\tBY\tEVENT")
+			for _, e := range events {
+				actor := e.Actor
+				if actor == "" {
+					actor = "-"
+				}
+				fmt.Fprintf(w, "%s\t#%d\t%s\t%s\n",
+					e.CreatedAt.Local().Format("2006-01-02 15:04"), e.TaskID, actor, e.Describe())
+			}
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().IntVarP(&limit, "limit", "n", 50, "number of recent events to show (all tasks)")
+
+	return cmd
+}
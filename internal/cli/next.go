@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/hwanchang/tsk/internal/store"
+)
+
+func newNextCmd() *cobra.Command {
+	var (
+		projectName string
+		limit       int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "next",
+		Short: "List the most urgent tasks that can be worked on now",
+		Long: `List actionable tasks, tasks that aren't done and aren't blocked by
+unfinished tasks, most urgent first.
+
+Urgency adds up weighted factors: priority, how soon the task is due and how
+long it has been overdue, its age, being in progress, and its tags. The
+weights are the "urgency" coefficients in the config file, e.g.
+
+  "urgency": {"due": 12, "tag_weights": {"someday": -3}}`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			filter := store.TaskFilter{
+				Actionable: true,
+				Sort:       []store.SortKey{{Field: "urgency", Desc: true}},
+				Limit:      limit,
+			}
+			if projectName != "" {
+				project, err := st.GetProjectByName(projectName)
+				if err != nil {
+					return err
+				}
+				filter.ProjectID = &project.ID
+			}
+
+			tasks, err := st.ListTasks(filter)
+			if err != nil {
+				return err
+			}
+
+			return printTable(tasks)
+		},
+	}
+
+	cmd.Flags().StringVarP(&projectName, "project", "p", "", "only tasks in this project")
+	cmd.Flags().IntVarP(&limit, "limit", "n", 10, "maximum number of tasks to show (0 for all)")
+
+	return cmd
+}
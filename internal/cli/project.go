@@ -86,33 +86,21 @@ func newProjectRmCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:     "rm <name>",
 		Aliases: []string{"remove", "delete"},
-		Short:   "Delete a project (tasks will be moved to Inbox)",
+		Short:   "Move a project to the trash (tasks will be moved to Inbox)",
 		Args:    cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			name := args[0]
 
-			projects, err := st.ListProjects()
+			project, err := st.GetProjectByName(name)
 			if err != nil {
 				return err
 			}
 
-			var projectID int64
-			for _, p := range projects {
-				if strings.EqualFold(p.Name, name) {
-					projectID = p.ID
-					break
-				}
-			}
-
-			if projectID == 0 {
-				return fmt.Errorf("project not found: %s", name)
-			}
-
-			if err := st.DeleteProject(projectID); err != nil {
+			if err := st.DeleteProject(project.ID); err != nil {
 				return err
 			}
 
-			fmt.Printf("Deleted project: %s (tasks moved to Inbox)\n", name)
+			fmt.Printf("Moved project to trash: %s (tasks moved to Inbox)\n", name)
 			return nil
 		},
 	}
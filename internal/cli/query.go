@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hwanchang/tsk/internal/filter"
+)
+
+// parseQuery parses a filter query, marking the bad token of an invalid one
+func parseQuery(input string) (filter.Expr, error) {
+	query, err := filter.Parse(input)
+	var ferr *filter.Error
+	if errors.As(err, &ferr) {
+		pointer := strings.ReplaceAll(ferr.Pointer(input), "\n", "\n  ")
+		return nil, fmt.Errorf("invalid query: %s\n\n  %s", ferr.Msg, pointer)
+	}
+	return query, err
+}
+
+// queriesDone reports whether a query, including the views it refers to,
+// picks tasks by status or completion date, so done tasks it matches should
+// be listed
+func queriesDone(query filter.Expr) bool {
+	expanded, err := filter.Expand(query, viewQuery)
+	if err != nil {
+		return false
+	}
+	return filter.Mentions(expanded, "status", "completed")
+}
+
+// viewQuery returns the query of a saved view
+func viewQuery(name string) (string, error) {
+	v, err := st.GetView(name)
+	if err != nil {
+		return "", err
+	}
+	return v.Query, nil
+}
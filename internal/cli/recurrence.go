@@ -2,9 +2,15 @@ package cli
 
 import (
 	"fmt"
+	"os"
 	"strconv"
+	"strings"
+	"text/tabwriter"
 
 	"github.com/spf13/cobra"
+
+	"github.com/hwanchang/tsk/internal/model"
+	"github.com/hwanchang/tsk/internal/quickadd"
 )
 
 func newRecurrenceCmd() *cobra.Command {
@@ -14,6 +20,9 @@ func newRecurrenceCmd() *cobra.Command {
 		Short:   "Manage task recurrence",
 	}
 
+	cmd.AddCommand(newRecurrenceListCmd())
+	cmd.AddCommand(newRecurrenceSetCmd())
+	cmd.AddCommand(newRecurrenceSkipCmd())
 	cmd.AddCommand(newRecurrenceRmCmd())
 
 	return cmd
@@ -55,3 +64,149 @@ func newRecurrenceRmCmd() *cobra.Command {
 		},
 	}
 }
+
+func newRecurrenceListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List recurring tasks",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			recs, err := st.ListRecurrences()
+			if err != nil {
+				return err
+			}
+			if len(recs) == 0 {
+				fmt.Println("No recurring tasks.")
+				return nil
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tTASK\tREPEATS\tFROM\tCATCH-UP\tDUE\tNEXT")
+			for _, rec := range recs {
+				task, err := st.GetTask(rec.TaskID)
+				if err != nil {
+					return err
+				}
+				next := "-"
+				if rec.Anchor == model.AnchorCompletion {
+					next = "on completion"
+				} else if !rec.NextDue.IsZero() {
+					next = formatDue(&rec.NextDue)
+				}
+				fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n",
+					task.ID, task.Title, rec.Rule.Describe(), rec.Anchor, rec.CatchUp, formatDue(task.DueDate), next)
+			}
+			return w.Flush()
+		},
+	}
+}
+
+func newRecurrenceSetCmd() *cobra.Command {
+	var (
+		from    string
+		catchUp string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "set <task-id> [rule]",
+		Short: "Set or change a task's recurrence",
+		Long: `Set a task's recurrence rule, or change how an existing recurrence
+schedules its next occurrence. Rules use the --repeat syntax, e.g. "weekly",
+"mon,wed,fri", "last fri", "15th until dec 31", or an RRULE.
+
+--from due schedules the next occurrence from the completed task's due date,
+so completing late doesn't shift the schedule; --from completion schedules it
+from when the task was completed. When a task is completed after later
+occurrences have passed, --catch-up skip moves on to the next future
+occurrence and --catch-up all creates a task for each missed one.`,
+		Example: `  tsk recurrence set 12 mon,wed,fri
+  tsk recurrence set 12 every 3 days --from completion
+  tsk recurrence set 12 --catch-up all`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			taskID, err := strconv.ParseInt(args[0], 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid task id: %s", args[0])
+			}
+			task, err := st.GetTask(taskID)
+			if err != nil {
+				return err
+			}
+
+			rec, err := st.GetRecurrence(taskID)
+			if err != nil {
+				return err
+			}
+			if len(args) > 1 {
+				rule, err := quickadd.ParseRepeat(strings.Join(args[1:], " "))
+				if err != nil {
+					return err
+				}
+				rec = model.NewRecurrence(taskID, rule)
+				rec.Schedule(task.DueDate)
+			} else if rec == nil {
+				return fmt.Errorf("task #%d has no recurrence; give a rule to set one", taskID)
+			} else if !cmd.Flags().Changed("from") && !cmd.Flags().Changed("catch-up") {
+				return fmt.Errorf("nothing to change (give a rule, --from, or --catch-up)")
+			}
+
+			if cmd.Flags().Changed("from") {
+				switch anchor := model.RecurrenceAnchor(from); anchor {
+				case model.AnchorDue, model.AnchorCompletion:
+					rec.Anchor = anchor
+				default:
+					return fmt.Errorf("invalid --from %q (use due or completion)", from)
+				}
+			}
+			if cmd.Flags().Changed("catch-up") {
+				switch mode := model.CatchUp(catchUp); mode {
+				case model.CatchUpSkip, model.CatchUpAll:
+					rec.CatchUp = mode
+				default:
+					return fmt.Errorf("invalid --catch-up %q (use skip or all)", catchUp)
+				}
+			}
+
+			if err := st.SetRecurrence(rec); err != nil {
+				return err
+			}
+			if rec, err = st.GetRecurrence(taskID); err != nil {
+				return err
+			}
+			fmt.Printf("Task #%d: %s repeats %s\n", task.ID, task.Title, rec.PatternString())
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&from, "from", "", "schedule from the due date or the completion date (due/completion)")
+	cmd.Flags().StringVar(&catchUp, "catch-up", "", "missed occurrences: skip to the next, or create all (skip/all)")
+
+	return cmd
+}
+
+func newRecurrenceSkipCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "skip <task-id>",
+		Short: "Skip a recurring task to its next occurrence",
+		Long: `Move a recurring task to its next occurrence without completing it.
+Occurrences that have already passed are skipped too.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			taskID, err := strconv.ParseInt(args[0], 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid task id: %s", args[0])
+			}
+			if err := st.SkipRecurrence(taskID); err != nil {
+				return err
+			}
+
+			task, err := st.GetTask(taskID)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Skipped task #%d: %s to %s\n", task.ID, task.Title, formatDue(task.DueDate))
+			return nil
+		},
+	}
+}
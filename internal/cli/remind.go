@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hwanchang/tsk/internal/dates"
+	"github.com/hwanchang/tsk/internal/model"
+)
+
+func newRemindCmd() *cobra.Command {
+	var remove int64
+
+	cmd := &cobra.Command{
+		Use:   "remind <id> [when]",
+		Short: "Add or list reminders on a task",
+		Long: `Add a reminder to a task, fired by 'tsk daemon'. A reminder is either
+relative to the due date, e.g. "30m-before", "2h before", "1d-before", or
+"at-due", or at a fixed time such as "tomorrow 9am" or "fri 14:00".
+Reminders relative to the due date follow it when it changes.
+
+Without a time, lists the task's reminders.`,
+		Example: `  tsk remind 12 30m-before
+  tsk remind 12 tomorrow 9am
+  tsk remind 12 --rm 3`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := strconv.ParseInt(args[0], 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid task id: %s", args[0])
+			}
+			task, err := st.GetTask(id)
+			if err != nil {
+				return err
+			}
+
+			if cmd.Flags().Changed("rm") {
+				reminders, err := st.ListReminders(task.ID)
+				if err != nil {
+					return err
+				}
+				if !slices.ContainsFunc(reminders, func(r model.Reminder) bool { return r.ID == remove }) {
+					return fmt.Errorf("task #%d has no reminder %d", task.ID, remove)
+				}
+				if err := st.DeleteReminder(remove); err != nil {
+					return err
+				}
+				fmt.Printf("Removed reminder %d from task #%d: %s\n", remove, task.ID, task.Title)
+				return nil
+			}
+			if len(args) == 1 {
+				return listReminders(task)
+			}
+
+			r, err := parseReminder(strings.Join(args[1:], " "))
+			if err != nil {
+				return err
+			}
+			r.TaskID = task.ID
+			if err := st.AddReminder(r); err != nil {
+				return err
+			}
+
+			fmt.Printf("Added reminder to task #%d: %s (%s)\n", task.ID, task.Title, r.Describe())
+			if r.At == nil && task.DueDate == nil {
+				fmt.Println("Task has no due date; the reminder fires once it has one.")
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().Int64Var(&remove, "rm", 0, "remove the reminder with this id")
+
+	return cmd
+}
+
+var offsetRe = regexp.MustCompile(`^(\d+) ?([mhdw])[- ]before$`)
+
+// parseReminder parses "30m-before", "at-due", or a date and time
+func parseReminder(s string) (*model.Reminder, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "at-due" || s == "at due" || s == "due" {
+		return &model.Reminder{}, nil
+	}
+	if m := offsetRe.FindStringSubmatch(s); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		unit := map[string]time.Duration{"m": time.Minute, "h": time.Hour, "d": 24 * time.Hour, "w": 7 * 24 * time.Hour}[m[2]]
+		return &model.Reminder{Before: time.Duration(n) * unit}, nil
+	}
+
+	at, err := dates.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid reminder %q (use e.g. 30m-before, at-due, or tomorrow 9am)", s)
+	}
+	if !at.After(time.Now()) {
+		return nil, fmt.Errorf("reminder time %s has passed", at.Format("2006-01-02 15:04"))
+	}
+	return &model.Reminder{At: &at}, nil
+}
+
+func listReminders(task *model.Task) error {
+	reminders, err := st.ListReminders(task.ID)
+	if err != nil {
+		return err
+	}
+	if len(reminders) == 0 {
+		fmt.Printf("No reminders on task #%d: %s\n", task.ID, task.Title)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tREMINDER\tFIRES\tSTATUS")
+	for _, r := range reminders {
+		fires := "-"
+		if at, ok := r.Time(task.DueDate); ok {
+			fires = at.Local().Format("2006-01-02 15:04")
+		}
+		status := "pending"
+		if r.FiredAt != nil {
+			status = "fired " + r.FiredAt.Local().Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", r.ID, r.Describe(), fires, status)
+	}
+	return w.Flush()
+}
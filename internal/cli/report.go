@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hwanchang/tsk/internal/dates"
+	"github.com/hwanchang/tsk/internal/model"
+)
+
+func newReportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Summarize tracked work",
+	}
+
+	cmd.AddCommand(newReportTimeCmd())
+
+	return cmd
+}
+
+func newReportTimeCmd() *cobra.Command {
+	var (
+		since string
+		until string
+		by    string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "time",
+		Short: "Summarize tracked hours",
+		Long: `Summarize the hours tracked between --since and --until (inclusive),
+grouped by project, tag, or task. Time on a task with several tags counts
+toward each of them. Defaults to the last 7 days.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			now := time.Now()
+			from := startOfDay(now).AddDate(0, 0, -6)
+			to := startOfDay(now).AddDate(0, 0, 1)
+			if since != "" {
+				d, err := dates.Parse(since)
+				if err != nil {
+					return fmt.Errorf("invalid --since date: %w", err)
+				}
+				from = startOfDay(d)
+			}
+			if until != "" {
+				d, err := dates.Parse(until)
+				if err != nil {
+					return fmt.Errorf("invalid --until date: %w", err)
+				}
+				to = startOfDay(d).AddDate(0, 0, 1)
+			}
+			if !to.After(from) {
+				return fmt.Errorf("--until is before --since")
+			}
+
+			totals, err := st.TimeReport(from, to, by)
+			if err != nil {
+				return err
+			}
+
+			period := fmt.Sprintf("%s to %s", from.Format("2006-01-02"), to.AddDate(0, 0, -1).Format("2006-01-02"))
+			if len(totals) == 0 {
+				fmt.Printf("No time tracked from %s.\n", period)
+				return nil
+			}
+
+			none := map[string]string{"project": "(no project)", "tag": "(untagged)"}[by]
+			var sum time.Duration
+			fmt.Printf("Time tracked from %s\n\n", period)
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintf(w, "%s\tHOURS\tTIME\n", map[string]string{"project": "PROJECT", "tag": "TAG", "task": "TASK"}[by])
+			for _, t := range totals {
+				name := t.Name
+				if name == "" {
+					name = none
+				}
+				sum += t.Duration
+				fmt.Fprintf(w, "%s\t%.2f\t%s\n", name, t.Duration.Hours(), model.FormatDuration(t.Duration))
+			}
+			if by != "tag" {
+				// Tag totals overlap, so they don't add up
+				fmt.Fprintf(w, "TOTAL\t%.2f\t%s\n", sum.Hours(), model.FormatDuration(sum))
+			}
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().StringVar(&since, "since", "", "first day to include (YYYY-MM-DD, today, ...)")
+	cmd.Flags().StringVar(&until, "until", "", "last day to include (default: today)")
+	cmd.Flags().StringVar(&by, "by", "project", "group by project, tag, or task")
+
+	return cmd
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
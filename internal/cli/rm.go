@@ -13,7 +13,7 @@ func newRmCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:     "rm <id>",
 		Aliases: []string{"remove", "delete"},
-		Short:   "Remove a task",
+		Short:   "Move a task to the trash",
 		Args:    cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			id, err := strconv.ParseInt(args[0], 10, 64)
@@ -40,7 +40,7 @@ func newRmCmd() *cobra.Command {
 				return err
 			}
 
-			fmt.Printf("Deleted task #%d: %s\n", task.ID, task.Title)
+			fmt.Printf("Moved task #%d to trash: %s\n", task.ID, task.Title)
 			return nil
 		},
 	}
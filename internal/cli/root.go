@@ -47,24 +47,43 @@ func NewRootCmd() *cobra.Command {
 
 	// Add subcommands
 	rootCmd.AddCommand(newAddCmd())
+	rootCmd.AddCommand(newEditCmd())
 	rootCmd.AddCommand(newListCmd())
+	rootCmd.AddCommand(newSearchCmd())
+	rootCmd.AddCommand(newViewCmd())
+	rootCmd.AddCommand(newExportCmd())
+	rootCmd.AddCommand(newImportCmd())
+	rootCmd.AddCommand(newNextCmd())
 	rootCmd.AddCommand(newDoneCmd())
 	rootCmd.AddCommand(newDoingCmd())
+	rootCmd.AddCommand(newStartCmd())
+	rootCmd.AddCommand(newStopCmd())
+	rootCmd.AddCommand(newTimeCmd())
+	rootCmd.AddCommand(newReportCmd())
+	rootCmd.AddCommand(newRemindCmd())
+	rootCmd.AddCommand(newDaemonCmd())
 	rootCmd.AddCommand(newRmCmd())
+	rootCmd.AddCommand(newTrashCmd())
+	rootCmd.AddCommand(newLogCmd())
+	rootCmd.AddCommand(newUndoCmd())
+	rootCmd.AddCommand(newRedoCmd())
 	rootCmd.AddCommand(newProjectCmd())
 	rootCmd.AddCommand(newTagCmd())
 	rootCmd.AddCommand(newRecurrenceCmd())
+	rootCmd.AddCommand(newDBCmd())
 
 	return rootCmd
 }
 
-func initStore() error {
-	path := dbPath
-	if path == "" {
-		path = db.GetDBPath()
+func resolveDBPath() string {
+	if dbPath != "" {
+		return dbPath
 	}
+	return db.GetDBPath()
+}
 
-	database, err := db.New(path)
+func initStore() error {
+	database, err := db.New(resolveDBPath())
 	if err != nil {
 		return fmt.Errorf("open database: %w", err)
 	}
@@ -75,6 +94,10 @@ func initStore() error {
 	}
 
 	st = store.New(database)
+
+	config.Load()
+	st.SetAutoCompleteParents(config.Get().AutoCompleteParent)
+	st.SetUrgencyCoefficients(config.GetUrgency())
 	return nil
 }
 
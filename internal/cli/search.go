@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+
+	"github.com/hwanchang/tsk/internal/search"
+)
+
+func newSearchCmd() *cobra.Command {
+	var limit int
+
+	cmd := &cobra.Command{
+		Use:   "search <query>",
+		Short: "Search tasks by title, description, tags, and project",
+		Long: `Search tasks, most relevant first. Words match any word they start, so
+"meet" finds "meeting". Quote a phrase to match it exactly, combine terms
+with OR, exclude them with -word or NOT, and group with parentheses. Limit a
+term to one field with title:, desc:, tag:, or project:.`,
+		Example: `  tsk search invoice
+  tsk search '"weekly review" OR retro'
+  tsk search 'tag:work -meeting'`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			results, err := st.SearchTasks(strings.Join(args, " "), limit)
+			if err != nil {
+				return err
+			}
+			if len(results) == 0 {
+				fmt.Println("No matching tasks.")
+				return nil
+			}
+
+			style := lipgloss.NewStyle().Bold(true).Underline(true)
+			match := func(s string) string { return style.Render(s) }
+			for _, r := range results {
+				fmt.Printf("%4d  %s  %s\n", r.Task.ID, statusIcon(r.Task.Status), search.Mark(r.Title, match))
+				if r.Snippet != "" {
+					snippet := strings.Join(strings.Fields(r.Snippet), " ")
+					fmt.Printf("           %s\n", search.Mark(snippet, match))
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVarP(&limit, "limit", "n", 20, "maximum number of results")
+
+	return cmd
+}
@@ -95,7 +95,7 @@ func newTagRmCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:     "rm <name>",
 		Aliases: []string{"remove", "delete"},
-		Short:   "Delete a tag",
+		Short:   "Move a tag to the trash",
 		Args:    cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			name := args[0]
@@ -124,7 +124,7 @@ func newTagRmCmd() *cobra.Command {
 				return err
 			}
 
-			fmt.Printf("Deleted tag: %s\n", name)
+			fmt.Printf("Moved tag to trash: %s\n", name)
 			return nil
 		},
 	}
@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hwanchang/tsk/internal/model"
+	"github.com/hwanchang/tsk/internal/store"
+)
+
+func newStartCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "start <id>",
+		Short: "Start tracking time on a task",
+		Long: `Start a timer on a task. Only one timer runs at a time, so a timer
+running on another task is stopped first.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := strconv.ParseInt(args[0], 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid task id: %s", args[0])
+			}
+			return startTimer(id)
+		},
+	}
+}
+
+func newStopCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "stop",
+		Short: "Stop the running timer",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			entry, err := st.StopTimer()
+			if errors.Is(err, store.ErrNoTimer) {
+				fmt.Println("No timer running.")
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			task, err := st.GetTask(entry.TaskID)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Stopped timer on task #%d: %s (%s)\n",
+				task.ID, task.Title, model.FormatDuration(entry.Duration(time.Now())))
+			return nil
+		},
+	}
+}
+
+func newTimeCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "time <id>",
+		Short: "Show time tracked on a task",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := strconv.ParseInt(args[0], 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid task id: %s", args[0])
+			}
+
+			task, err := st.GetTask(id)
+			if err != nil {
+				return err
+			}
+			entries, err := st.ListTimeEntries(id)
+			if err != nil {
+				return err
+			}
+			if len(entries) == 0 {
+				fmt.Printf("No time tracked on task #%d: %s\n", task.ID, task.Title)
+				return nil
+			}
+
+			now := time.Now()
+			var total time.Duration
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "STARTED\tENDED\tDURATION")
+			for _, e := range entries {
+				ended := "running"
+				if e.EndedAt != nil {
+					ended = e.EndedAt.Local().Format("2006-01-02 15:04")
+				}
+				d := e.Duration(now)
+				total += d
+				fmt.Fprintf(w, "%s\t%s\t%s\n", e.StartedAt.Local().Format("2006-01-02 15:04"), ended, model.FormatDuration(d))
+			}
+			fmt.Fprintf(w, "\t\t%s total\n", model.FormatDuration(total))
+			return w.Flush()
+		},
+	}
+}
+
+// startTimer starts a task's timer and reports a timer it replaced
+func startTimer(taskID int64) error {
+	previous, err := st.RunningTimer()
+	if err != nil {
+		return err
+	}
+	if _, err := st.StartTimer(taskID); err != nil {
+		return err
+	}
+
+	if previous != nil && previous.TaskID != taskID {
+		if prev, err := st.GetTask(previous.TaskID); err == nil {
+			fmt.Printf("Stopped timer on task #%d: %s\n", prev.ID, prev.Title)
+		}
+	}
+	task, err := st.GetTask(taskID)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Started timer on task #%d: %s\n", task.ID, task.Title)
+	return nil
+}
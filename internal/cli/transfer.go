@@ -0,0 +1,255 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hwanchang/tsk/internal/model"
+	"github.com/hwanchang/tsk/internal/transfer"
+)
+
+func newExportCmd() *cobra.Command {
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "export [file]",
+		Short: "Export projects, tags, and tasks",
+		Long: `Export all projects, tags, and tasks (not those in the trash) to a file, or
+to standard output without one. The format defaults to the file's extension,
+or JSON.
+
+The JSON format is versioned and lossless: "tsk import" of an export
+recreates the same tasks, subtasks, dependencies, and recurrences. NDJSON
+has the same records, one per line. Other formats can't carry everything
+over; what they leave out is reported.`,
+		Example: `  tsk export backup.json
+  tsk export -f ndjson > tasks.ndjson
+  tsk export --format ics > tasks.ics
+  tsk export --to taskwarrior | task import`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := ""
+			if len(args) > 0 && args[0] != "-" {
+				path = args[0]
+			}
+			codec, err := transfer.Lookup(fileFormat(format, path))
+			if err != nil {
+				return err
+			}
+
+			doc, err := transfer.Export(st)
+			if err != nil {
+				return err
+			}
+
+			if path == "" {
+				if err := codec.Encode(os.Stdout, doc); err != nil {
+					return err
+				}
+				printUnmapped(doc.Unmapped)
+				return nil
+			}
+			f, err := os.Create(path)
+			if err != nil {
+				return fmt.Errorf("create export: %w", err)
+			}
+			if err := codec.Encode(f, doc); err != nil {
+				f.Close()
+				return err
+			}
+			if err := f.Close(); err != nil {
+				return fmt.Errorf("write export: %w", err)
+			}
+			fmt.Printf("Exported %d tasks to %s\n", len(doc.Tasks), path)
+			printUnmapped(doc.Unmapped)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&format, "format", "f", "", "file format ("+strings.Join(transfer.Formats(), "/")+")")
+	cmd.Flags().StringVar(&format, "to", "", "same as --format, e.g. --to taskwarrior")
+	cmd.MarkFlagsMutuallyExclusive("format", "to")
+
+	return cmd
+}
+
+func newImportCmd() *cobra.Command {
+	var (
+		format  string
+		columns string
+		merge   bool
+		replace bool
+		dryRun  bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "import [file]",
+		Short: "Import projects, tags, and tasks",
+		Long: `Import projects, tags, and tasks from a file, or from standard input without
+one. The format defaults to the file's extension, or JSON.
+
+By default (--merge) the tasks are added to the existing ones, reusing
+projects and tags with the same names. Tasks keep a UID across exports, so
+a task whose UID already exists is updated instead of copied. --replace
+first moves the existing tasks, projects, and tags that the file doesn't
+have to the trash. Either way the import is a single change that
+"tsk undo" reverts.
+
+With --from taskwarrior, the output of "task export" is read: projects,
+tags, priorities, due dates, statuses, dependencies, and recurrences carry
+over, and annotations become the description. Deleted tasks and attributes
+with no equivalent, such as wait and scheduled, are reported and skipped.
+
+An .ics file is read as iCalendar: each VTODO becomes a task, with its
+categories as tags and RELATED-TO as the parent or, with RELTYPE=DEPENDS-ON,
+a blocker. Cancelled to-dos, events, and alarms are reported and skipped.
+
+A CSV file has a task per row. Columns named after a field, such as title,
+due_date, or tags, are read as that field; --map names the fields of other
+columns. Dates are read like --due, and missing projects and tags are
+created. Rows with errors are reported by line and skipped, and the other
+rows imported.`,
+		Example: `  tsk import backup.json --dry-run
+  tsk import backup.json --replace
+  tsk import -f ndjson < tasks.ndjson
+  tsk import reminders.ics
+  tsk import tasks.csv --map "Title=title,Due=due_date,Labels=tags"
+  task export | tsk import --from taskwarrior`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch {
+			case merge && replace && cmd.Flags().Changed("merge"):
+				return fmt.Errorf("--merge and --replace can't be used together")
+			case !merge && !replace:
+				return fmt.Errorf("--merge=false needs --replace, the only other mode")
+			}
+
+			path := ""
+			if len(args) > 0 && args[0] != "-" {
+				path = args[0]
+			}
+			codec, err := transfer.Lookup(fileFormat(format, path))
+			if err != nil {
+				return err
+			}
+			if columns != "" {
+				csvCodec, ok := codec.(transfer.CSV)
+				if !ok {
+					return fmt.Errorf("--map only applies to CSV")
+				}
+				if csvCodec.Columns, err = transfer.ParseColumnMap(columns); err != nil {
+					return err
+				}
+				codec = csvCodec
+			}
+
+			var r io.Reader = os.Stdin
+			if path != "" {
+				f, err := os.Open(path)
+				if err != nil {
+					return fmt.Errorf("open import: %w", err)
+				}
+				defer f.Close()
+				r = f
+			}
+			doc, err := codec.Decode(r)
+			if err != nil {
+				return err
+			}
+			for _, err := range doc.Rejected {
+				fmt.Fprintf(os.Stderr, "Skipped %v\n", err)
+			}
+
+			sum, err := transfer.Import(st, doc, transfer.Options{Replace: replace, DryRun: dryRun})
+			if err != nil {
+				return fmt.Errorf("import: %w", err)
+			}
+			printImportSummary(sum, dryRun)
+			printUnmapped(doc.Unmapped)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&format, "format", "f", "", "file format ("+strings.Join(transfer.Formats(), "/")+")")
+	cmd.Flags().StringVar(&format, "from", "", "same as --format, e.g. --from taskwarrior")
+	cmd.Flags().StringVar(&columns, "map", "", `CSV columns to read as fields, e.g. "Name=title,Labels=tags"`)
+	cmd.Flags().BoolVar(&merge, "merge", true, "add to the existing tasks")
+	cmd.Flags().BoolVar(&replace, "replace", false, "move the existing tasks, projects, and tags to the trash first")
+	cmd.Flags().BoolVarP(&dryRun, "dry-run", "n", false, "show what would be imported without changing anything")
+	cmd.MarkFlagsMutuallyExclusive("format", "from")
+
+	return cmd
+}
+
+// fileFormat returns the given format, or the one of path's extension, or JSON
+func fileFormat(format, path string) string {
+	if format != "" {
+		return format
+	}
+	if f := transfer.FormatOf(path); f != "" {
+		return f
+	}
+	return "json"
+}
+
+func printImportSummary(sum *transfer.Summary, dryRun bool) {
+	verb, moved := "Imported", "Moved"
+	if dryRun {
+		verb, moved = "Would import", "Would move"
+	}
+	if sum.Trashed > 0 {
+		fmt.Printf("%s %d existing tasks to the trash\n", moved, sum.Trashed)
+	}
+	if sum.Updated > 0 {
+		fmt.Printf("%s %d tasks (%d updated by UID)\n", verb, sum.Tasks, sum.Updated)
+	} else {
+		fmt.Printf("%s %d tasks\n", verb, sum.Tasks)
+	}
+	if sum.Restored > 0 {
+		fmt.Printf("Restored from the trash: %d tasks, projects, and tags\n", sum.Restored)
+	}
+	if len(sum.NewProjects) > 0 {
+		fmt.Printf("New projects: %s\n", strings.Join(sum.NewProjects, ", "))
+	}
+	if len(sum.NewTags) > 0 {
+		fmt.Printf("New tags: %s\n", strings.Join(sum.NewTags, ", "))
+	}
+	if dryRun {
+		fmt.Println("Dry run: nothing was changed.")
+	}
+}
+
+// printUnmapped reports what a format couldn't carry over, on standard error
+// so it stays out of exports to standard output
+func printUnmapped(unmapped map[string]int) {
+	if len(unmapped) == 0 {
+		return
+	}
+	fields := make([]string, 0, len(unmapped))
+	for field := range unmapped {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for i, field := range fields {
+		fields[i] = fmt.Sprintf("%s (%d)", field, unmapped[field])
+	}
+	fmt.Fprintf(os.Stderr, "Not mapped: %s\n", strings.Join(fields, ", "))
+}
+
+// printTasksAs writes tasks in an export format
+func printTasksAs(format string, tasks []model.Task) error {
+	codec, err := transfer.Lookup(format)
+	if err != nil {
+		return err
+	}
+	doc, err := transfer.ExportTasks(st, tasks)
+	if err != nil {
+		return err
+	}
+	return codec.Encode(os.Stdout, doc)
+}
@@ -0,0 +1,219 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hwanchang/tsk/internal/store"
+)
+
+func newTrashCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "trash",
+		Short: "Manage deleted tasks, projects, and tags",
+		Long: `Deleted tasks, projects, and tags are kept in the trash until purged.
+
+Restoring a task also restores the subtasks that were deleted with it.
+Tasks of a deleted project are moved to Inbox and stay there when the
+project is restored.`,
+	}
+
+	cmd.AddCommand(newTrashListCmd())
+	cmd.AddCommand(newTrashRestoreCmd())
+	cmd.AddCommand(newTrashPurgeCmd())
+
+	return cmd
+}
+
+func newTrashListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List items in the trash",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tasks, err := st.ListTasks(store.TaskFilter{Trashed: true})
+			if err != nil {
+				return err
+			}
+			projects, err := st.ListTrashedProjects()
+			if err != nil {
+				return err
+			}
+			tags, err := st.ListTrashedTags()
+			if err != nil {
+				return err
+			}
+
+			if len(tasks)+len(projects)+len(tags) == 0 {
+				fmt.Println("Trash is empty.")
+				return nil
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "TYPE\tID\tNAME\tDELETED")
+			for _, t := range tasks {
+				fmt.Fprintf(w, "task\t%d\t%s\t%s\n", t.ID, t.Title, formatDeleted(*t.DeletedAt))
+			}
+			for _, p := range projects {
+				fmt.Fprintf(w, "project\t%d\t%s\t%s\n", p.ID, p.Name, formatDeleted(*p.DeletedAt))
+			}
+			for _, t := range tags {
+				fmt.Fprintf(w, "tag\t%d\t%s\t%s\n", t.ID, t.Name, formatDeleted(*t.DeletedAt))
+			}
+			return w.Flush()
+		},
+	}
+}
+
+func newTrashRestoreCmd() *cobra.Command {
+	var (
+		projectName string
+		tagName     string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "restore [<id>]",
+		Short: "Restore a task, project, or tag from the trash",
+		Example: `  tsk trash restore 12
+  tsk trash restore --project Work
+  tsk trash restore --tag urgent`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch {
+			case projectName != "":
+				projects, err := st.ListTrashedProjects()
+				if err != nil {
+					return err
+				}
+				for _, p := range projects {
+					if strings.EqualFold(p.Name, projectName) {
+						if err := st.RestoreProject(p.ID); err != nil {
+							return err
+						}
+						fmt.Printf("Restored project: %s\n", p.Name)
+						return nil
+					}
+				}
+				return fmt.Errorf("project not in trash: %s", projectName)
+
+			case tagName != "":
+				tags, err := st.ListTrashedTags()
+				if err != nil {
+					return err
+				}
+				for _, t := range tags {
+					if t.Name == tagName {
+						if err := st.RestoreTag(t.ID); err != nil {
+							return err
+						}
+						fmt.Printf("Restored tag: %s\n", t.Name)
+						return nil
+					}
+				}
+				return fmt.Errorf("tag not in trash: %s", tagName)
+			}
+
+			if len(args) == 0 {
+				return fmt.Errorf("task id, --project, or --tag is required")
+			}
+			id, err := strconv.ParseInt(args[0], 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid task id: %s", args[0])
+			}
+
+			if err := st.RestoreTask(id); err != nil {
+				return err
+			}
+			task, err := st.GetTask(id)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Restored task #%d: %s\n", task.ID, task.Title)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&projectName, "project", "p", "", "restore a project by name")
+	cmd.Flags().StringVarP(&tagName, "tag", "t", "", "restore a tag by name")
+
+	cmd.MarkFlagsMutuallyExclusive("project", "tag")
+
+	return cmd
+}
+
+func newTrashPurgeCmd() *cobra.Command {
+	var (
+		olderThan string
+		force     bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "purge",
+		Short: "Permanently delete items in the trash",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			before := time.Now()
+			if olderThan != "" {
+				age, err := parseAge(olderThan)
+				if err != nil {
+					return err
+				}
+				before = before.Add(-age)
+			}
+
+			if !force {
+				if olderThan != "" {
+					fmt.Printf("Permanently delete items trashed more than %s ago? [y/N] ", olderThan)
+				} else {
+					fmt.Print("Permanently delete everything in the trash? [y/N] ")
+				}
+				var confirm string
+				fmt.Scanln(&confirm)
+				if confirm != "y" && confirm != "Y" {
+					fmt.Println("Cancelled.")
+					return nil
+				}
+			}
+
+			n, err := st.PurgeTrash(before)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Purged %d item(s).\n", n)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&olderThan, "older-than", "", "only purge items trashed longer ago than this (e.g. 30d, 2w, 12h)")
+	cmd.Flags().BoolVarP(&force, "force", "f", false, "skip confirmation")
+
+	return cmd
+}
+
+// parseAge parses a duration, additionally accepting days (30d) and weeks (2w)
+func parseAge(s string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			if v, err := strconv.Atoi(n); err == nil && v >= 0 {
+				return time.Duration(v) * unit, nil
+			}
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age %q (e.g. 30d, 2w, 12h)", s)
+	}
+	return d, nil
+}
+
+func formatDeleted(t time.Time) string {
+	return t.Local().Format("2006-01-02 15:04")
+}
@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hwanchang/tsk/internal/store"
+)
+
+func newUndoCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "undo",
+		Short: "Undo the last change",
+		Long: `Undo the last change made from the CLI or the TUI.
+
+Each command is undone as a whole, e.g. undoing a task deletion also restores
+its subtasks, tags, and recurrence. Undone changes can be re-applied with
+tsk redo until another change is made.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			label, err := st.Undo()
+			if errors.Is(err, store.ErrNothingToUndo) {
+				fmt.Println("Nothing to undo.")
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Undid: %s\n", label)
+			return nil
+		},
+	}
+}
+
+func newRedoCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "redo",
+		Short: "Redo the last undone change",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			label, err := st.Redo()
+			if errors.Is(err, store.ErrNothingToRedo) {
+				fmt.Println("Nothing to redo.")
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Redid: %s\n", label)
+			return nil
+		},
+	}
+}
@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hwanchang/tsk/internal/filter"
+	"github.com/hwanchang/tsk/internal/model"
+)
+
+func newViewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "view",
+		Short: "Manage saved views",
+		Long: `Manage saved views: named filter queries, listed with "tsk list @name" and
+shown alongside projects in the TUI. See "tsk list --help" for the query syntax.`,
+	}
+
+	cmd.AddCommand(newViewListCmd())
+	cmd.AddCommand(newViewSaveCmd())
+	cmd.AddCommand(newViewRmCmd())
+
+	return cmd
+}
+
+func newViewListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List saved views",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			views, err := st.ListViews()
+			if err != nil {
+				return err
+			}
+
+			if len(views) == 0 {
+				fmt.Println("No views found.")
+				return nil
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "NAME\tQUERY")
+			for _, v := range views {
+				fmt.Fprintf(w, "@%s\t%s\n", v.Name, v.Query)
+			}
+			return w.Flush()
+		},
+	}
+}
+
+func newViewSaveCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "save <name> <query>",
+		Short: "Save a query as a view, replacing any view with that name",
+		Example: `  tsk view save urgent "tag:urgent status:todo"
+  tsk list @urgent`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := strings.TrimPrefix(args[0], "@")
+			query := strings.Join(args[1:], " ")
+			if err := model.ValidateViewName(name); err != nil {
+				return err
+			}
+
+			expr, err := parseQuery(query)
+			if err != nil {
+				return err
+			}
+			// Check the views it refers to, counting this one as saved
+			_, err = filter.Expand(expr, func(n string) (string, error) {
+				if strings.EqualFold(n, name) {
+					return query, nil
+				}
+				return viewQuery(n)
+			})
+			if err != nil {
+				return err
+			}
+
+			v := &model.View{Name: name, Query: query}
+			if err := st.SaveView(v); err != nil {
+				return err
+			}
+			fmt.Printf("Saved view @%s: %s\n", v.Name, v.Query)
+			return nil
+		},
+	}
+}
+
+func newViewRmCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "rm <name>",
+		Short: "Delete a saved view",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := strings.TrimPrefix(args[0], "@")
+			if err := st.DeleteView(name); err != nil {
+				return err
+			}
+			fmt.Printf("Deleted view @%s\n", name)
+			return nil
+		},
+	}
+}
@@ -4,15 +4,29 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+
+	"github.com/hwanchang/tsk/internal/model"
 )
 
 type Config struct {
 	Theme string `json:"theme"`
+
+	// AutoCompleteParent completes a task when its last open subtask is done
+	AutoCompleteParent bool `json:"auto_complete_parent"`
+
+	// Sort is the task order in the TUI, e.g. "priority,due"; empty for the
+	// manual order
+	Sort string `json:"sort,omitempty"`
+
+	// Urgency weighs the factors of task urgency; coefficients left out keep
+	// their defaults
+	Urgency model.UrgencyCoefficients `json:"urgency"`
 }
 
 var (
 	current = Config{
-		Theme: "purple", // default theme
+		Theme:   "purple", // default theme
+		Urgency: model.DefaultUrgencyCoefficients(),
 	}
 	configPath string
 )
@@ -57,3 +71,15 @@ func SetTheme(theme string) {
 func GetTheme() string {
 	return current.Theme
 }
+
+func SetSort(sort string) {
+	current.Sort = sort
+}
+
+func GetSort() string {
+	return current.Sort
+}
+
+func GetUrgency() model.UrgencyCoefficients {
+	return current.Urgency
+}
@@ -0,0 +1,275 @@
+// Package dates parses the due dates accepted by the CLI and the TUI, from
+// "2026-10-20" and "2026-10-20T14:00" to "fri", "next monday", "in 3 weeks",
+// "end of month", "w43", "2pm", and "tomorrow 9:30".
+//
+// Dates without a time of day are due at the end of the day. Weekdays and
+// year-less dates refer to the next such day, counting today; "next <weekday>"
+// is that day in the following week (weeks start on Monday).
+package dates
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Parse parses a due date relative to the current time
+func Parse(s string) (time.Time, error) {
+	return ParseAt(s, time.Now())
+}
+
+// ParseAt parses a due date relative to now, in now's location
+func ParseAt(s string, now time.Time) (time.Time, error) {
+	input := strings.Join(strings.Fields(strings.ToLower(s)), " ")
+	if input == "" {
+		return time.Time{}, fmt.Errorf("empty date")
+	}
+	loc := now.Location()
+
+	// Full timestamps
+	if t, err := time.Parse(time.RFC3339, strings.ToUpper(input)); err == nil {
+		return t.In(loc), nil
+	}
+	for _, layout := range []string{"2006-01-02t15:04:05", "2006-01-02t15:04"} {
+		if t, err := time.ParseInLocation(layout, input, loc); err == nil {
+			return t, nil
+		}
+	}
+
+	// Split off a trailing time of day: "tomorrow 9:30", "fri at 2pm"
+	words := strings.Fields(input)
+	hour, minute, timed := 0, 0, false
+	for n := 2; n >= 1 && !timed; n-- {
+		if len(words) < n {
+			continue
+		}
+		if h, m, ok := parseClock(strings.Join(words[len(words)-n:], "")); ok {
+			hour, minute, timed = h, m, true
+			words = words[:len(words)-n]
+			if len(words) > 0 && words[len(words)-1] == "at" {
+				words = words[:len(words)-1]
+			}
+		}
+	}
+
+	// The time is set on the calendar, so days when clocks change keep it
+	at := func(day time.Time) time.Time {
+		return time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, loc)
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	if len(words) == 0 {
+		if !timed {
+			return time.Time{}, fmt.Errorf("unrecognized date: %s", s)
+		}
+		// A time alone is today, or tomorrow once it has passed
+		t := at(today)
+		if t.Before(now) {
+			t = at(today.AddDate(0, 0, 1))
+		}
+		return t, nil
+	}
+
+	day, ok := parseDay(strings.Join(words, " "), today)
+	if !ok {
+		return time.Time{}, fmt.Errorf("unrecognized date: %s", s)
+	}
+	if timed {
+		return at(day), nil
+	}
+	return EndOfDay(day), nil
+}
+
+// EndOfDay returns the last second of t's day
+func EndOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 23, 59, 59, 0, t.Location())
+}
+
+// HasTime reports whether a due date has a time of day, rather than being
+// due at the end of the day
+func HasTime(t time.Time) bool {
+	return t.Hour() != 23 || t.Minute() != 59
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tues": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thur": time.Thursday, "thurs": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+}
+
+// Weekday parses a weekday name such as "fri" or "Friday"
+func Weekday(name string) (time.Weekday, bool) {
+	wd, ok := weekdays[strings.ToLower(name)]
+	return wd, ok
+}
+
+var (
+	relativeRe = regexp.MustCompile(`^(?:in )?(\d+|a|an) ?(d|days?|w|weeks?|months?|y|years?)$`)
+	weekRe     = regexp.MustCompile(`^(?:(\d{4})-?)?(?:w|wk|week) ?(\d{1,2})$`)
+)
+
+// parseDay parses the date part of the input to midnight of that day
+func parseDay(s string, today time.Time) (time.Time, bool) {
+	switch s {
+	case "today", "tod", "eod", "end of day", "tonight":
+		return today, true
+	case "tomorrow", "tmr", "tom":
+		return today.AddDate(0, 0, 1), true
+	case "yesterday":
+		return today.AddDate(0, 0, -1), true
+	case "next week":
+		return today.AddDate(0, 0, 7), true
+	case "next month":
+		return today.AddDate(0, 1, 0), true
+	case "next year":
+		return today.AddDate(1, 0, 0), true
+	case "eow", "end of week", "end of the week":
+		return weekStart(today).AddDate(0, 0, 6), true
+	case "eom", "end of month", "end of the month":
+		return time.Date(today.Year(), today.Month()+1, 0, 0, 0, 0, 0, today.Location()), true
+	case "eoy", "end of year", "end of the year":
+		return time.Date(today.Year(), time.December, 31, 0, 0, 0, 0, today.Location()), true
+	}
+
+	// Weekdays: "fri", "this fri", "next fri"
+	if wd, ok := weekdays[strings.TrimPrefix(s, "this ")]; ok {
+		return today.AddDate(0, 0, (int(wd)-int(today.Weekday())+7)%7), true
+	}
+	if name, ok := strings.CutPrefix(s, "next "); ok {
+		if wd, ok := weekdays[name]; ok {
+			offset := (int(wd) + 6) % 7 // days after Monday
+			return weekStart(today).AddDate(0, 0, 7+offset), true
+		}
+	}
+
+	// Relative: "in 3 weeks", "2 days", "3d", "in a month"
+	if m := relativeRe.FindStringSubmatch(s); m != nil {
+		n := 1
+		if m[1] != "a" && m[1] != "an" {
+			n, _ = strconv.Atoi(m[1])
+		}
+		switch m[2][0] {
+		case 'd':
+			return today.AddDate(0, 0, n), true
+		case 'w':
+			return today.AddDate(0, 0, 7*n), true
+		case 'm':
+			return today.AddDate(0, n, 0), true
+		case 'y':
+			return today.AddDate(n, 0, 0), true
+		}
+	}
+
+	// ISO week numbers: "w43", "week 43", "2026-w43" are due at the end of the week
+	if m := weekRe.FindStringSubmatch(s); m != nil {
+		week, _ := strconv.Atoi(m[2])
+		year := today.Year()
+		if m[1] != "" {
+			year, _ = strconv.Atoi(m[1])
+		}
+		day, ok := isoWeekEnd(year, week, today.Location())
+		if ok && m[1] == "" && day.Before(today) {
+			day, ok = isoWeekEnd(year+1, week, today.Location())
+		}
+		return day, ok
+	}
+
+	return parseCalendarDate(s, today)
+}
+
+var (
+	fullLayouts = []string{
+		"2006-01-02", "2006/01/02", "1/2/2006", "01/02/2006",
+		"Jan 2 2006", "Jan 2, 2006", "January 2 2006", "January 2, 2006",
+		"2 Jan 2006", "2 January 2006",
+	}
+	yearlessLayouts = []string{
+		"01-02", "1/2", "01/02", "Jan 2", "January 2", "2 Jan", "2 January",
+	}
+)
+
+// parseCalendarDate parses dates such as "2026-10-20", "10/20", or "oct 20"
+// (month names match in any case). Dates without a year are in the current
+// year, or the next once passed.
+func parseCalendarDate(s string, today time.Time) (time.Time, bool) {
+	loc := today.Location()
+	for _, layout := range fullLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, true
+		}
+	}
+	for _, layout := range yearlessLayouts {
+		t, err := time.ParseInLocation(layout, s, loc)
+		if err != nil {
+			continue
+		}
+		t = time.Date(today.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+		if t.Before(today) {
+			t = t.AddDate(1, 0, 0)
+		}
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+var clockRe = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?(am|pm)?$`)
+
+// parseClock parses a time of day such as "2pm", "9:30", "9:30am", or "noon"
+// to its hour and minute. Bare hours need am/pm so "3" isn't a time.
+func parseClock(s string) (hour, minute int, ok bool) {
+	switch s {
+	case "noon":
+		return 12, 0, true
+	case "midnight":
+		return 0, 0, true
+	}
+
+	m := clockRe.FindStringSubmatch(s)
+	if m == nil || m[2] == "" && m[3] == "" {
+		return 0, 0, false
+	}
+	hour, _ = strconv.Atoi(m[1])
+	minute, _ = strconv.Atoi(m[2])
+	if minute > 59 {
+		return 0, 0, false
+	}
+	switch m[3] {
+	case "":
+		if hour > 23 {
+			return 0, 0, false
+		}
+	case "am", "pm":
+		if hour < 1 || hour > 12 {
+			return 0, 0, false
+		}
+		hour %= 12
+		if m[3] == "pm" {
+			hour += 12
+		}
+	}
+	return hour, minute, true
+}
+
+// weekStart returns the Monday of t's week
+func weekStart(t time.Time) time.Time {
+	return t.AddDate(0, 0, -((int(t.Weekday()) + 6) % 7))
+}
+
+// isoWeekEnd returns the Sunday that ends ISO week number week of year
+func isoWeekEnd(year, week int, loc *time.Location) (time.Time, bool) {
+	if week < 1 || week > 53 {
+		return time.Time{}, false
+	}
+	// January 4th is always in week 1
+	monday := weekStart(time.Date(year, time.January, 4, 0, 0, 0, 0, loc)).AddDate(0, 0, 7*(week-1))
+	if _, w := monday.ISOWeek(); w != week {
+		return time.Time{}, false // week 53 in a year with 52
+	}
+	return monday.AddDate(0, 0, 6), true
+}
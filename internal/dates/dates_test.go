@@ -0,0 +1,134 @@
+package dates
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestParseAt(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Saturday, in ISO week 42 of a year with 53 weeks. Clocks go back an
+	// hour at 3am on Sunday, October 25.
+	now := time.Date(2026, time.October, 17, 10, 0, 0, 0, berlin)
+	at := func(y int, m time.Month, d, hour, min int) time.Time {
+		return time.Date(y, m, d, hour, min, 0, 0, berlin)
+	}
+	eod := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, 23, 59, 59, 0, berlin)
+	}
+
+	tests := []struct {
+		input string
+		now   time.Time // if not now
+		want  time.Time
+	}{
+		// Timestamps and calendar dates
+		{input: "2026-10-20", want: eod(2026, time.October, 20)},
+		{input: "2026-10-20T14:00", want: at(2026, time.October, 20, 14, 0)},
+		{input: "2026-10-20t14:00:30", want: at(2026, time.October, 20, 14, 0).Add(30 * time.Second)},
+		{input: "2026-10-20T12:00:00Z", want: at(2026, time.October, 20, 14, 0)},
+		{input: "2026/10/20", want: eod(2026, time.October, 20)},
+		{input: "1/2/2026", want: eod(2026, time.January, 2)},
+		{input: "Oct 20, 2026", want: eod(2026, time.October, 20)},
+		{input: "20 october 2026", want: eod(2026, time.October, 20)},
+
+		// Dates without a year roll over to next year once passed
+		{input: "oct 17", want: eod(2026, time.October, 17)},
+		{input: "10/16", want: eod(2027, time.October, 16)},
+		{input: "jan 5", want: eod(2027, time.January, 5)},
+		{input: "12-31", want: eod(2026, time.December, 31)},
+		{input: "2 Nov", want: eod(2026, time.November, 2)},
+
+		// Named days
+		{input: "today", want: eod(2026, time.October, 17)},
+		{input: "tonight", want: eod(2026, time.October, 17)},
+		{input: "tomorrow", want: eod(2026, time.October, 18)},
+		{input: "tmr", want: eod(2026, time.October, 18)},
+		{input: "yesterday", want: eod(2026, time.October, 16)},
+		{input: "eow", want: eod(2026, time.October, 18)},
+		{input: "end of month", want: eod(2026, time.October, 31)},
+		{input: "eoy", want: eod(2026, time.December, 31)},
+		{input: "next week", want: eod(2026, time.October, 24)},
+		{input: "next month", want: eod(2026, time.November, 17)},
+		{input: "next year", want: eod(2027, time.October, 17)},
+
+		// Weekdays count today; "next" is in the following week
+		{input: "sat", want: eod(2026, time.October, 17)},
+		{input: "fri", want: eod(2026, time.October, 23)},
+		{input: "this Monday", want: eod(2026, time.October, 19)},
+		{input: "next mon", want: eod(2026, time.October, 19)},
+		{input: "next fri", want: eod(2026, time.October, 23)},
+		{input: "next sunday", want: eod(2026, time.October, 25)},
+
+		// Relative
+		{input: "3d", want: eod(2026, time.October, 20)},
+		{input: "2 days", want: eod(2026, time.October, 19)},
+		{input: "in 3 weeks", want: eod(2026, time.November, 7)},
+		{input: "in a month", want: eod(2026, time.November, 17)},
+		{input: "1y", want: eod(2027, time.October, 17)},
+
+		// ISO weeks end on Sunday; passed weeks are next year's
+		{input: "w42", want: eod(2026, time.October, 18)},
+		{input: "week 43", want: eod(2026, time.October, 25)},
+		{input: "w41", want: eod(2027, time.October, 17)},
+		{input: "w53", want: eod(2027, time.January, 3)},
+		{input: "2026-w53", want: eod(2027, time.January, 3)},
+		{input: "2027-W01", want: eod(2027, time.January, 10)},
+
+		// Times of day alone are today, or tomorrow once passed
+		{input: "2pm", want: at(2026, time.October, 17, 14, 0)},
+		{input: "10:00", want: at(2026, time.October, 17, 10, 0)},
+		{input: "9:30", want: at(2026, time.October, 18, 9, 30)},
+		{input: "9am", want: at(2026, time.October, 18, 9, 0)},
+		{input: "12am", want: at(2026, time.October, 18, 0, 0)},
+		{input: "noon", want: at(2026, time.October, 17, 12, 0)},
+		{input: "midnight", want: at(2026, time.October, 18, 0, 0)},
+
+		// Dates with a time
+		{input: "tomorrow 9:30", want: at(2026, time.October, 18, 9, 30)},
+		{input: "fri at 2pm", want: at(2026, time.October, 23, 14, 0)},
+		{input: "next mon 2 pm", want: at(2026, time.October, 19, 14, 0)},
+		{input: "2026-10-20 17:00", want: at(2026, time.October, 20, 17, 0)},
+
+		// The day clocks go back has 25 hours, which mustn't shift the time
+		{input: "oct 25 2pm", want: at(2026, time.October, 25, 14, 0)},
+		{input: "next sun 23:30", want: at(2026, time.October, 25, 23, 30)},
+		{input: "1am", now: at(2026, time.October, 24, 23, 0), want: at(2026, time.October, 25, 1, 0)},
+		{input: "9am", now: at(2026, time.October, 25, 12, 0), want: at(2026, time.October, 26, 9, 0)},
+		{input: "oct 25", want: eod(2026, time.October, 25)},
+
+		// And the day they go forward, 23
+		{input: "2026-03-29 18:00", want: at(2026, time.March, 29, 18, 0)},
+		{input: "tomorrow 8pm", now: at(2026, time.March, 28, 12, 0), want: at(2026, time.March, 29, 20, 0)},
+	}
+	for _, tt := range tests {
+		ref := now
+		if !tt.now.IsZero() {
+			ref = tt.now
+		}
+		got, err := ParseAt(tt.input, ref)
+		if err != nil {
+			t.Errorf("ParseAt(%q): %v", tt.input, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("ParseAt(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseAtInvalid(t *testing.T) {
+	now := time.Date(2026, time.October, 17, 10, 0, 0, 0, time.UTC)
+	for _, input := range []string{
+		"", "   ", "3", "soon", "25:00", "9:75", "13pm", "0am",
+		"w0", "w54", "2027-w53", "feb 30 2026", "next", "in days",
+	} {
+		if got, err := ParseAt(input, now); err == nil {
+			t.Errorf("ParseAt(%q) = %v, want an error", input, got)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+)
+
+// JournalTables are the tables whose row changes are recorded for undo/redo
+var JournalTables = []string{"projects", "tasks", "tags", "task_tags", "recurrences", "task_dependencies", "time_entries", "reminders", "views"}
+
+// syncJournal (re)creates the undo triggers for every journaled table so they
+// capture all of its current columns. Triggers are only rewritten when their
+// definition changed, e.g. after a migration added a column.
+func (db *DB) syncJournal() error {
+	for _, table := range JournalTables {
+		columns, err := db.Columns(table)
+		if err != nil {
+			return err
+		}
+
+		for _, op := range []string{"insert", "update", "delete"} {
+			name := fmt.Sprintf("undo_%s_%s", table, op)
+			want := journalTrigger(name, table, op, columns)
+
+			var have string
+			db.QueryRow("SELECT sql FROM sqlite_master WHERE type = 'trigger' AND name = ?", name).Scan(&have)
+			if have == want {
+				continue
+			}
+
+			if _, err := db.Exec("DROP TRIGGER IF EXISTS " + name); err != nil {
+				return fmt.Errorf("drop trigger %s: %w", name, err)
+			}
+			if _, err := db.Exec(want); err != nil {
+				return fmt.Errorf("create trigger %s: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func journalTrigger(name, table, op string, columns []string) string {
+	row := func(prefix string) string {
+		parts := make([]string, len(columns))
+		for i, c := range columns {
+			parts[i] = fmt.Sprintf("'%s', %s.%s", c, prefix, c)
+		}
+		return "json_object(" + strings.Join(parts, ", ") + ")"
+	}
+
+	oldRow, newRow := "NULL", "NULL"
+	switch op {
+	case "insert":
+		newRow = row("NEW")
+	case "update":
+		oldRow, newRow = row("OLD"), row("NEW")
+	case "delete":
+		oldRow = row("OLD")
+	}
+
+	return fmt.Sprintf(`CREATE TRIGGER %s AFTER %s ON %s
+WHEN (SELECT recording FROM undo_state WHERE id = 1) IS NOT NULL
+BEGIN
+    INSERT INTO undo_changes (group_id, tbl, op, old_row, new_row)
+    VALUES ((SELECT recording FROM undo_state WHERE id = 1), '%s', '%s', %s, %s);
+END`, name, strings.ToUpper(op), table, table, op, oldRow, newRow)
+}
+
+// Columns returns the column names of a table in declaration order
+func (db *DB) Columns(table string) ([]string, error) {
+	rows, err := db.Query("SELECT name FROM pragma_table_info(?) ORDER BY cid", table)
+	if err != nil {
+		return nil, fmt.Errorf("read columns of %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("scan column: %w", err)
+		}
+		columns = append(columns, name)
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("table not found: %s", table)
+	}
+	return columns, rows.Err()
+}
@@ -0,0 +1,158 @@
+package db
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Migrations live in migrations/ as NNNN_description.sql and are applied in
+// order, each in its own transaction. They must not contain PRAGMA statements
+// that are no-ops inside a transaction (e.g. foreign_keys).
+//
+//go:embed migrations/*.sql
+var migrationFS embed.FS
+
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+type MigrationStatus struct {
+	Migration
+	Applied bool
+}
+
+// Migrations returns all embedded migrations ordered by version
+func Migrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFS, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("read migrations: %w", err)
+	}
+
+	var migrations []Migration
+	seen := map[int]string{}
+	for _, e := range entries {
+		name := e.Name()
+		prefix, desc, ok := strings.Cut(strings.TrimSuffix(name, ".sql"), "_")
+		version, err := strconv.Atoi(prefix)
+		if !ok || err != nil || version < 1 {
+			return nil, fmt.Errorf("invalid migration file name: %s", name)
+		}
+		if other, dup := seen[version]; dup {
+			return nil, fmt.Errorf("duplicate migration version %d: %s, %s", version, other, name)
+		}
+		seen[version] = name
+
+		data, err := migrationFS.ReadFile(path.Join("migrations", name))
+		if err != nil {
+			return nil, fmt.Errorf("read migration %s: %w", name, err)
+		}
+		migrations = append(migrations, Migration{
+			Version: version,
+			Name:    strings.ReplaceAll(desc, "_", " "),
+			SQL:     string(data),
+		})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Migrate applies all pending migrations. It refuses to touch a database
+// whose schema is newer than this binary knows about.
+func (db *DB) Migrate() error {
+	migrations, err := Migrations()
+	if err != nil {
+		return err
+	}
+
+	version, err := db.SchemaVersion()
+	if err != nil {
+		return err
+	}
+	if err := checkVersion(version, migrations); err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if m.Version <= version {
+			continue
+		}
+		if err := db.apply(m); err != nil {
+			return err
+		}
+	}
+	return db.syncJournal()
+}
+
+// MigrationStatus lists every known migration and whether it has been applied
+func (db *DB) MigrationStatus() (int, []MigrationStatus, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	version, err := db.SchemaVersion()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	status := make([]MigrationStatus, len(migrations))
+	for i, m := range migrations {
+		status[i] = MigrationStatus{Migration: m, Applied: m.Version <= version}
+	}
+	return version, status, nil
+}
+
+// SchemaVersion returns the version of the most recently applied migration
+func (db *DB) SchemaVersion() (int, error) {
+	if _, err := db.Exec("CREATE TABLE IF NOT EXISTS schema_version (version INTEGER PRIMARY KEY)"); err != nil {
+		return 0, fmt.Errorf("create schema_version table: %w", err)
+	}
+
+	var version int
+	row := db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_version")
+	if err := row.Scan(&version); err != nil {
+		return 0, fmt.Errorf("read schema version: %w", err)
+	}
+	return version, nil
+}
+
+func (db *DB) apply(m Migration) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin migration %d: %w", m.Version, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(m.SQL); err != nil {
+		return fmt.Errorf("apply migration %d (%s): %w", m.Version, m.Name, err)
+	}
+	if _, err := tx.Exec("INSERT INTO schema_version (version) VALUES (?)", m.Version); err != nil {
+		return fmt.Errorf("record migration %d: %w", m.Version, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit migration %d: %w", m.Version, err)
+	}
+	return nil
+}
+
+func checkVersion(version int, migrations []Migration) error {
+	latest := 0
+	if len(migrations) > 0 {
+		latest = migrations[len(migrations)-1].Version
+	}
+	if version > latest {
+		return fmt.Errorf("database schema version %d is newer than this version of tsk supports (%d); please upgrade tsk", version, latest)
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+-- Undo history. Each store transaction becomes a group of row changes,
+-- recorded by triggers that db.syncJournal keeps in sync with the schema.
+CREATE TABLE IF NOT EXISTS undo_groups (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    undone INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS undo_changes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    group_id INTEGER NOT NULL REFERENCES undo_groups(id) ON DELETE CASCADE,
+    tbl TEXT NOT NULL,
+    op TEXT NOT NULL CHECK(op IN ('insert', 'update', 'delete')),
+    old_row TEXT, -- JSON, NULL for inserts
+    new_row TEXT  -- JSON, NULL for deletes
+);
+
+CREATE INDEX IF NOT EXISTS idx_undo_changes_group ON undo_changes(group_id);
+
+-- The group currently being recorded, or NULL. Only ever set inside a
+-- transaction, so other connections always see NULL.
+CREATE TABLE IF NOT EXISTS undo_state (
+    id INTEGER PRIMARY KEY CHECK(id = 1),
+    recording INTEGER
+);
+
+INSERT OR IGNORE INTO undo_state (id, recording) VALUES (1, NULL);
@@ -0,0 +1,7 @@
+-- Soft delete. Trashed rows keep their data until purged; deleted_at is
+-- NULL for live rows.
+ALTER TABLE tasks ADD COLUMN deleted_at DATETIME;
+ALTER TABLE projects ADD COLUMN deleted_at DATETIME;
+ALTER TABLE tags ADD COLUMN deleted_at DATETIME;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_deleted ON tasks(deleted_at);
@@ -0,0 +1,11 @@
+-- Time tracking: one row per timer run. A running timer has no ended_at;
+-- the store keeps at most one running at a time.
+CREATE TABLE IF NOT EXISTS time_entries (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    started_at DATETIME NOT NULL,
+    ended_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_time_entries_task ON time_entries(task_id);
+CREATE INDEX IF NOT EXISTS idx_time_entries_running ON time_entries(ended_at) WHERE ended_at IS NULL;
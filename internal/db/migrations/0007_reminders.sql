@@ -0,0 +1,14 @@
+-- Reminders fire at a fixed time (remind_at) or a number of minutes before
+-- the task's due date (offset_minutes). fired_at is set once delivered.
+CREATE TABLE IF NOT EXISTS reminders (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    remind_at DATETIME,
+    offset_minutes INTEGER,
+    fired_at DATETIME,
+    created_at DATETIME NOT NULL,
+    CHECK ((remind_at IS NULL) != (offset_minutes IS NULL))
+);
+
+CREATE INDEX IF NOT EXISTS idx_reminders_task ON reminders(task_id);
+CREATE INDEX IF NOT EXISTS idx_reminders_pending ON reminders(fired_at) WHERE fired_at IS NULL;
@@ -0,0 +1,31 @@
+-- Recurrences are stored as RFC 5545 RRULE values (e.g.
+-- FREQ=WEEKLY;BYDAY=MO,WE,FR) instead of a fixed pattern and interval.
+-- occurrence counts the tasks in the series so far, for COUNT limits.
+CREATE TABLE recurrences_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id INTEGER UNIQUE REFERENCES tasks(id) ON DELETE CASCADE,
+    rule TEXT NOT NULL,
+    occurrence INTEGER NOT NULL DEFAULT 1 CHECK(occurrence > 0),
+    next_due DATETIME NOT NULL
+);
+
+INSERT INTO recurrences_new (id, task_id, rule, next_due)
+SELECT id, task_id,
+       'FREQ=' || upper(pattern) || CASE WHEN interval > 1 THEN ';INTERVAL=' || interval ELSE '' END,
+       next_due
+FROM recurrences;
+
+DROP TABLE recurrences;
+ALTER TABLE recurrences_new RENAME TO recurrences;
+
+-- Keep journaled recurrence rows undoable in the new shape
+UPDATE undo_changes SET
+    old_row = CASE WHEN old_row IS NULL THEN NULL ELSE json_set(json_remove(old_row, '$.pattern', '$.interval'),
+        '$.rule', 'FREQ=' || upper(json_extract(old_row, '$.pattern')) ||
+            CASE WHEN json_extract(old_row, '$.interval') > 1 THEN ';INTERVAL=' || json_extract(old_row, '$.interval') ELSE '' END,
+        '$.occurrence', 1) END,
+    new_row = CASE WHEN new_row IS NULL THEN NULL ELSE json_set(json_remove(new_row, '$.pattern', '$.interval'),
+        '$.rule', 'FREQ=' || upper(json_extract(new_row, '$.pattern')) ||
+            CASE WHEN json_extract(new_row, '$.interval') > 1 THEN ';INTERVAL=' || json_extract(new_row, '$.interval') ELSE '' END,
+        '$.occurrence', 1) END
+WHERE tbl = 'recurrences';
@@ -0,0 +1,5 @@
+-- How a recurrence schedules its next occurrence: from the completed task's
+-- due date or from when it was completed, and whether occurrences missed
+-- while a task was overdue are skipped or each created.
+ALTER TABLE recurrences ADD COLUMN anchor TEXT NOT NULL DEFAULT 'due' CHECK(anchor IN ('due', 'completion'));
+ALTER TABLE recurrences ADD COLUMN catch_up TEXT NOT NULL DEFAULT 'skip' CHECK(catch_up IN ('skip', 'all'));
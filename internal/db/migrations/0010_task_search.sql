@@ -0,0 +1,52 @@
+-- Full-text index over each task's title, description, tag names, and
+-- project name, keyed by task id. Triggers keep it in sync with the tables
+-- it draws from, including changes replayed by undo.
+CREATE VIRTUAL TABLE tasks_fts USING fts5(
+    title, description, tags, project,
+    prefix = '2 3',
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+
+INSERT INTO tasks_fts (rowid, title, description, tags, project)
+SELECT t.id, t.title, COALESCE(t.description, ''),
+       COALESCE((SELECT group_concat(g.name, ' ') FROM task_tags tt JOIN tags g ON g.id = tt.tag_id WHERE tt.task_id = t.id AND g.deleted_at IS NULL), ''),
+       COALESCE((SELECT p.name FROM projects p WHERE p.id = t.project_id AND p.deleted_at IS NULL), '')
+FROM tasks t;
+
+CREATE TRIGGER tasks_fts_insert AFTER INSERT ON tasks BEGIN
+    INSERT INTO tasks_fts (rowid, title, description, tags, project)
+    VALUES (NEW.id, NEW.title, COALESCE(NEW.description, ''), '',
+            COALESCE((SELECT name FROM projects WHERE id = NEW.project_id AND deleted_at IS NULL), ''));
+END;
+
+CREATE TRIGGER tasks_fts_update AFTER UPDATE OF title, description, project_id ON tasks BEGIN
+    UPDATE tasks_fts SET
+        title = NEW.title,
+        description = COALESCE(NEW.description, ''),
+        project = COALESCE((SELECT name FROM projects WHERE id = NEW.project_id AND deleted_at IS NULL), '')
+    WHERE rowid = NEW.id;
+END;
+
+CREATE TRIGGER tasks_fts_delete AFTER DELETE ON tasks BEGIN
+    DELETE FROM tasks_fts WHERE rowid = OLD.id;
+END;
+
+CREATE TRIGGER tasks_fts_tag_insert AFTER INSERT ON task_tags BEGIN
+    UPDATE tasks_fts SET tags = COALESCE((SELECT group_concat(g.name, ' ') FROM task_tags tt JOIN tags g ON g.id = tt.tag_id WHERE tt.task_id = NEW.task_id AND g.deleted_at IS NULL), '')
+    WHERE rowid = NEW.task_id;
+END;
+
+CREATE TRIGGER tasks_fts_tag_delete AFTER DELETE ON task_tags BEGIN
+    UPDATE tasks_fts SET tags = COALESCE((SELECT group_concat(g.name, ' ') FROM task_tags tt JOIN tags g ON g.id = tt.tag_id WHERE tt.task_id = OLD.task_id AND g.deleted_at IS NULL), '')
+    WHERE rowid = OLD.task_id;
+END;
+
+CREATE TRIGGER tasks_fts_tag_update AFTER UPDATE OF name, deleted_at ON tags BEGIN
+    UPDATE tasks_fts SET tags = COALESCE((SELECT group_concat(g.name, ' ') FROM task_tags tt JOIN tags g ON g.id = tt.tag_id WHERE tt.task_id = tasks_fts.rowid AND g.deleted_at IS NULL), '')
+    WHERE rowid IN (SELECT task_id FROM task_tags WHERE tag_id = NEW.id);
+END;
+
+CREATE TRIGGER tasks_fts_project_update AFTER UPDATE OF name, deleted_at ON projects BEGIN
+    UPDATE tasks_fts SET project = CASE WHEN NEW.deleted_at IS NULL THEN NEW.name ELSE '' END
+    WHERE rowid IN (SELECT id FROM tasks WHERE project_id = NEW.id);
+END;
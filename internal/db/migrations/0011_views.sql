@@ -0,0 +1,16 @@
+-- Saved views are named filter queries, listed with "tsk list @name". The
+-- smart views below ship by default and can be changed or removed like any
+-- other.
+CREATE TABLE IF NOT EXISTS views (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE COLLATE NOCASE,
+    query TEXT NOT NULL,
+    position INTEGER NOT NULL DEFAULT 0
+);
+
+INSERT INTO views (name, query, position) VALUES
+    ('today', 'status:open due:today', 1),
+    ('overdue', 'is:overdue', 2),
+    ('upcoming', 'status:open due>today due<=+7d', 3),
+    ('no-due-date', 'status:open due:none', 4),
+    ('recently-completed', 'completed>=-7d', 5);
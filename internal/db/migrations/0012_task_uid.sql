@@ -0,0 +1,13 @@
+-- Tasks get a stable UID that exports carry, so importing a file again
+-- updates the tasks it came from instead of adding copies. Existing tasks
+-- get random version 4 UUIDs.
+ALTER TABLE tasks ADD COLUMN uid TEXT;
+
+UPDATE tasks SET uid = lower(
+    hex(randomblob(4)) || '-' || hex(randomblob(2)) || '-4' ||
+    substr(hex(randomblob(2)), 2) || '-' ||
+    substr('89ab', 1 + abs(random()) % 4, 1) || substr(hex(randomblob(2)), 2) || '-' ||
+    hex(randomblob(6))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tasks_uid ON tasks(uid);
@@ -0,0 +1,62 @@
+-- Times used to be written as Go time strings, such as
+-- "2026-10-18 09:00:00.5 +0200 CEST m=+0.001", which SQLite's date functions
+-- can't read. They're now written as "2026-10-18 09:00:00.5+02:00"; this
+-- rewrites the existing ones, including those in the undo journal.
+CREATE TEMP TABLE go_times (old TEXT PRIMARY KEY, new TEXT);
+
+INSERT OR IGNORE INTO go_times (old)
+          SELECT created_at FROM projects
+    UNION SELECT deleted_at FROM projects
+    UNION SELECT due_date FROM tasks
+    UNION SELECT created_at FROM tasks
+    UNION SELECT completed_at FROM tasks
+    UNION SELECT deleted_at FROM tasks
+    UNION SELECT deleted_at FROM tags
+    UNION SELECT next_due FROM recurrences
+    UNION SELECT created_at FROM task_events
+    UNION SELECT created_at FROM undo_groups
+    UNION SELECT started_at FROM time_entries
+    UNION SELECT ended_at FROM time_entries
+    UNION SELECT remind_at FROM reminders
+    UNION SELECT fired_at FROM reminders
+    UNION SELECT created_at FROM reminders
+    UNION SELECT j.value FROM undo_changes c, json_each(c.old_row) j
+    UNION SELECT j.value FROM undo_changes c, json_each(c.new_row) j;
+
+DELETE FROM go_times WHERE old IS NULL OR typeof(old) != 'text'
+    OR old NOT GLOB '[0-9][0-9][0-9][0-9]-[0-9][0-9]-[0-9][0-9] [0-9][0-9]:[0-9][0-9]:[0-9][0-9]*[ ][+-][0-9][0-9][0-9][0-9]*';
+
+-- "2026-10-18 09:00:00" + ".5" + "+02" + ":" + "00", where what follows the
+-- seconds is ".5 +0200 CEST m=+0.001", or " +0200 CEST" without a fraction
+UPDATE go_times SET new =
+    substr(old, 1, 19) ||
+    substr(substr(old, 20), 1, instr(substr(old, 20), ' ') - 1) ||
+    substr(substr(old, 20), instr(substr(old, 20), ' ') + 1, 3) || ':' ||
+    substr(substr(old, 20), instr(substr(old, 20), ' ') + 4, 2);
+
+UPDATE projects SET created_at = (SELECT new FROM go_times WHERE old = created_at) WHERE created_at IN (SELECT old FROM go_times);
+UPDATE projects SET deleted_at = (SELECT new FROM go_times WHERE old = deleted_at) WHERE deleted_at IN (SELECT old FROM go_times);
+UPDATE tasks SET due_date = (SELECT new FROM go_times WHERE old = due_date) WHERE due_date IN (SELECT old FROM go_times);
+UPDATE tasks SET created_at = (SELECT new FROM go_times WHERE old = created_at) WHERE created_at IN (SELECT old FROM go_times);
+UPDATE tasks SET completed_at = (SELECT new FROM go_times WHERE old = completed_at) WHERE completed_at IN (SELECT old FROM go_times);
+UPDATE tasks SET deleted_at = (SELECT new FROM go_times WHERE old = deleted_at) WHERE deleted_at IN (SELECT old FROM go_times);
+UPDATE tags SET deleted_at = (SELECT new FROM go_times WHERE old = deleted_at) WHERE deleted_at IN (SELECT old FROM go_times);
+UPDATE recurrences SET next_due = (SELECT new FROM go_times WHERE old = next_due) WHERE next_due IN (SELECT old FROM go_times);
+UPDATE task_events SET created_at = (SELECT new FROM go_times WHERE old = created_at) WHERE created_at IN (SELECT old FROM go_times);
+UPDATE undo_groups SET created_at = (SELECT new FROM go_times WHERE old = created_at) WHERE created_at IN (SELECT old FROM go_times);
+UPDATE time_entries SET started_at = (SELECT new FROM go_times WHERE old = started_at) WHERE started_at IN (SELECT old FROM go_times);
+UPDATE time_entries SET ended_at = (SELECT new FROM go_times WHERE old = ended_at) WHERE ended_at IN (SELECT old FROM go_times);
+UPDATE reminders SET remind_at = (SELECT new FROM go_times WHERE old = remind_at) WHERE remind_at IN (SELECT old FROM go_times);
+UPDATE reminders SET fired_at = (SELECT new FROM go_times WHERE old = fired_at) WHERE fired_at IN (SELECT old FROM go_times);
+UPDATE reminders SET created_at = (SELECT new FROM go_times WHERE old = created_at) WHERE created_at IN (SELECT old FROM go_times);
+
+UPDATE undo_changes SET old_row = (
+    SELECT json_group_object(j.key, COALESCE((SELECT new FROM go_times WHERE old = j.value), j.value))
+    FROM json_each(old_row) j
+) WHERE old_row IS NOT NULL;
+UPDATE undo_changes SET new_row = (
+    SELECT json_group_object(j.key, COALESCE((SELECT new FROM go_times WHERE old = j.value), j.value))
+    FROM json_each(new_row) j
+) WHERE new_row IS NOT NULL;
+
+DROP TABLE go_times;
@@ -0,0 +1,588 @@
+// Package filter parses task filter expressions such as
+//
+//	status:todo,doing project:Work tag:urgent and not tag:later due<+3d priority>=medium
+//
+// Conditions are field:value, with comma-separated values matching any of
+// them, or a comparison such as due<+3d or created>=2026-01-01. Adjacent
+// conditions must all match; "or", "not" (or a leading -), and parentheses
+// combine them. Other words search the task text, as in "tsk search", and
+// @name refers to a saved view.
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/hwanchang/tsk/internal/dates"
+	"github.com/hwanchang/tsk/internal/model"
+)
+
+// Expr is a parsed filter expression: And, Or, Not, Cond, Text, or View
+type Expr interface {
+	expr()
+}
+
+// And matches tasks matching every expression
+type And []Expr
+
+// Or matches tasks matching any expression
+type Or []Expr
+
+// Not matches tasks not matching X
+type Not struct {
+	X Expr
+}
+
+// Cond compares a task field with one or more values
+type Cond struct {
+	Field  string   // field name, with aliases resolved
+	Op     string   // ":", "=", "!=", "<", "<=", ">", or ">="
+	Values []string // normalized: status names, priority numbers, lower-case keywords
+	Times  []Span   // for date fields, the span of each value
+}
+
+// Text searches the title, description, tags, and project, using the
+// search package syntax
+type Text struct {
+	Query string
+}
+
+// View matches the tasks of a saved view, see Expand
+type View struct {
+	Name string
+}
+
+func (And) expr()  {}
+func (Or) expr()   {}
+func (Not) expr()  {}
+func (Cond) expr() {}
+func (Text) expr() {}
+func (View) expr() {}
+
+// Span is the time a date value refers to: a whole day for dates, or an
+// instant for times of day
+type Span struct {
+	Start, End time.Time
+}
+
+// Error is a syntax error at a token of the input
+type Error struct {
+	Pos   int // byte offset of the token
+	Token string
+	Msg   string
+}
+
+func (e *Error) Error() string {
+	if e.Token == "" {
+		return fmt.Sprintf("%s at end of filter", e.Msg)
+	}
+	return fmt.Sprintf("%s at %q (column %d)", e.Msg, e.Token, e.Pos+1)
+}
+
+// Pointer returns input with a line underneath marking the bad token
+func (e *Error) Pointer(input string) string {
+	width := len([]rune(e.Token))
+	if width == 0 {
+		width = 1
+	}
+	pad := strings.Repeat(" ", len([]rune(input[:min(e.Pos, len(input))])))
+	return input + "\n" + pad + strings.Repeat("^", width)
+}
+
+// field describes a filterable field
+type field struct {
+	ops   string // accepted operators besides ":"
+	parse func(value string, now time.Time) (string, *Span, error)
+}
+
+var fields = map[string]field{
+	"status":    {ops: "= !=", parse: parseStatus},
+	"project":   {ops: "= !=", parse: parseName},
+	"tag":       {ops: "= !=", parse: parseTag},
+	"priority":  {ops: "= != < <= > >=", parse: parsePriority},
+	"due":       {ops: "= != < <= > >=", parse: parseDate},
+	"created":   {ops: "= != < <= > >=", parse: parseDate},
+	"completed": {ops: "= != < <= > >=", parse: parseDate},
+	"id":        {ops: "= != < <= > >=", parse: parseID},
+	"parent":    {ops: "= !=", parse: parseParent},
+	"is":        {parse: parseIs},
+	"title":     {},
+	"desc":      {},
+}
+
+var aliases = map[string]string{
+	"s": "status", "proj": "project", "p": "project", "tags": "tag", "t": "tag",
+	"pri": "priority", "prio": "priority", "done": "completed", "description": "desc",
+}
+
+// Fields lists the field names a filter accepts
+func Fields() []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Parse parses a filter expression relative to the current time
+func Parse(input string) (Expr, error) {
+	return ParseAt(input, time.Now())
+}
+
+// ParseAt parses a filter expression, resolving relative dates against now.
+// An empty input returns a nil Expr, which matches every task.
+func ParseAt(input string, now time.Time) (Expr, error) {
+	tokens, err := tokenize(input)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+	p := &parser{tokens: tokens, now: now}
+	e, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if tok, ok := p.peek(); ok {
+		if tok.text == ")" {
+			return nil, tok.errorf("unexpected closing parenthesis")
+		}
+		return nil, tok.errorf("unexpected %q", tok.text)
+	}
+	return e, nil
+}
+
+// Mentions reports whether e has a condition on any of the fields
+func Mentions(e Expr, names ...string) bool {
+	switch e := e.(type) {
+	case And:
+		return slices.ContainsFunc(e, func(x Expr) bool { return Mentions(x, names...) })
+	case Or:
+		return slices.ContainsFunc(e, func(x Expr) bool { return Mentions(x, names...) })
+	case Not:
+		return Mentions(e.X, names...)
+	case Cond:
+		return slices.Contains(names, e.Field)
+	}
+	return false
+}
+
+// Expand replaces the views in e with their parsed queries, which lookup
+// returns by name
+func Expand(e Expr, lookup func(name string) (string, error)) (Expr, error) {
+	return expand(e, lookup, nil)
+}
+
+func expand(e Expr, lookup func(string) (string, error), seen []string) (Expr, error) {
+	switch e := e.(type) {
+	case And:
+		exprs, err := expandAll(e, lookup, seen)
+		return And(exprs), err
+	case Or:
+		exprs, err := expandAll(e, lookup, seen)
+		return Or(exprs), err
+	case Not:
+		x, err := expand(e.X, lookup, seen)
+		return Not{X: x}, err
+	case View:
+		name := strings.ToLower(e.Name)
+		if slices.Contains(seen, name) {
+			return nil, fmt.Errorf("view @%s refers to itself", e.Name)
+		}
+		query, err := lookup(e.Name)
+		if err != nil {
+			return nil, err
+		}
+		x, err := Parse(query)
+		if err != nil {
+			return nil, fmt.Errorf("view @%s: %w", e.Name, err)
+		}
+		if x == nil {
+			return And{}, nil
+		}
+		return expand(x, lookup, append(seen, name))
+	}
+	return e, nil
+}
+
+func expandAll(exprs []Expr, lookup func(string) (string, error), seen []string) ([]Expr, error) {
+	expanded := make([]Expr, len(exprs))
+	for i, x := range exprs {
+		var err error
+		if expanded[i], err = expand(x, lookup, seen); err != nil {
+			return nil, err
+		}
+	}
+	return expanded, nil
+}
+
+type token struct {
+	text   string
+	pos    int
+	quoted bool // a quoted phrase, searched as text
+}
+
+func (t token) errorf(format string, args ...any) *Error {
+	return &Error{Pos: t.pos, Token: t.text, Msg: fmt.Sprintf(format, args...)}
+}
+
+// tokenize splits input on whitespace and parentheses, keeping quoted text
+// (including values such as project:"Side project") in one token
+func tokenize(input string) ([]token, error) {
+	var tokens []token
+	start, quote := -1, -1
+	flush := func(end int) {
+		if start >= 0 {
+			text := input[start:end]
+			tok := token{text: text, pos: start}
+			if strings.HasPrefix(text, `"`) && strings.HasSuffix(text, `"`) && len(text) > 1 {
+				tok.quoted = true
+			}
+			tokens = append(tokens, tok)
+			start = -1
+		}
+	}
+	for i, c := range input {
+		switch {
+		case c == '"':
+			if start < 0 {
+				start = i
+			}
+			if quote < 0 {
+				quote = i
+			} else {
+				quote = -1
+			}
+		case quote >= 0:
+		case unicode.IsSpace(c):
+			flush(i)
+		case c == '(' || c == ')':
+			// Parentheses stand alone, except inside a word such as "(meeting)"
+			if c == '(' && start >= 0 {
+				continue
+			}
+			if c == ')' && start >= 0 && strings.Contains(input[start:i], "(") {
+				continue
+			}
+			flush(i)
+			tokens = append(tokens, token{text: string(c), pos: i})
+		default:
+			if start < 0 {
+				start = i
+			}
+		}
+	}
+	if quote >= 0 {
+		return nil, &Error{Pos: quote, Token: input[quote:], Msg: "unclosed quote"}
+	}
+	flush(len(input))
+	return tokens, nil
+}
+
+type parser struct {
+	tokens []token
+	i      int
+	now    time.Time
+}
+
+func (p *parser) peek() (token, bool) {
+	if p.i >= len(p.tokens) {
+		return token{}, false
+	}
+	return p.tokens[p.i], true
+}
+
+// keyword reports whether the next token is the keyword, consuming it
+func (p *parser) keyword(words ...string) bool {
+	tok, ok := p.peek()
+	if ok && !tok.quoted && slices.Contains(words, strings.ToLower(tok.text)) {
+		p.i++
+		return true
+	}
+	return false
+}
+
+// or parses conditions separated by "or"
+func (p *parser) or() (Expr, error) {
+	var terms Or
+	for {
+		e, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, e)
+		if !p.keyword("or", "||") {
+			break
+		}
+	}
+	if len(terms) == 1 {
+		return terms[0], nil
+	}
+	return terms, nil
+}
+
+// and parses adjacent conditions, optionally separated by "and"
+func (p *parser) and() (Expr, error) {
+	var terms And
+	for {
+		e, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, e)
+		if p.keyword("and", "&&") {
+			continue
+		}
+		tok, ok := p.peek()
+		if !ok || tok.text == ")" || !tok.quoted && slices.Contains([]string{"or", "||"}, strings.ToLower(tok.text)) {
+			break
+		}
+	}
+	if len(terms) == 1 {
+		return terms[0], nil
+	}
+	return terms, nil
+}
+
+// unary parses a condition with any number of leading "not"s
+func (p *parser) unary() (Expr, error) {
+	tok, ok := p.peek()
+	if !ok {
+		return nil, &Error{Pos: p.end(), Msg: "missing condition"}
+	}
+	if p.keyword("not", "!") {
+		e, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return Not{X: e}, nil
+	}
+	if !tok.quoted && len(tok.text) > 1 && (tok.text[0] == '-' || tok.text[0] == '!') {
+		p.i++
+		e, err := p.term(token{text: tok.text[1:], pos: tok.pos + 1})
+		if err != nil {
+			return nil, err
+		}
+		return Not{X: e}, nil
+	}
+	return p.primary()
+}
+
+// primary parses a parenthesized expression or a single condition
+func (p *parser) primary() (Expr, error) {
+	tok, _ := p.peek()
+	p.i++
+	switch {
+	case tok.quoted:
+		return Text{Query: tok.text}, nil
+	case tok.text == ")":
+		return nil, tok.errorf("missing condition before closing parenthesis")
+	case tok.text == "(":
+		e, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if next, ok := p.peek(); !ok || next.text != ")" {
+			return nil, tok.errorf("unclosed parenthesis")
+		}
+		p.i++
+		return e, nil
+	case slices.Contains([]string{"and", "or", "&&", "||"}, strings.ToLower(tok.text)):
+		return nil, tok.errorf("missing condition before %q", tok.text)
+	}
+	return p.term(tok)
+}
+
+var condRe = regexp.MustCompile(`^([a-zA-Z]+)(:|!=|<=|>=|=|<|>)(.*)$`)
+
+// term parses a field condition, a view, or a search word
+func (p *parser) term(tok token) (Expr, error) {
+	if name, ok := strings.CutPrefix(tok.text, "@"); ok {
+		if err := model.ValidateViewName(name); err != nil {
+			return nil, tok.errorf("invalid view name")
+		}
+		return View{Name: name}, nil
+	}
+	m := condRe.FindStringSubmatch(tok.text)
+	if m == nil {
+		return Text{Query: tok.text}, nil
+	}
+	name, op, value := strings.ToLower(m[1]), m[2], m[3]
+	if alias, ok := aliases[name]; ok {
+		name = alias
+	}
+	f, ok := fields[name]
+	if !ok {
+		if op == ":" && !strings.Contains(tok.text, "://") {
+			return nil, &Error{Pos: tok.pos, Token: m[1], Msg: fmt.Sprintf("unknown field %q (fields: %s)", m[1], strings.Join(Fields(), ", "))}
+		}
+		return Text{Query: tok.text}, nil
+	}
+	if op != ":" && !slices.Contains(strings.Fields(f.ops), op) {
+		return nil, &Error{Pos: tok.pos + len(m[1]), Token: op, Msg: fmt.Sprintf("%s can't be compared with %s", name, op)}
+	}
+
+	valuePos := tok.pos + len(m[1]) + len(op)
+	if value == "" {
+		return nil, &Error{Pos: tok.pos, Token: tok.text, Msg: fmt.Sprintf("missing value for %s", name)}
+	}
+	if f.parse == nil {
+		// title: and desc: search one field
+		return Text{Query: name + ":" + value}, nil
+	}
+
+	cond := Cond{Field: name, Op: op}
+	for _, v := range splitValues(value, valuePos) {
+		if op != ":" && op != "=" && op != "!=" && len(cond.Values) > 0 {
+			return nil, &Error{Pos: v.pos, Token: v.text, Msg: fmt.Sprintf("%s compares with a single value", op)}
+		}
+		if v.text == "" {
+			return nil, &Error{Pos: v.pos, Token: ",", Msg: "empty value"}
+		}
+		value, span, err := f.parse(v.text, p.now)
+		if err != nil {
+			return nil, &Error{Pos: v.pos, Token: v.text, Msg: err.Error()}
+		}
+		if (value == "none" || value == "any") && op != ":" && op != "=" && op != "!=" {
+			return nil, &Error{Pos: v.pos, Token: v.text, Msg: fmt.Sprintf("%s can't be compared with %s", v.text, op)}
+		}
+		cond.Values = append(cond.Values, value)
+		if span != nil {
+			cond.Times = append(cond.Times, *span)
+		}
+	}
+	if len(cond.Values) > 1 && (slices.Contains(cond.Values, "none") || slices.Contains(cond.Values, "any")) {
+		return nil, &Error{Pos: valuePos, Token: value, Msg: "none and any can't be combined with other values"}
+	}
+	return cond, nil
+}
+
+// splitValues splits comma-separated values, unquoting each one
+func splitValues(s string, pos int) []token {
+	var values []token
+	start, quoted := 0, false
+	for i := 0; i <= len(s); i++ {
+		if i < len(s) && s[i] == '"' {
+			quoted = !quoted
+		}
+		if i == len(s) || s[i] == ',' && !quoted {
+			text := strings.TrimSpace(strings.Trim(s[start:i], `"`))
+			values = append(values, token{text: text, pos: pos + start})
+			start = i + 1
+		}
+	}
+	return values
+}
+
+func parseStatus(v string, _ time.Time) (string, *Span, error) {
+	switch v = strings.ToLower(v); v {
+	case "todo", "doing", "done":
+		return v, nil, nil
+	case "open":
+		return v, nil, nil // todo or doing
+	}
+	return "", nil, fmt.Errorf("unknown status (use todo, doing, done, or open)")
+}
+
+func parseName(v string, _ time.Time) (string, *Span, error) {
+	return v, nil, nil
+}
+
+// parseTag parses a tag name, or "none" and "any" for untagged and tagged tasks
+func parseTag(v string, _ time.Time) (string, *Span, error) {
+	if strings.EqualFold(v, "none") || strings.EqualFold(v, "any") {
+		return strings.ToLower(v), nil, nil
+	}
+	return v, nil, nil
+}
+
+func parsePriority(v string, _ time.Time) (string, *Span, error) {
+	p, err := model.ParsePriorityName(v)
+	if err != nil {
+		return "", nil, fmt.Errorf("unknown priority (use none, low, medium, or high)")
+	}
+	return strconv.Itoa(int(p)), nil, nil
+}
+
+func parseID(v string, _ time.Time) (string, *Span, error) {
+	if _, err := strconv.ParseInt(strings.TrimPrefix(v, "#"), 10, 64); err != nil {
+		return "", nil, fmt.Errorf("invalid task ID")
+	}
+	return strings.TrimPrefix(v, "#"), nil, nil
+}
+
+func parseParent(v string, now time.Time) (string, *Span, error) {
+	if strings.EqualFold(v, "none") || strings.EqualFold(v, "any") {
+		return strings.ToLower(v), nil, nil
+	}
+	value, _, err := parseID(v, now)
+	return value, nil, err
+}
+
+var isValues = []string{"blocked", "overdue", "recurring", "actionable", "subtask"}
+
+func parseIs(v string, _ time.Time) (string, *Span, error) {
+	v = strings.ToLower(v)
+	if !slices.Contains(isValues, v) {
+		return "", nil, fmt.Errorf("unknown state (use %s)", strings.Join(isValues, ", "))
+	}
+	return v, nil, nil
+}
+
+var offsetRe = regexp.MustCompile(`^([+-])(\d+)([dwmy])$`)
+
+// parseDate parses a date such as "2026-01-01", "fri", "+3d", or "-1w" to
+// the day it refers to, or "none" and "any" for tasks without and with one
+func parseDate(v string, now time.Time) (string, *Span, error) {
+	v = strings.ToLower(v)
+	switch v {
+	case "none", "any":
+		return v, nil, nil
+	case "now":
+		return v, &Span{Start: now, End: now}, nil
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	var day time.Time
+	if m := offsetRe.FindStringSubmatch(v); m != nil {
+		n, _ := strconv.Atoi(m[2])
+		if m[1] == "-" {
+			n = -n
+		}
+		switch m[3] {
+		case "d":
+			day = today.AddDate(0, 0, n)
+		case "w":
+			day = today.AddDate(0, 0, 7*n)
+		case "m":
+			day = today.AddDate(0, n, 0)
+		case "y":
+			day = today.AddDate(n, 0, 0)
+		}
+	} else {
+		t, err := dates.ParseAt(v, now)
+		if err != nil {
+			return "", nil, fmt.Errorf("unrecognized date (use e.g. 2026-01-01, fri, +3d, or -1w)")
+		}
+		if dates.HasTime(t) {
+			return v, &Span{Start: t, End: t}, nil
+		}
+		day = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	}
+	return v, &Span{Start: day, End: dates.EndOfDay(day)}, nil
+}
+
+func (p *parser) end() int {
+	if len(p.tokens) == 0 {
+		return 0
+	}
+	last := p.tokens[len(p.tokens)-1]
+	return last.pos + len(last.text)
+}
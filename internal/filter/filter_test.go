@@ -0,0 +1,237 @@
+package filter
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+// testNow is Saturday, October 17, 2026, in Berlin
+func testNow(t *testing.T) time.Time {
+	t.Helper()
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return time.Date(2026, time.October, 17, 10, 0, 0, 0, berlin)
+}
+
+func TestParseAt(t *testing.T) {
+	now := testNow(t)
+	day := func(m time.Month, d int) Span {
+		start := time.Date(2026, m, d, 0, 0, 0, 0, now.Location())
+		return Span{Start: start, End: time.Date(2026, m, d, 23, 59, 59, 0, now.Location())}
+	}
+	instant := func(m time.Month, d, hour int) Span {
+		t := time.Date(2026, m, d, hour, 0, 0, 0, now.Location())
+		return Span{Start: t, End: t}
+	}
+	text := func(q string) Text { return Text{Query: q} }
+
+	tests := []struct {
+		input string
+		want  Expr
+	}{
+		{"", nil},
+		{"   ", nil},
+
+		// Conditions, with aliases and comma lists
+		{"status:todo", Cond{Field: "status", Op: ":", Values: []string{"todo"}}},
+		{"status:todo,doing", Cond{Field: "status", Op: ":", Values: []string{"todo", "doing"}}},
+		{"s:Open", Cond{Field: "status", Op: ":", Values: []string{"open"}}},
+		{"status!=done", Cond{Field: "status", Op: "!=", Values: []string{"done"}}},
+		{"tags:urgent,later", Cond{Field: "tag", Op: ":", Values: []string{"urgent", "later"}}},
+		{"tag:None", Cond{Field: "tag", Op: ":", Values: []string{"none"}}},
+		{`project:"Side project"`, Cond{Field: "project", Op: ":", Values: []string{"Side project"}}},
+		{`p:"a, b",c`, Cond{Field: "project", Op: ":", Values: []string{"a, b", "c"}}},
+		{"is:overdue", Cond{Field: "is", Op: ":", Values: []string{"overdue"}}},
+		{"is:blocked,recurring", Cond{Field: "is", Op: ":", Values: []string{"blocked", "recurring"}}},
+		{"id:#12", Cond{Field: "id", Op: ":", Values: []string{"12"}}},
+		{"parent:any", Cond{Field: "parent", Op: ":", Values: []string{"any"}}},
+
+		// Priorities compare by their order, none < low < medium < high
+		{"priority>=medium", Cond{Field: "priority", Op: ">=", Values: []string{"2"}}},
+		{"pri:high,low", Cond{Field: "priority", Op: ":", Values: []string{"3", "1"}}},
+		{"prio<H", Cond{Field: "priority", Op: "<", Values: []string{"3"}}},
+		{"priority:none", Cond{Field: "priority", Op: ":", Values: []string{"0"}}},
+
+		// Dates cover their whole day; times of day are instants
+		{"due:none", Cond{Field: "due", Op: ":", Values: []string{"none"}}},
+		{"due!=any", Cond{Field: "due", Op: "!=", Values: []string{"any"}}},
+		{"due<+3d", Cond{Field: "due", Op: "<", Values: []string{"+3d"}, Times: []Span{day(time.October, 20)}}},
+		{"due>-1w", Cond{Field: "due", Op: ">", Values: []string{"-1w"}, Times: []Span{day(time.October, 10)}}},
+		{"due<+1m", Cond{Field: "due", Op: "<", Values: []string{"+1m"}, Times: []Span{day(time.November, 17)}}},
+		{"due<tomorrow", Cond{Field: "due", Op: "<", Values: []string{"tomorrow"}, Times: []Span{day(time.October, 18)}}},
+		{"due<=Fri", Cond{Field: "due", Op: "<=", Values: []string{"fri"}, Times: []Span{day(time.October, 23)}}},
+		{"due:today,tomorrow", Cond{Field: "due", Op: ":", Values: []string{"today", "tomorrow"},
+			Times: []Span{day(time.October, 17), day(time.October, 18)}}},
+		{`due<"tomorrow 9am"`, Cond{Field: "due", Op: "<", Values: []string{"tomorrow 9am"}, Times: []Span{instant(time.October, 18, 9)}}},
+		{"created>=2026-01-01", Cond{Field: "created", Op: ">=", Values: []string{"2026-01-01"}, Times: []Span{day(time.January, 1)}}},
+		{"done:now", Cond{Field: "completed", Op: ":", Values: []string{"now"}, Times: []Span{{Start: now, End: now}}}},
+
+		// Search words, phrases, views, and fields searched as text
+		{"milk", text("milk")},
+		{`"buy milk"`, text(`"buy milk"`)},
+		{"title:milk", text("title:milk")},
+		{"description:milk", text("desc:milk")},
+		{"https://example.com", text("https://example.com")},
+		{"x=1", text("x=1")},
+		{"call(mom)", text("call(mom)")},
+		{"(meeting)", text("meeting")},
+		{"@work", View{Name: "work"}},
+
+		// and binds tighter than or; not tighter than both
+		{"a b", And{text("a"), text("b")}},
+		{"a and b AND c", And{text("a"), text("b"), text("c")}},
+		{"a && b", And{text("a"), text("b")}},
+		{"a b or c", Or{And{text("a"), text("b")}, text("c")}},
+		{"a or b c", Or{text("a"), And{text("b"), text("c")}}},
+		{"a || b", Or{text("a"), text("b")}},
+		{"(a or b) c", And{Or{text("a"), text("b")}, text("c")}},
+		{"a (b or (c d))", And{text("a"), Or{text("b"), And{text("c"), text("d")}}}},
+		{"not a b", And{Not{X: text("a")}, text("b")}},
+		{"not (a b)", Not{X: And{text("a"), text("b")}}},
+		{"not not a", Not{X: Not{X: text("a")}}},
+		{"! a or b", Or{Not{X: text("a")}, text("b")}},
+		{"-tag:later", Not{X: Cond{Field: "tag", Op: ":", Values: []string{"later"}}}},
+		{"!is:blocked a", And{Not{X: Cond{Field: "is", Op: ":", Values: []string{"blocked"}}}, text("a")}},
+		{`"or" a`, And{text(`"or"`), text("a")}},
+		{"status:todo,doing project:Work or not tag:later due<+3d", Or{
+			And{
+				Cond{Field: "status", Op: ":", Values: []string{"todo", "doing"}},
+				Cond{Field: "project", Op: ":", Values: []string{"Work"}},
+			},
+			And{
+				Not{X: Cond{Field: "tag", Op: ":", Values: []string{"later"}}},
+				Cond{Field: "due", Op: "<", Values: []string{"+3d"}, Times: []Span{day(time.October, 20)}},
+			},
+		}},
+	}
+	for _, tt := range tests {
+		got, err := ParseAt(tt.input, now)
+		if err != nil {
+			t.Errorf("ParseAt(%q): %v", tt.input, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseAt(%q) =\n\t%#v\nwant\n\t%#v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseAtErrors(t *testing.T) {
+	now := testNow(t)
+	tests := []struct {
+		input   string
+		msg     string
+		pointer string // under the input
+	}{
+		{"status:later", `unknown status (use todo, doing, done, or open) at "later" (column 8)`,
+			"       ^^^^^"},
+		{"a foo:bar", `unknown field "foo" (fields: completed, created, desc, due, id, is, parent, priority, project, status, tag, title) at "foo" (column 3)`,
+			"  ^^^"},
+		{"status<todo", `status can't be compared with < at "<" (column 7)`,
+			"      ^"},
+		{"is>=overdue", `is can't be compared with >= at ">=" (column 3)`,
+			"  ^^"},
+		{"due:", `missing value for due at "due:" (column 1)`,
+			"^^^^"},
+		{"tag:a,,b", `empty value at "," (column 7)`,
+			"      ^"},
+		{"due<+3d,+4d", `< compares with a single value at "+4d" (column 9)`,
+			"        ^^^"},
+		{"due<none", `none can't be compared with < at "none" (column 5)`,
+			"    ^^^^"},
+		{"tag:none,work", `none and any can't be combined with other values at "none,work" (column 5)`,
+			"    ^^^^^^^^^"},
+		{"priority:urgent", `unknown priority (use none, low, medium, or high) at "urgent" (column 10)`,
+			"         ^^^^^^"},
+		{"due<someday", `unrecognized date (use e.g. 2026-01-01, fri, +3d, or -1w) at "someday" (column 5)`,
+			"    ^^^^^^^"},
+		{"id:abc", `invalid task ID at "abc" (column 4)`,
+			"   ^^^"},
+		{"is:late", `unknown state (use blocked, overdue, recurring, actionable, subtask) at "late" (column 4)`,
+			"   ^^^^"},
+		{"@bad!name", `invalid view name at "@bad!name" (column 1)`,
+			"^^^^^^^^^"},
+		{"(a or b", `unclosed parenthesis at "(" (column 1)`,
+			"^"},
+		{"a b)", `unexpected closing parenthesis at ")" (column 4)`,
+			"   ^"},
+		{"() a", `missing condition before closing parenthesis at ")" (column 2)`,
+			" ^"},
+		{"or a", `missing condition before "or" at "or" (column 1)`,
+			"^^"},
+		{"a and or b", `missing condition before "or" at "or" (column 7)`,
+			"      ^^"},
+		{"a or", "missing condition at end of filter",
+			"    ^"},
+		{"not", "missing condition at end of filter",
+			"   ^"},
+		{`title:"buy milk`, `unclosed quote at "\"buy milk" (column 7)`,
+			"      ^^^^^^^^^"},
+
+		// The pointer counts characters, not bytes
+		{"tag:café is:late", `unknown state (use blocked, overdue, recurring, actionable, subtask) at "late" (column 14)`,
+			"            ^^^^"},
+	}
+	for _, tt := range tests {
+		_, err := ParseAt(tt.input, now)
+		var perr *Error
+		if !errors.As(err, &perr) {
+			t.Errorf("ParseAt(%q): got error %v, want a *Error", tt.input, err)
+			continue
+		}
+		if perr.Error() != tt.msg {
+			t.Errorf("ParseAt(%q): got error\n\t%s\nwant\n\t%s", tt.input, perr.Error(), tt.msg)
+		}
+		if got, want := perr.Pointer(tt.input), tt.input+"\n"+tt.pointer; got != want {
+			t.Errorf("ParseAt(%q): got pointer\n%s\nwant\n%s", tt.input, got, want)
+		}
+	}
+}
+
+func TestExpand(t *testing.T) {
+	views := map[string]string{
+		"work":   "project:Work",
+		"urgent": "@work priority>=high",
+		"loop":   "@loop2",
+		"loop2":  "a or @loop",
+		"all":    "",
+	}
+	lookup := func(name string) (string, error) {
+		query, ok := views[name]
+		if !ok {
+			return "", errors.New("view not found: " + name)
+		}
+		return query, nil
+	}
+	e, err := ParseAt("@urgent or @all", testNow(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Expand(e, lookup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Or{
+		And{
+			Cond{Field: "project", Op: ":", Values: []string{"Work"}},
+			Cond{Field: "priority", Op: ">=", Values: []string{"3"}},
+		},
+		And{},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expand = %#v, want %#v", got, want)
+	}
+	if !Mentions(got, "priority") || Mentions(got, "due") {
+		t.Errorf("Mentions got the fields of %#v wrong", got)
+	}
+
+	e, _ = ParseAt("@loop", testNow(t))
+	if _, err := Expand(e, lookup); err == nil || err.Error() != "view @loop refers to itself" {
+		t.Errorf("got error %v expanding a view that refers to itself", err)
+	}
+}
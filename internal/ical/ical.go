@@ -0,0 +1,304 @@
+// Package ical reads and writes iCalendar data (RFC 5545). A calendar is a
+// tree of components, such as VCALENDAR and VTODO, made of properties that
+// are written one per content line:
+//
+//	DUE;TZID=Europe/Berlin:20261020T170000
+//
+// Lines longer than 75 octets are folded onto continuation lines that
+// start with a space.
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Property is a content line. Value is as written, so TEXT values are
+// still escaped; see Text.
+type Property struct {
+	Name   string
+	Params map[string]string // by uppercase name, unquoted
+	Value  string
+}
+
+// TextProperty makes a property with a TEXT value
+func TextProperty(name, text string) Property {
+	return Property{Name: name, Value: EscapeText(text)}
+}
+
+// Param returns the value of a parameter, or "" if it isn't set
+func (p Property) Param(name string) string {
+	return p.Params[strings.ToUpper(name)]
+}
+
+// Text returns a TEXT value, unescaped
+func (p Property) Text() string {
+	return UnescapeText(p.Value)
+}
+
+// Texts returns a list of TEXT values, such as CATEGORIES, unescaped
+func (p Property) Texts() []string {
+	var texts []string
+	start := 0
+	for i := 0; i < len(p.Value); i++ {
+		switch p.Value[i] {
+		case '\\':
+			i++
+		case ',':
+			texts = append(texts, UnescapeText(p.Value[start:i]))
+			start = i + 1
+		}
+	}
+	return append(texts, UnescapeText(p.Value[start:]))
+}
+
+// Component is a BEGIN/END block of properties and nested components
+type Component struct {
+	Name     string
+	Props    []Property
+	Children []*Component
+}
+
+// Prop returns the first property with a name, or nil if there is none
+func (c *Component) Prop(name string) *Property {
+	for i := range c.Props {
+		if c.Props[i].Name == name {
+			return &c.Props[i]
+		}
+	}
+	return nil
+}
+
+// Add appends a property
+func (c *Component) Add(p Property) {
+	c.Props = append(c.Props, p)
+}
+
+// Parse reads the components of a stream, usually a single VCALENDAR.
+// Names are uppercased; lines may end in CRLF or LF.
+func Parse(r io.Reader) ([]*Component, error) {
+	var (
+		top   []*Component
+		stack []*Component
+	)
+	handle := func(line string, n int) error {
+		p, err := parseLine(line)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", n, err)
+		}
+		switch p.Name {
+		case "BEGIN":
+			c := &Component{Name: strings.ToUpper(p.Value)}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.Children = append(parent.Children, c)
+			} else {
+				top = append(top, c)
+			}
+			stack = append(stack, c)
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1].Name != strings.ToUpper(p.Value) {
+				return fmt.Errorf("line %d: unexpected END:%s", n, p.Value)
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			if len(stack) == 0 {
+				return fmt.Errorf("line %d: %s outside of a component", n, p.Name)
+			}
+			stack[len(stack)-1].Add(p)
+		}
+		return nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 16<<20)
+	var line string // the logical line being unfolded
+	start := 0      // and its first line number
+	for n := 1; scanner.Scan(); n++ {
+		text := strings.TrimSuffix(scanner.Text(), "\r")
+		if text != "" && (text[0] == ' ' || text[0] == '\t') {
+			line += text[1:]
+			continue
+		}
+		if strings.TrimSpace(line) != "" {
+			if err := handle(line, start); err != nil {
+				return nil, err
+			}
+		}
+		line, start = text, n
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read iCalendar: %w", err)
+	}
+	if strings.TrimSpace(line) != "" {
+		if err := handle(line, start); err != nil {
+			return nil, err
+		}
+	}
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("missing END:%s", stack[len(stack)-1].Name)
+	}
+	return top, nil
+}
+
+// parseLine splits a content line into name, parameters, and value
+func parseLine(line string) (Property, error) {
+	i := strings.IndexAny(line, ";:")
+	if i <= 0 {
+		return Property{}, fmt.Errorf("invalid content line %q", line)
+	}
+	p := Property{Name: strings.ToUpper(line[:i])}
+
+	for line[i] == ';' {
+		line = line[i+1:]
+		eq := strings.IndexByte(line, '=')
+		if eq <= 0 {
+			return Property{}, fmt.Errorf("%s: invalid parameter", p.Name)
+		}
+		name := strings.ToUpper(line[:eq])
+
+		// Scan to the next ; or : outside of quotes
+		quoted := false
+		i = eq + 1
+		for ; i < len(line); i++ {
+			c := line[i]
+			if c == '"' {
+				quoted = !quoted
+			} else if !quoted && (c == ';' || c == ':') {
+				break
+			}
+		}
+		if i == len(line) {
+			return Property{}, fmt.Errorf("%s: missing value", p.Name)
+		}
+		if p.Params == nil {
+			p.Params = make(map[string]string)
+		}
+		p.Params[name] = strings.ReplaceAll(line[eq+1:i], `"`, "")
+	}
+
+	p.Value = line[i+1:]
+	return p, nil
+}
+
+// Write writes components as content lines ending in CRLF, folding long ones
+func Write(w io.Writer, components ...*Component) error {
+	bw := bufio.NewWriter(w)
+	var write func(c *Component)
+	write = func(c *Component) {
+		writeLine(bw, "BEGIN:"+c.Name)
+		for _, p := range c.Props {
+			writeLine(bw, formatLine(p))
+		}
+		for _, child := range c.Children {
+			write(child)
+		}
+		writeLine(bw, "END:"+c.Name)
+	}
+	for _, c := range components {
+		write(c)
+	}
+	return bw.Flush()
+}
+
+// formatLine formats a property as an unfolded content line
+func formatLine(p Property) string {
+	var b strings.Builder
+	b.WriteString(p.Name)
+	for _, name := range sortedKeys(p.Params) {
+		value := p.Params[name]
+		if strings.ContainsAny(value, ";:,") {
+			value = `"` + value + `"`
+		}
+		b.WriteString(";" + name + "=" + value)
+	}
+	b.WriteString(":" + p.Value)
+	return b.String()
+}
+
+// writeLine writes a content line, folded into lines of at most 75 octets
+// without splitting a UTF-8 sequence
+func writeLine(w *bufio.Writer, line string) {
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		w.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+		limit = 74 // after the leading space
+	}
+	w.WriteString(line + "\r\n")
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var (
+	textEscaper   = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	textUnescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+)
+
+// EscapeText escapes a TEXT value
+func EscapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+// UnescapeText reverses EscapeText
+func UnescapeText(s string) string {
+	return textUnescaper.Replace(s)
+}
+
+const (
+	dateLayout     = "20060102"
+	dateTimeLayout = "20060102T150405"
+)
+
+// Time parses a DATE or DATE-TIME value. Times ending in Z are UTC, those
+// with a TZID in that zone if it's known, and the rest, like dates, local.
+// isDate reports a date without a time.
+func (p Property) Time() (t time.Time, isDate bool, err error) {
+	loc := time.Local
+	if tzid := p.Param("TZID"); tzid != "" {
+		if l, err := time.LoadLocation(strings.TrimPrefix(tzid, "/")); err == nil {
+			loc = l
+		}
+	}
+
+	v := p.Value
+	switch {
+	case strings.EqualFold(p.Param("VALUE"), "DATE") || len(v) == len(dateLayout):
+		t, err = time.ParseInLocation(dateLayout, v, time.Local)
+		isDate = true
+	case strings.HasSuffix(v, "Z"):
+		t, err = time.Parse(dateTimeLayout, strings.TrimSuffix(v, "Z"))
+	default:
+		t, err = time.ParseInLocation(dateTimeLayout, v, loc)
+	}
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("%s: invalid date %q", p.Name, v)
+	}
+	return t, isDate, nil
+}
+
+// DateTimeProperty makes a property with a UTC DATE-TIME value
+func DateTimeProperty(name string, t time.Time) Property {
+	return Property{Name: name, Value: t.UTC().Format(dateTimeLayout) + "Z"}
+}
+
+// DateProperty makes a property with the DATE of t
+func DateProperty(name string, t time.Time) Property {
+	return Property{Name: name, Params: map[string]string{"VALUE": "DATE"}, Value: t.Format(dateLayout)}
+}
@@ -0,0 +1,194 @@
+package ical
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Todo is a VTODO, with the properties that have a field read into it
+type Todo struct {
+	UID         string
+	Summary     string
+	Description string
+	Status      string // NEEDS-ACTION, IN-PROCESS, COMPLETED, or CANCELLED
+	Priority    int    // 1 (highest) to 9 (lowest), or 0 if undefined
+	Due         *time.Time
+	DueIsDate   bool // Due is a date without a time
+	Created     time.Time
+	Completed   *time.Time
+	Categories  []string
+	RRule       string
+	Parent      string   // UID of the parent to-do
+	DependsOn   []string // UIDs of the to-dos this one waits on
+
+	Other    []Property   // properties without a field, such as X- ones
+	Children []*Component // nested components, such as VALARM
+}
+
+// ignored properties carry nothing a task has
+var ignored = map[string]bool{
+	"DTSTAMP": true, "LAST-MODIFIED": true, "SEQUENCE": true, "PERCENT-COMPLETE": true,
+}
+
+// ReadTodos reads the VTODOs of a calendar. Other components, such as
+// VEVENT, are returned apart; time zones are looked up by their TZID.
+func ReadTodos(r io.Reader) (todos []Todo, other []*Component, err error) {
+	components, err := Parse(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, cal := range components {
+		if cal.Name != "VCALENDAR" {
+			return nil, nil, fmt.Errorf("not an iCalendar file: found %s instead of VCALENDAR", cal.Name)
+		}
+		for _, c := range cal.Children {
+			switch c.Name {
+			case "VTODO":
+				todo, err := readTodo(c)
+				if err != nil {
+					return nil, nil, err
+				}
+				todos = append(todos, *todo)
+			case "VTIMEZONE":
+			default:
+				other = append(other, c)
+			}
+		}
+	}
+	return todos, other, nil
+}
+
+func readTodo(c *Component) (*Todo, error) {
+	todo := &Todo{Children: c.Children}
+	for _, p := range c.Props {
+		if err := todo.set(p); err != nil {
+			if uid := c.Prop("UID"); uid != nil {
+				return nil, fmt.Errorf("VTODO %s: %w", uid.Text(), err)
+			}
+			return nil, fmt.Errorf("VTODO: %w", err)
+		}
+	}
+	return todo, nil
+}
+
+// set reads a property into its field
+func (t *Todo) set(p Property) error {
+	switch p.Name {
+	case "UID":
+		t.UID = p.Text()
+	case "SUMMARY":
+		t.Summary = p.Text()
+	case "DESCRIPTION":
+		t.Description = p.Text()
+	case "STATUS":
+		t.Status = strings.ToUpper(p.Value)
+	case "PRIORITY":
+		n, err := strconv.Atoi(p.Value)
+		if err != nil || n < 0 || n > 9 {
+			return fmt.Errorf("invalid priority %q (use 0 to 9)", p.Value)
+		}
+		t.Priority = n
+	case "DUE":
+		due, isDate, err := p.Time()
+		if err != nil {
+			return err
+		}
+		t.Due, t.DueIsDate = &due, isDate
+	case "CREATED":
+		created, _, err := p.Time()
+		if err != nil {
+			return err
+		}
+		t.Created = created
+	case "COMPLETED":
+		completed, _, err := p.Time()
+		if err != nil {
+			return err
+		}
+		t.Completed = &completed
+	case "CATEGORIES":
+		t.Categories = append(t.Categories, p.Texts()...)
+	case "RRULE":
+		t.RRule = p.Value
+	case "RELATED-TO":
+		switch strings.ToUpper(p.Param("RELTYPE")) {
+		case "", "PARENT":
+			t.Parent = p.Text()
+		case "DEPENDS-ON":
+			t.DependsOn = append(t.DependsOn, p.Text())
+		default:
+			t.Other = append(t.Other, p)
+		}
+	default:
+		if !ignored[p.Name] {
+			t.Other = append(t.Other, p)
+		}
+	}
+	return nil
+}
+
+// WriteTodos writes a calendar of to-dos. DTSTAMP is the time each was
+// created, which keeps the output the same for the same to-dos.
+func WriteTodos(w io.Writer, prodID string, todos []Todo) error {
+	cal := &Component{Name: "VCALENDAR"}
+	cal.Add(Property{Name: "VERSION", Value: "2.0"})
+	cal.Add(Property{Name: "PRODID", Value: prodID})
+	for _, t := range todos {
+		cal.Children = append(cal.Children, t.component())
+	}
+	return Write(w, cal)
+}
+
+func (t Todo) component() *Component {
+	c := &Component{Name: "VTODO", Children: t.Children}
+	c.Add(TextProperty("UID", t.UID))
+	if t.Created.IsZero() {
+		c.Add(DateTimeProperty("DTSTAMP", time.Now()))
+	} else {
+		c.Add(DateTimeProperty("DTSTAMP", t.Created))
+		c.Add(DateTimeProperty("CREATED", t.Created))
+	}
+	c.Add(TextProperty("SUMMARY", t.Summary))
+	if t.Description != "" {
+		c.Add(TextProperty("DESCRIPTION", t.Description))
+	}
+	if t.Status != "" {
+		c.Add(Property{Name: "STATUS", Value: t.Status})
+	}
+	if t.Priority != 0 {
+		c.Add(Property{Name: "PRIORITY", Value: strconv.Itoa(t.Priority)})
+	}
+	if t.Due != nil {
+		if t.DueIsDate {
+			c.Add(DateProperty("DUE", *t.Due))
+		} else {
+			c.Add(DateTimeProperty("DUE", *t.Due))
+		}
+	}
+	if t.Completed != nil {
+		c.Add(DateTimeProperty("COMPLETED", *t.Completed))
+	}
+	if len(t.Categories) > 0 {
+		escaped := make([]string, len(t.Categories))
+		for i, category := range t.Categories {
+			escaped[i] = EscapeText(category)
+		}
+		c.Add(Property{Name: "CATEGORIES", Value: strings.Join(escaped, ",")})
+	}
+	if t.RRule != "" {
+		c.Add(Property{Name: "RRULE", Value: t.RRule})
+	}
+	if t.Parent != "" {
+		c.Add(TextProperty("RELATED-TO", t.Parent))
+	}
+	for _, uid := range t.DependsOn {
+		p := TextProperty("RELATED-TO", uid)
+		p.Params = map[string]string{"RELTYPE": "DEPENDS-ON"}
+		c.Add(p)
+	}
+	c.Props = append(c.Props, t.Other...)
+	return c
+}
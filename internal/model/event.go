@@ -0,0 +1,77 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+type EventKind string
+
+const (
+	EventCreated    EventKind = "created"
+	EventChanged    EventKind = "changed" // a field such as title or due date
+	EventStatus     EventKind = "status"
+	EventTagAdded   EventKind = "tag_added"
+	EventTagRemoved EventKind = "tag_removed"
+	EventMoved      EventKind = "moved"   // project change
+	EventDeleted    EventKind = "deleted" // moved to the trash
+	EventRestored   EventKind = "restored"
+	EventBlocked    EventKind = "blocked"   // dependency added
+	EventUnblocked  EventKind = "unblocked" // dependency removed
+	EventUndone     EventKind = "undone"    // an earlier change was undone
+	EventRedone     EventKind = "redone"
+)
+
+// TaskEvent is one entry in a task's history
+type TaskEvent struct {
+	ID        int64
+	TaskID    int64
+	Kind      EventKind
+	Field     string
+	OldValue  string
+	NewValue  string
+	Actor     string
+	CreatedAt time.Time
+}
+
+// Describe returns a one-line, human-readable summary of the event
+func (e TaskEvent) Describe() string {
+	switch e.Kind {
+	case EventCreated:
+		return fmt.Sprintf("created %q", e.NewValue)
+	case EventChanged:
+		return fmt.Sprintf("%s: %s → %s", e.Field, describeValue(e.OldValue), describeValue(e.NewValue))
+	case EventStatus:
+		return fmt.Sprintf("status: %s → %s", e.OldValue, e.NewValue)
+	case EventTagAdded:
+		return "tag added: " + e.NewValue
+	case EventTagRemoved:
+		return "tag removed: " + e.OldValue
+	case EventMoved:
+		return fmt.Sprintf("project: %s → %s", describeValue(e.OldValue), describeValue(e.NewValue))
+	case EventDeleted:
+		return fmt.Sprintf("deleted %q", e.OldValue)
+	case EventBlocked:
+		return "blocked by " + e.NewValue
+	case EventUnblocked:
+		return "no longer blocked by " + e.OldValue
+	case EventRestored:
+		return fmt.Sprintf("restored %q from trash", e.NewValue)
+	case EventUndone:
+		return "undid: " + e.NewValue
+	case EventRedone:
+		return "redid: " + e.NewValue
+	}
+	return string(e.Kind)
+}
+
+func describeValue(v string) string {
+	if v == "" {
+		return "(none)"
+	}
+	runes := []rune(v)
+	if len(runes) > 30 {
+		v = string(runes[:27]) + "..."
+	}
+	return fmt.Sprintf("%q", v)
+}
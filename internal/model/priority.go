@@ -1,5 +1,10 @@
 package model
 
+import (
+	"fmt"
+	"strings"
+)
+
 type Priority int
 
 const (
@@ -33,3 +38,17 @@ func ParsePriority(s string) Priority {
 		return PriorityNone
 	}
 }
+
+// ParsePriorityName parses a priority as users write it, ignoring case:
+// any name ParsePriority knows, or "none", "0", or "" for no priority
+func ParsePriorityName(s string) (Priority, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	switch name {
+	case "", "none", "0":
+		return PriorityNone, nil
+	}
+	if p := ParsePriority(name); p != PriorityNone {
+		return p, nil
+	}
+	return PriorityNone, fmt.Errorf("invalid priority %q (use none/low/medium/high)", s)
+}
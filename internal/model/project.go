@@ -7,6 +7,7 @@ type Project struct {
 	Name        string
 	Description string
 	CreatedAt   time.Time
+	DeletedAt   *time.Time // set while the project is in the trash
 
 	// Computed stats
 	TaskCount int
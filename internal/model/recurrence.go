@@ -11,79 +11,127 @@ const (
 	Yearly  RecurrencePattern = "yearly"
 )
 
+// RecurrenceAnchor is what the next occurrence is scheduled from
+type RecurrenceAnchor string
+
+const (
+	AnchorDue        RecurrenceAnchor = "due"        // the completed task's due date
+	AnchorCompletion RecurrenceAnchor = "completion" // when the task was completed
+)
+
+// CatchUp is how occurrences missed while a task was overdue are handled
+type CatchUp string
+
+const (
+	CatchUpSkip CatchUp = "skip" // move on to the next future occurrence
+	CatchUpAll  CatchUp = "all"  // create a task for each missed occurrence
+)
+
 type Recurrence struct {
-	ID       int64
-	TaskID   int64
-	Pattern  RecurrencePattern
-	Interval int
-	NextDue  time.Time
+	ID         int64
+	TaskID     int64
+	Rule       RRule
+	Occurrence int // position of TaskID in the series, counting from 1
+	NextDue    time.Time
+	Anchor     RecurrenceAnchor // empty keeps the current anchor when set
+	CatchUp    CatchUp          // empty keeps the current catch-up when set
 }
 
-func NewRecurrence(taskID int64, pattern RecurrencePattern, interval int) *Recurrence {
-	if interval < 1 {
-		interval = 1
+func NewRecurrence(taskID int64, rule RRule) *Recurrence {
+	if rule.Interval < 1 {
+		rule.Interval = 1
 	}
 	return &Recurrence{
-		TaskID:   taskID,
-		Pattern:  pattern,
-		Interval: interval,
-		NextDue:  time.Now(),
+		TaskID:     taskID,
+		Rule:       rule,
+		Occurrence: 1,
+		NextDue:    time.Now(),
 	}
 }
 
-func (r *Recurrence) CalculateNextDue(from time.Time) time.Time {
-	switch r.Pattern {
-	case Daily:
-		return from.AddDate(0, 0, r.Interval)
-	case Weekly:
-		return from.AddDate(0, 0, 7*r.Interval)
-	case Monthly:
-		return from.AddDate(0, r.Interval, 0)
-	case Yearly:
-		return from.AddDate(r.Interval, 0, 0)
+// Schedule sets the next occurrence to the one after the task's due date,
+// or after now if it has none. It is zero once the series has ended.
+func (r *Recurrence) Schedule(due *time.Time) {
+	from := time.Now()
+	if due != nil {
+		from = *due
 	}
-	return from
+	r.NextDue, _ = r.CalculateNextDue(from)
 }
 
-func (r *Recurrence) PatternString() string {
-	if r.Interval == 1 {
-		switch r.Pattern {
-		case Daily:
-			return "daily"
-		case Weekly:
-			return "weekly"
-		case Monthly:
-			return "monthly"
-		case Yearly:
-			return "yearly"
+// CalculateNextDue returns the occurrence after from. It reports false once
+// the series has ended through COUNT or UNTIL.
+func (r *Recurrence) CalculateNextDue(from time.Time) (time.Time, bool) {
+	if r.Rule.Count > 0 && r.Occurrence >= r.Rule.Count {
+		return time.Time{}, false
+	}
+	return r.Rule.Next(from)
+}
+
+// Advance moves the series past the current occurrence, due at due, which
+// was completed at now. It returns the due dates of the tasks to create: the
+// next occurrence, or with CatchUpAll also every occurrence missed before
+// it. None are returned once the series has ended.
+func (r *Recurrence) Advance(due *time.Time, now time.Time) []time.Time {
+	return r.advance(due, now, r.CatchUp == CatchUpAll)
+}
+
+// Skip moves the series past the current occurrence without completing it,
+// returning the next occurrence's due date. It reports false once the
+// series has ended.
+func (r *Recurrence) Skip(due *time.Time, now time.Time) (time.Time, bool) {
+	dues := r.advance(due, now, false)
+	if len(dues) == 0 {
+		return time.Time{}, false
+	}
+	return dues[0], true
+}
+
+func (r *Recurrence) advance(due *time.Time, now time.Time, all bool) []time.Time {
+	next, ok := r.following(due, now)
+	var dues []time.Time
+	for ok {
+		r.Occurrence++
+		if all || len(dues) == 0 {
+			dues = append(dues, next)
+		} else {
+			dues[0] = next // skipped a missed occurrence
+		}
+		if next.After(now) {
+			break
+		}
+		next, ok = r.CalculateNextDue(next)
+	}
+	if len(dues) > 0 {
+		r.Schedule(&dues[len(dues)-1])
+	}
+	return dues
+}
+
+// following returns the occurrence after the current one
+func (r *Recurrence) following(due *time.Time, now time.Time) (time.Time, bool) {
+	if r.Anchor == AnchorCompletion {
+		// Keep the due date's time of day
+		from := now
+		if due != nil {
+			from = time.Date(now.Year(), now.Month(), now.Day(), due.Hour(), due.Minute(), due.Second(), 0, now.Location())
 		}
+		return r.CalculateNextDue(from)
 	}
 
-	unit := ""
-	switch r.Pattern {
-	case Daily:
-		unit = "days"
-	case Weekly:
-		unit = "weeks"
-	case Monthly:
-		unit = "months"
-	case Yearly:
-		unit = "years"
+	if r.NextDue.IsZero() {
+		return time.Time{}, false
+	}
+	if r.Rule.Count > 0 && r.Occurrence >= r.Rule.Count ||
+		r.Rule.Until != nil && r.NextDue.After(*r.Rule.Until) {
+		return time.Time{}, false
 	}
-	return "every " + string(rune('0'+r.Interval)) + " " + unit
+	return r.NextDue, true
 }
 
-func ParseRecurrencePattern(s string) RecurrencePattern {
-	switch s {
-	case "daily", "d":
-		return Daily
-	case "weekly", "w":
-		return Weekly
-	case "monthly", "m":
-		return Monthly
-	case "yearly", "y":
-		return Yearly
-	default:
-		return Daily
+func (r *Recurrence) PatternString() string {
+	if r.Anchor == AnchorCompletion {
+		return r.Rule.Describe() + " after completion"
 	}
+	return r.Rule.Describe()
 }
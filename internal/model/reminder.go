@@ -0,0 +1,53 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// Reminder fires at a fixed time, or Before a task's due date when At is nil
+type Reminder struct {
+	ID        int64
+	TaskID    int64
+	At        *time.Time
+	Before    time.Duration
+	FiredAt   *time.Time
+	CreatedAt time.Time
+}
+
+// Time returns when the reminder fires. Reminders relative to the due date
+// don't fire while the task has none.
+func (r Reminder) Time(due *time.Time) (time.Time, bool) {
+	if r.At != nil {
+		return *r.At, true
+	}
+	if due == nil {
+		return time.Time{}, false
+	}
+	return due.Add(-r.Before), true
+}
+
+// Describe returns a short description such as "30m before due" or
+// "Oct 20 09:00"
+func (r Reminder) Describe() string {
+	if r.At != nil {
+		return r.At.Local().Format("Jan 2 15:04")
+	}
+	if r.Before == 0 {
+		return "at due time"
+	}
+	return FormatOffset(r.Before) + " before due"
+}
+
+// FormatOffset formats a reminder offset in its largest whole unit, e.g.
+// "30m", "2h", or "1d"
+func FormatOffset(d time.Duration) string {
+	switch {
+	case d%(24*time.Hour) == 0:
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	default:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	}
+}
@@ -0,0 +1,521 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RRule is a recurrence rule in RFC 5545 form, e.g.
+// FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE,FR. Weeks start on Monday.
+type RRule struct {
+	Freq       RecurrencePattern
+	Interval   int
+	ByDay      []WeekdayNum
+	ByMonthDay []int // negative days count from the end of the month
+	ByMonth    []time.Month
+	BySetPos   []int // picks from the occurrences in each period
+	Count      int   // total occurrences, 0 for no limit
+	Until      *time.Time
+}
+
+// WeekdayNum is a BYDAY entry: every Day when N is 0, otherwise the Nth Day
+// of the month or year (the last when N is -1)
+type WeekdayNum struct {
+	N   int
+	Day time.Weekday
+}
+
+var dayCodes = [...]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// Weekdays are Monday through Friday
+var Weekdays = []WeekdayNum{{0, time.Monday}, {0, time.Tuesday}, {0, time.Wednesday}, {0, time.Thursday}, {0, time.Friday}}
+
+func (w WeekdayNum) String() string {
+	if w.N == 0 {
+		return dayCodes[w.Day]
+	}
+	return strconv.Itoa(w.N) + dayCodes[w.Day]
+}
+
+var weekdayNumRe = regexp.MustCompile(`^([+-]?\d{1,2})?(SU|MO|TU|WE|TH|FR|SA)$`)
+
+// ParseRRule parses an RFC 5545 RRULE value such as "FREQ=MONTHLY;BYDAY=-1FR",
+// with or without the "RRULE:" prefix
+func ParseRRule(s string) (RRule, error) {
+	s = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(s)), "RRULE:")
+	r := RRule{Interval: 1}
+
+	for _, part := range strings.Split(s, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return RRule{}, fmt.Errorf("invalid rule part %q", part)
+		}
+		var err error
+		switch key {
+		case "FREQ":
+			r.Freq = RecurrencePattern(strings.ToLower(value))
+			if !slices.Contains([]RecurrencePattern{Daily, Weekly, Monthly, Yearly}, r.Freq) {
+				return RRule{}, fmt.Errorf("unsupported frequency %q", value)
+			}
+		case "INTERVAL":
+			r.Interval, err = strconv.Atoi(value)
+			if err == nil && r.Interval < 1 {
+				err = fmt.Errorf("must be positive")
+			}
+		case "COUNT":
+			r.Count, err = strconv.Atoi(value)
+			if err == nil && r.Count < 1 {
+				err = fmt.Errorf("must be positive")
+			}
+		case "UNTIL":
+			var until time.Time
+			until, err = parseUntil(value)
+			r.Until = &until
+		case "BYDAY":
+			for _, v := range strings.Split(value, ",") {
+				m := weekdayNumRe.FindStringSubmatch(v)
+				if m == nil {
+					err = fmt.Errorf("invalid weekday %q", v)
+					break
+				}
+				w := WeekdayNum{Day: time.Weekday(slices.Index(dayCodes[:], m[2]))}
+				if m[1] != "" {
+					w.N, _ = strconv.Atoi(m[1])
+					if w.N == 0 || w.N < -53 || w.N > 53 {
+						err = fmt.Errorf("invalid weekday %q", v)
+						break
+					}
+				}
+				r.ByDay = append(r.ByDay, w)
+			}
+		case "BYMONTHDAY":
+			r.ByMonthDay, err = parseInts(value, 31)
+		case "BYSETPOS":
+			r.BySetPos, err = parseInts(value, 366)
+		case "BYMONTH":
+			var months []int
+			months, err = parseInts(value, 12)
+			for _, m := range months {
+				if m < 0 {
+					err = fmt.Errorf("invalid month %d", m)
+				}
+				r.ByMonth = append(r.ByMonth, time.Month(m))
+			}
+		case "WKST":
+			if value != "MO" {
+				err = fmt.Errorf("only weeks starting on MO are supported")
+			}
+		default:
+			return RRule{}, fmt.Errorf("unsupported rule part %s", key)
+		}
+		if err != nil {
+			return RRule{}, fmt.Errorf("invalid %s: %w", key, err)
+		}
+	}
+
+	if err := r.Validate(); err != nil {
+		return RRule{}, err
+	}
+	return r, nil
+}
+
+// parseUntil parses an UNTIL value: a date, which ends at the end of that
+// day, or a local or UTC date-time
+func parseUntil(s string) (time.Time, error) {
+	if t, err := time.Parse("20060102T150405Z", s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("20060102T150405", s, time.Local); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("20060102", s, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("use YYYYMMDD or YYYYMMDDTHHMMSSZ")
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 23, 59, 59, 0, time.Local), nil
+}
+
+// parseInts parses a list of non-zero integers within ±limit
+func parseInts(s string, limit int) ([]int, error) {
+	var ns []int
+	for _, v := range strings.Split(s, ",") {
+		n, err := strconv.Atoi(v)
+		if err != nil || n == 0 || n < -limit || n > limit {
+			return nil, fmt.Errorf("invalid value %q", v)
+		}
+		ns = append(ns, n)
+	}
+	return ns, nil
+}
+
+// Validate checks the combinations RFC 5545 allows
+func (r RRule) Validate() error {
+	switch {
+	case r.Freq == "":
+		return fmt.Errorf("rule has no frequency")
+	case r.Count > 0 && r.Until != nil:
+		return fmt.Errorf("rule can't have both COUNT and UNTIL")
+	case r.Freq == Weekly && len(r.ByMonthDay) > 0:
+		return fmt.Errorf("BYMONTHDAY can't be used with weekly rules")
+	case len(r.BySetPos) > 0 && len(r.ByDay) == 0 && len(r.ByMonthDay) == 0 && len(r.ByMonth) == 0:
+		return fmt.Errorf("BYSETPOS needs another BY rule")
+	}
+	if r.Freq == Daily || r.Freq == Weekly {
+		for _, w := range r.ByDay {
+			if w.N != 0 {
+				return fmt.Errorf("numbered weekdays such as %s need a monthly or yearly rule", w)
+			}
+		}
+	}
+	return nil
+}
+
+// String formats the rule as an RRULE value
+func (r RRule) String() string {
+	parts := []string{"FREQ=" + strings.ToUpper(string(r.Freq))}
+	if r.Interval > 1 {
+		parts = append(parts, fmt.Sprintf("INTERVAL=%d", r.Interval))
+	}
+	if len(r.ByMonth) > 0 {
+		parts = append(parts, "BYMONTH="+joinInts(r.ByMonth))
+	}
+	if len(r.ByMonthDay) > 0 {
+		parts = append(parts, "BYMONTHDAY="+joinInts(r.ByMonthDay))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, w := range r.ByDay {
+			days[i] = w.String()
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.BySetPos) > 0 {
+		parts = append(parts, "BYSETPOS="+joinInts(r.BySetPos))
+	}
+	if r.Count > 0 {
+		parts = append(parts, fmt.Sprintf("COUNT=%d", r.Count))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	return strings.Join(parts, ";")
+}
+
+func joinInts[T ~int](ns []T) string {
+	s := make([]string, len(ns))
+	for i, n := range ns {
+		s[i] = strconv.Itoa(int(n))
+	}
+	return strings.Join(s, ",")
+}
+
+// IsSimple reports whether the rule only has a frequency and interval
+func (r RRule) IsSimple() bool {
+	return len(r.ByDay) == 0 && len(r.ByMonthDay) == 0 && len(r.ByMonth) == 0 &&
+		len(r.BySetPos) == 0 && r.Count == 0 && r.Until == nil
+}
+
+// Describe returns a readable form of the rule, e.g. "weekly on Mon, Wed" or
+// "every 2 months on the last Fri, 6 times"
+func (r RRule) Describe() string {
+	var b strings.Builder
+	if r.Interval <= 1 {
+		b.WriteString(string(r.Freq))
+	} else {
+		unit := map[RecurrencePattern]string{Daily: "days", Weekly: "weeks", Monthly: "months", Yearly: "years"}[r.Freq]
+		fmt.Fprintf(&b, "every %d %s", r.Interval, unit)
+	}
+
+	if len(r.ByMonth) > 0 {
+		months := make([]string, len(r.ByMonth))
+		for i, m := range r.ByMonth {
+			months[i] = m.String()[:3]
+		}
+		b.WriteString(" in " + strings.Join(months, ", "))
+	}
+
+	var on []string
+	for _, d := range r.ByMonthDay {
+		if d == -1 {
+			on = append(on, "the last day")
+		} else {
+			on = append(on, "the "+ordinal(d))
+		}
+	}
+	if len(r.ByDay) > 0 {
+		on = append(on, describeDays(r.ByDay, r.BySetPos))
+	}
+	if len(on) > 0 {
+		b.WriteString(" on " + strings.Join(on, ", "))
+	}
+
+	if r.Count > 0 {
+		fmt.Fprintf(&b, ", %d times", r.Count)
+	}
+	if r.Until != nil {
+		b.WriteString(" until " + r.Until.Local().Format("Jan 2, 2006"))
+	}
+	return b.String()
+}
+
+// describeDays describes BYDAY entries, narrowed by BYSETPOS
+func describeDays(days []WeekdayNum, setPos []int) string {
+	var names []string
+	if slices.Equal(days, Weekdays) {
+		names = []string{"weekday"}
+	} else {
+		for _, w := range days {
+			name := w.Day.String()[:3]
+			if w.N != 0 {
+				name = "the " + ordinal(w.N) + " " + name
+			}
+			names = append(names, name)
+		}
+	}
+	list := strings.Join(names, ", ")
+
+	if len(setPos) == 0 {
+		if list == "weekday" {
+			return "weekdays"
+		}
+		return list
+	}
+	positions := make([]string, len(setPos))
+	for i, p := range setPos {
+		positions[i] = ordinal(p)
+	}
+	if len(names) > 1 {
+		list = "of " + list
+	}
+	return "the " + strings.Join(positions, ", ") + " " + list
+}
+
+// ordinal formats n as "1st", "2nd", "last", "2nd-last", ...
+func ordinal(n int) string {
+	if n == -1 {
+		return "last"
+	}
+	if n < 0 {
+		return ordinal(-n) + "-last"
+	}
+	suffix := "th"
+	if n%100 < 11 || n%100 > 13 {
+		switch n % 10 {
+		case 1:
+			suffix = "st"
+		case 2:
+			suffix = "nd"
+		case 3:
+			suffix = "rd"
+		}
+	}
+	return strconv.Itoa(n) + suffix
+}
+
+// maxPeriods bounds the search for the next occurrence of rules that rarely
+// or never match, such as the 31st of February
+const maxPeriods = 5000
+
+// Next returns the first occurrence strictly after from, at from's time of
+// day. Daily and weekly rules without BY parts simply add the interval;
+// monthly and yearly ones skip months without from's day, so the 31st
+// recurs on the 31st and Feb 29 in leap years, as RFC 5545 has it. It
+// reports false when the rule has no further occurrences before UNTIL;
+// COUNT is left to the caller.
+func (r RRule) Next(from time.Time) (time.Time, bool) {
+	interval := max(r.Interval, 1)
+	simple := len(r.ByDay) == 0 && len(r.ByMonthDay) == 0 && len(r.ByMonth) == 0
+	if simple && (r.Freq == Daily || r.Freq == Weekly) {
+		next := addPeriods(from, r.Freq, interval)
+		return next, r.Until == nil || !next.After(*r.Until)
+	}
+
+	start := periodStart(from, r.Freq)
+	for i := 0; i < maxPeriods; i++ {
+		period := addPeriods(start, r.Freq, i*interval)
+		for _, day := range r.expand(period, from) {
+			t := time.Date(day.Year(), day.Month(), day.Day(), from.Hour(), from.Minute(), from.Second(), 0, from.Location())
+			if !t.After(from) {
+				continue
+			}
+			if r.Until != nil && t.After(*r.Until) {
+				return time.Time{}, false
+			}
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// periodStart returns midnight at the start of the day, week, month, or year
+// containing t
+func periodStart(t time.Time, freq RecurrencePattern) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	switch freq {
+	case Weekly:
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case Monthly:
+		return day.AddDate(0, 0, 1-day.Day())
+	case Yearly:
+		return time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, t.Location())
+	}
+	return day
+}
+
+func addPeriods(t time.Time, freq RecurrencePattern, n int) time.Time {
+	switch freq {
+	case Daily:
+		return t.AddDate(0, 0, n)
+	case Weekly:
+		return t.AddDate(0, 0, 7*n)
+	case Monthly:
+		return t.AddDate(0, n, 0)
+	case Yearly:
+		return t.AddDate(n, 0, 0)
+	}
+	return t
+}
+
+// expand returns the days the rule matches in the period starting at start,
+// in order. Days, weekdays, and months unspecified by the rule are taken
+// from from.
+func (r RRule) expand(start, from time.Time) []time.Time {
+	var days []time.Time
+	switch r.Freq {
+	case Daily:
+		days = []time.Time{start}
+	case Weekly:
+		for i := 0; i < 7; i++ {
+			day := start.AddDate(0, 0, i)
+			if len(r.ByDay) == 0 && day.Weekday() == from.Weekday() || r.matchesWeekday(day) {
+				days = append(days, day)
+			}
+		}
+	case Monthly:
+		days = r.monthDays(start, from)
+	case Yearly:
+		if len(r.ByMonth) == 0 && len(r.ByMonthDay) == 0 && len(r.ByDay) > 0 {
+			days = r.weekdaysIn(start, start.AddDate(1, 0, 0))
+			break
+		}
+		// BYMONTHDAY alone expands to every month, otherwise from's is used
+		months := r.ByMonth
+		if len(months) == 0 && len(r.ByMonthDay) > 0 {
+			for m := time.January; m <= time.December; m++ {
+				months = append(months, m)
+			}
+		} else if len(months) == 0 {
+			months = []time.Month{from.Month()}
+		}
+		for _, m := range months {
+			days = append(days, r.monthDays(time.Date(start.Year(), m, 1, 0, 0, 0, 0, start.Location()), from)...)
+		}
+	}
+
+	// BY parts that limit rather than expand the frequency
+	days = slices.DeleteFunc(days, func(day time.Time) bool {
+		if len(r.ByMonth) > 0 && !slices.Contains(r.ByMonth, day.Month()) {
+			return true
+		}
+		if r.Freq == Daily {
+			if len(r.ByMonthDay) > 0 && !r.matchesMonthDay(day) {
+				return true
+			}
+			if len(r.ByDay) > 0 && !r.matchesWeekday(day) {
+				return true
+			}
+		}
+		return false
+	})
+	slices.SortFunc(days, func(a, b time.Time) int { return a.Compare(b) })
+	days = slices.CompactFunc(days, func(a, b time.Time) bool { return a.Equal(b) })
+
+	if len(r.BySetPos) == 0 {
+		return days
+	}
+	var picked []time.Time
+	for _, pos := range r.BySetPos {
+		if i := pos - 1; pos < 0 {
+			i = len(days) + pos
+			if i >= 0 {
+				picked = append(picked, days[i])
+			}
+		} else if i < len(days) {
+			picked = append(picked, days[i])
+		}
+	}
+	slices.SortFunc(picked, func(a, b time.Time) int { return a.Compare(b) })
+	return slices.CompactFunc(picked, func(a, b time.Time) bool { return a.Equal(b) })
+}
+
+// monthDays returns the days the rule matches in the month starting at start
+func (r RRule) monthDays(start, from time.Time) []time.Time {
+	end := start.AddDate(0, 1, 0)
+	last := end.AddDate(0, 0, -1).Day()
+
+	if len(r.ByMonthDay) == 0 && len(r.ByDay) == 0 {
+		if from.Day() > last {
+			return nil // no 31st this month
+		}
+		return []time.Time{start.AddDate(0, 0, from.Day()-1)}
+	}
+	if len(r.ByMonthDay) == 0 {
+		return r.weekdaysIn(start, end)
+	}
+
+	var days []time.Time
+	for i := 0; i < last; i++ {
+		day := start.AddDate(0, 0, i)
+		if r.matchesMonthDay(day) {
+			days = append(days, day)
+		}
+	}
+	if len(r.ByDay) > 0 {
+		weekdays := r.weekdaysIn(start, end)
+		days = slices.DeleteFunc(days, func(day time.Time) bool {
+			return !slices.ContainsFunc(weekdays, day.Equal)
+		})
+	}
+	return days
+}
+
+// weekdaysIn returns the days in [start, end) matching BYDAY, where numbered
+// weekdays count from either end of the range
+func (r RRule) weekdaysIn(start, end time.Time) []time.Time {
+	var days []time.Time
+	for _, w := range r.ByDay {
+		var matches []time.Time
+		for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+			if day.Weekday() == w.Day {
+				matches = append(matches, day)
+			}
+		}
+		switch {
+		case w.N == 0:
+			days = append(days, matches...)
+		case w.N > 0 && w.N <= len(matches):
+			days = append(days, matches[w.N-1])
+		case w.N < 0 && -w.N <= len(matches):
+			days = append(days, matches[len(matches)+w.N])
+		}
+	}
+	return days
+}
+
+func (r RRule) matchesWeekday(day time.Time) bool {
+	return slices.ContainsFunc(r.ByDay, func(w WeekdayNum) bool { return w.Day == day.Weekday() })
+}
+
+func (r RRule) matchesMonthDay(day time.Time) bool {
+	last := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, day.Location()).Day()
+	for _, d := range r.ByMonthDay {
+		if d == day.Day() || d < 0 && last+1+d == day.Day() {
+			return true
+		}
+	}
+	return false
+}
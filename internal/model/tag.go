@@ -1,9 +1,12 @@
 package model
 
+import "time"
+
 type Tag struct {
-	ID    int64
-	Name  string
-	Color string
+	ID        int64
+	Name      string
+	Color     string
+	DeletedAt *time.Time // set while the tag is in the trash
 }
 
 func NewTag(name string) *Tag {
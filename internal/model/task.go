@@ -4,6 +4,7 @@ import "time"
 
 type Task struct {
 	ID          int64
+	UID         string // stable across exports and imports
 	ProjectID   *int64
 	ParentID    *int64
 	Title       string
@@ -14,6 +15,12 @@ type Task struct {
 	CreatedAt   time.Time
 	CompletedAt *time.Time
 	Position    int
+	DeletedAt   *time.Time // set while the task is in the trash
+
+	// Computed
+	Blocked      bool // blocked by at least one unfinished task
+	SubtaskCount int  // direct subtasks
+	SubtasksDone int  // direct subtasks that are done
 
 	// Relations (populated on join)
 	Tags       []Tag
@@ -0,0 +1,62 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// TimeEntry is one run of a task's timer. EndedAt is nil while it's running.
+type TimeEntry struct {
+	ID        int64
+	TaskID    int64
+	StartedAt time.Time
+	EndedAt   *time.Time
+}
+
+func (e TimeEntry) Running() bool {
+	return e.EndedAt == nil
+}
+
+// Duration returns the tracked time, counting a running timer up to now
+func (e TimeEntry) Duration(now time.Time) time.Duration {
+	end := now
+	if e.EndedAt != nil {
+		end = *e.EndedAt
+	}
+	return end.Sub(e.StartedAt)
+}
+
+// Within returns the part of the entry that falls between since and until
+func (e TimeEntry) Within(since, until, now time.Time) time.Duration {
+	start, end := e.StartedAt, now
+	if e.EndedAt != nil {
+		end = *e.EndedAt
+	}
+	if start.Before(since) {
+		start = since
+	}
+	if end.After(until) {
+		end = until
+	}
+	if end.Before(start) {
+		return 0
+	}
+	return end.Sub(start)
+}
+
+// TimeTotal is the tracked time for one group (project, tag, or task) of a report
+type TimeTotal struct {
+	Name     string
+	Duration time.Duration
+}
+
+// FormatDuration formats a duration as hours and minutes, e.g. "2h 05m"
+func FormatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	h := int(d / time.Hour)
+	m := int(d % time.Hour / time.Minute)
+	if h == 0 {
+		return fmt.Sprintf("%dm", m)
+	}
+	return fmt.Sprintf("%dh %02dm", h, m)
+}
@@ -0,0 +1,130 @@
+package model
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+// UrgencyCoefficients weigh the factors of a task's urgency. Each factor
+// ranges from 0 to 1 and is multiplied by its coefficient.
+type UrgencyCoefficients struct {
+	PriorityHigh   float64 `json:"priority_high"`
+	PriorityMedium float64 `json:"priority_medium"`
+	PriorityLow    float64 `json:"priority_low"`
+
+	// Due rises from 0.2 two weeks before the due date to 1 at it, and
+	// Overdue over the first week past it
+	Due     float64 `json:"due"`
+	Overdue float64 `json:"overdue"`
+
+	// Age rises with the days since the task was created, up to AgeMax
+	Age    float64 `json:"age"`
+	AgeMax float64 `json:"age_max"`
+
+	Doing   float64 `json:"doing"`
+	Blocked float64 `json:"blocked"`
+
+	// Tags counts 0.8 for one tag, 0.9 for two, and 1 for more; TagWeights
+	// are added for each tag they name
+	Tags       float64            `json:"tags"`
+	TagWeights map[string]float64 `json:"tag_weights,omitempty"`
+}
+
+// DefaultUrgencyCoefficients are close to Taskwarrior's
+func DefaultUrgencyCoefficients() UrgencyCoefficients {
+	return UrgencyCoefficients{
+		PriorityHigh:   6.0,
+		PriorityMedium: 3.9,
+		PriorityLow:    1.8,
+		Due:            9.0,
+		Overdue:        3.0,
+		Age:            2.0,
+		AgeMax:         365,
+		Doing:          4.0,
+		Blocked:        -5.0,
+		Tags:           1.0,
+	}
+}
+
+// PriorityWeight returns the coefficient of a priority
+func (c UrgencyCoefficients) PriorityWeight(p Priority) float64 {
+	switch p {
+	case PriorityHigh:
+		return c.PriorityHigh
+	case PriorityMedium:
+		return c.PriorityMedium
+	case PriorityLow:
+		return c.PriorityLow
+	}
+	return 0
+}
+
+// TagWeight returns the weight of a tag, ignoring case
+func (c UrgencyCoefficients) TagWeight(name string) float64 {
+	for tag, w := range c.TagWeights {
+		if strings.EqualFold(tag, name) {
+			return w
+		}
+	}
+	return 0
+}
+
+// TagCountFactor returns the tags factor for a number of tags
+func TagCountFactor(n int) float64 {
+	switch {
+	case n <= 0:
+		return 0
+	case n == 1:
+		return 0.8
+	case n == 2:
+		return 0.9
+	}
+	return 1
+}
+
+// DueFactor returns the due date proximity factor for a task due in days
+// (negative once past due)
+func DueFactor(days float64) float64 {
+	switch {
+	case days >= 14:
+		return 0.2
+	case days <= 0:
+		return 1
+	}
+	return 1 - days*0.8/14
+}
+
+// OverdueFactor returns the overdue factor for a task due days ago
+func OverdueFactor(daysAgo float64) float64 {
+	return math.Max(0, math.Min(daysAgo/7, 1))
+}
+
+// Urgency scores how urgently the task needs attention at now, after
+// Taskwarrior. Done tasks have none.
+func (t *Task) Urgency(c UrgencyCoefficients, now time.Time) float64 {
+	if t.Status == StatusDone {
+		return 0
+	}
+
+	u := c.PriorityWeight(t.Priority)
+	if t.DueDate != nil {
+		days := t.DueDate.Sub(now).Hours() / 24
+		u += c.Due*DueFactor(days) + c.Overdue*OverdueFactor(-days)
+	}
+	if c.AgeMax > 0 {
+		age := now.Sub(t.CreatedAt).Hours() / 24
+		u += c.Age * math.Max(0, math.Min(age/c.AgeMax, 1))
+	}
+	if t.Status == StatusDoing {
+		u += c.Doing
+	}
+	if t.Blocked {
+		u += c.Blocked
+	}
+	u += c.Tags * TagCountFactor(len(t.Tags))
+	for _, tag := range t.Tags {
+		u += c.TagWeight(tag.Name)
+	}
+	return u
+}
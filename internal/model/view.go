@@ -0,0 +1,24 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// View is a saved filter query, listed with "tsk list @name"
+type View struct {
+	ID       int64
+	Name     string
+	Query    string
+	Position int
+}
+
+var viewNameRe = regexp.MustCompile(`^[\pL\pN_-]+$`)
+
+// ValidateViewName checks that a view name can be written as @name
+func ValidateViewName(name string) error {
+	if !viewNameRe.MatchString(name) {
+		return fmt.Errorf("invalid view name %q: use letters, digits, - and _", name)
+	}
+	return nil
+}
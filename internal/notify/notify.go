@@ -0,0 +1,124 @@
+// Package notify delivers task reminders. A Notifier sends a Notification
+// somewhere: standard output, a shell command such as notify-send, a file,
+// or a Unix socket.
+package notify
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+)
+
+// Notification is a reminder that fired for a task
+type Notification struct {
+	TaskID int64      `json:"task_id"`
+	Title  string     `json:"title"`
+	Due    *time.Time `json:"due,omitempty"`
+	At     time.Time  `json:"at"`
+}
+
+// Message returns a one-line description of the notification
+func (n Notification) Message() string {
+	if n.Due == nil {
+		return fmt.Sprintf("Reminder: %s (#%d)", n.Title, n.TaskID)
+	}
+	return fmt.Sprintf("Reminder: %s (#%d) is due %s", n.Title, n.TaskID, n.Due.Local().Format("Mon Jan 2 15:04"))
+}
+
+// Notifier delivers notifications
+type Notifier interface {
+	Notify(n Notification) error
+}
+
+// Stdout writes each notification's message as a line to W
+type Stdout struct {
+	W io.Writer
+}
+
+func (s Stdout) Notify(n Notification) error {
+	_, err := fmt.Fprintf(s.W, "%s  %s\n", n.At.Local().Format("15:04"), n.Message())
+	return err
+}
+
+// Command runs a shell command for each notification, e.g.
+//
+//	notify-send "tsk" "$TSK_MESSAGE"
+//
+// The notification is passed in the TSK_TASK_ID, TSK_TITLE, TSK_DUE
+// (RFC 3339, empty without a due date), and TSK_MESSAGE environment variables.
+type Command struct {
+	Shell string
+}
+
+func (c Command) Notify(n Notification) error {
+	due := ""
+	if n.Due != nil {
+		due = n.Due.Format(time.RFC3339)
+	}
+	cmd := exec.Command("sh", "-c", c.Shell)
+	cmd.Env = append(os.Environ(),
+		"TSK_TASK_ID="+strconv.FormatInt(n.TaskID, 10),
+		"TSK_TITLE="+n.Title,
+		"TSK_DUE="+due,
+		"TSK_MESSAGE="+n.Message(),
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("run notify command: %w: %s", err, out)
+	}
+	return nil
+}
+
+// File appends each notification to a file as a line of JSON
+type File struct {
+	Path string
+}
+
+func (f File) Notify(n Notification) error {
+	file, err := os.OpenFile(f.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("open notify file: %w", err)
+	}
+	if err := json.NewEncoder(file).Encode(n); err != nil {
+		file.Close()
+		return fmt.Errorf("write notify file: %w", err)
+	}
+	return file.Close()
+}
+
+// Socket sends each notification as a line of JSON over a new connection to
+// a Unix socket
+type Socket struct {
+	Path string
+}
+
+func (s Socket) Notify(n Notification) error {
+	conn, err := net.DialTimeout("unix", s.Path, 5*time.Second)
+	if err != nil {
+		return fmt.Errorf("connect notify socket: %w", err)
+	}
+	defer conn.Close()
+	if err := json.NewEncoder(conn).Encode(n); err != nil {
+		return fmt.Errorf("write notify socket: %w", err)
+	}
+	return nil
+}
+
+// Multi delivers each notification to every notifier, returning their
+// joined errors
+type Multi []Notifier
+
+func (m Multi) Notify(n Notification) error {
+	var errs []error
+	for _, notifier := range m {
+		if err := notifier.Notify(n); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
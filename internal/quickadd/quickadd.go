@@ -0,0 +1,368 @@
+// Package quickadd parses a task title with inline attributes, e.g.
+//
+//	Pay rent +home #bills !high due:friday every:1m >12
+//
+// sets the project, a tag, the priority, the due date, a monthly recurrence,
+// and the parent task. A backslash keeps a token literal, so "\#1 fan" is
+// titled "#1 fan". Tokens that don't parse as an attribute, such as "#42" or
+// "!important", are left in the title.
+package quickadd
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/hwanchang/tsk/internal/dates"
+	"github.com/hwanchang/tsk/internal/model"
+)
+
+// Result is a parsed quick-add input. Unset attributes are left zero.
+type Result struct {
+	Title    string
+	Project  string // project name from +project
+	Tags     []string
+	Priority *model.Priority
+	Due      *time.Time
+	Repeat   *model.Recurrence // rule only
+	ParentID int64
+}
+
+// Parse splits input into a title and its inline attributes
+func Parse(input string) (*Result, error) {
+	r := &Result{}
+	var words []string
+
+	for _, tok := range tokenize(input) {
+		if isEscaped(tok) {
+			words = append(words, tok[1:])
+			continue
+		}
+		if !isAttribute(tok) {
+			words = append(words, tok)
+			continue
+		}
+		if err := r.apply(tok); err != nil {
+			return nil, err
+		}
+	}
+
+	r.Title = strings.Join(words, " ")
+	if r.Title == "" {
+		return nil, fmt.Errorf("missing title")
+	}
+	return r, nil
+}
+
+// apply sets the attribute for a token accepted by isAttribute
+func (r *Result) apply(tok string) error {
+	switch key, value := splitKey(tok); {
+	case key == "due":
+		due, err := dates.Parse(value)
+		if err != nil {
+			return fmt.Errorf("invalid due date %q: %w", value, err)
+		}
+		r.Due = &due
+	case key == "every":
+		rule, err := ParseRepeat(value)
+		if err != nil {
+			return err
+		}
+		r.Repeat = model.NewRecurrence(0, rule)
+	case tok[0] == '+':
+		r.Project = strings.Trim(tok[1:], `"`)
+	case tok[0] == '#':
+		name := strings.Trim(tok[1:], `"`)
+		for _, t := range r.Tags {
+			if strings.EqualFold(t, name) {
+				return nil
+			}
+		}
+		r.Tags = append(r.Tags, name)
+	case tok[0] == '!':
+		p := model.PriorityHigh
+		if tok != "!!" {
+			p = model.ParsePriority(strings.ToLower(tok[1:]))
+		}
+		r.Priority = &p
+	case tok[0] == '>':
+		r.ParentID, _ = strconv.ParseInt(tok[1:], 10, 64)
+	}
+	return nil
+}
+
+// isEscaped reports whether a token starts with a backslash that keeps it
+// literal, as in \#1, \+1, or \due:soon
+func isEscaped(tok string) bool {
+	if len(tok) < 2 || tok[0] != '\\' {
+		return false
+	}
+	if key, _ := splitKey(tok[1:]); key != "" {
+		return true
+	}
+	return strings.ContainsRune(`\+#!>`, rune(tok[1]))
+}
+
+// isAttribute reports whether a token sets an attribute rather than being
+// part of the title
+func isAttribute(tok string) bool {
+	if key, value := splitKey(tok); key != "" {
+		return value != ""
+	}
+	if len(tok) < 2 {
+		return false
+	}
+	switch name := tok[1:]; tok[0] {
+	case '+', '#':
+		return isName(strings.Trim(name, `"`))
+	case '!':
+		return tok == "!!" || model.ParsePriority(strings.ToLower(name)) != model.PriorityNone
+	case '>':
+		_, err := strconv.ParseInt(name, 10, 64)
+		return err == nil && !strings.HasPrefix(name, "-")
+	}
+	return false
+}
+
+// splitKey splits a "due:" or "every:" token into its key and unquoted value
+func splitKey(tok string) (string, string) {
+	key, value, ok := strings.Cut(tok, ":")
+	if !ok {
+		return "", ""
+	}
+	key = strings.ToLower(key)
+	if key != "due" && key != "every" {
+		return "", ""
+	}
+	return key, strings.Trim(value, `"`)
+}
+
+// isName reports whether s can name a project or tag. Names start with a
+// letter or digit and need at least one non-digit, so references like "#42"
+// stay in the title. Names with spaces are quoted: +"side project".
+func isName(s string) bool {
+	first := []rune(s + " ")[0]
+	if !unicode.IsLetter(first) && !unicode.IsDigit(first) {
+		return false
+	}
+	return strings.IndexFunc(s, func(c rune) bool { return !unicode.IsDigit(c) }) >= 0
+}
+
+// tokenize splits input on whitespace, keeping quoted values such as
+// due:"next week" in one token
+func tokenize(input string) []string {
+	var tokens []string
+	var cur strings.Builder
+	quoted := false
+	for _, c := range input {
+		switch {
+		case c == '"':
+			quoted = !quoted
+			cur.WriteRune(c)
+		case unicode.IsSpace(c) && !quoted:
+			if cur.Len() > 0 {
+				tokens = append(tokens, cur.String())
+				cur.Reset()
+			}
+		default:
+			cur.WriteRune(c)
+		}
+	}
+	if cur.Len() > 0 {
+		tokens = append(tokens, cur.String())
+	}
+	return tokens
+}
+
+var repeatUnits = map[string]model.RecurrencePattern{
+	"d": model.Daily, "day": model.Daily, "days": model.Daily, "daily": model.Daily,
+	"w": model.Weekly, "week": model.Weekly, "weeks": model.Weekly, "weekly": model.Weekly,
+	"m": model.Monthly, "month": model.Monthly, "months": model.Monthly, "monthly": model.Monthly,
+	"y": model.Yearly, "year": model.Yearly, "years": model.Yearly, "yearly": model.Yearly,
+}
+
+var timesRe = regexp.MustCompile(`^(.+?),? (?:for )?(\d+) times$`)
+
+// ParseRepeat parses a recurrence into a rule. It accepts
+//
+//	weekly, 2w, 3 days, daily:2          every N days, weeks, months, or years
+//	mon,wed,fri, weekdays, weekends      weekly on those days
+//	15th, last day, 1st mon, last fri    monthly on that day
+//	last weekday, 1st weekday            monthly on that working day
+//	2 weeks on mon,fri, month on 1,15    an interval with days
+//
+// optionally followed by "until <date>" or "N times", or an RFC 5545 RRULE
+// such as FREQ=MONTHLY;BYDAY=-1FR.
+func ParseRepeat(s string) (model.RRule, error) {
+	input := strings.Join(strings.Fields(strings.ToLower(s)), " ")
+	if strings.HasPrefix(input, "rrule:") || strings.HasPrefix(input, "freq=") {
+		return model.ParseRRule(input)
+	}
+
+	var until *time.Time
+	if base, date, ok := strings.Cut(input, " until "); ok {
+		t, err := dates.Parse(date)
+		if err != nil {
+			return model.RRule{}, fmt.Errorf("invalid recurrence end %q: %w", date, err)
+		}
+		until, input = &t, base
+	}
+	count := 0
+	if m := timesRe.FindStringSubmatch(input); m != nil {
+		count, _ = strconv.Atoi(m[2])
+		input = m[1]
+	}
+
+	rule, err := parseRepeatRule(strings.TrimPrefix(input, "every "))
+	if err != nil {
+		return model.RRule{}, fmt.Errorf("invalid recurrence %q (use e.g. weekly, 2w, mon,fri, last fri, or an RRULE): %w", s, err)
+	}
+	rule.Count, rule.Until = count, until
+	if err := rule.Validate(); err != nil {
+		return model.RRule{}, fmt.Errorf("invalid recurrence %q: %w", s, err)
+	}
+	return rule, nil
+}
+
+// parseRepeatRule parses a recurrence without its end
+func parseRepeatRule(s string) (model.RRule, error) {
+	unit, days, hasDays := strings.Cut(s, " on ")
+	if !hasDays {
+		if freq, interval, err := parseRepeatUnit(s); err == nil {
+			return model.RRule{Freq: freq, Interval: interval}, nil
+		}
+		unit, days = "", s
+	}
+
+	rule := model.RRule{Interval: 1}
+	if err := parseRepeatDays(&rule, days); err != nil {
+		return model.RRule{}, err
+	}
+	if unit == "" {
+		// Days of the month repeat monthly, days of the week weekly
+		rule.Freq = model.Weekly
+		if len(rule.ByMonthDay) > 0 || len(rule.BySetPos) > 0 ||
+			slices.ContainsFunc(rule.ByDay, func(w model.WeekdayNum) bool { return w.N != 0 }) {
+			rule.Freq = model.Monthly
+		}
+		return rule, nil
+	}
+
+	var err error
+	rule.Freq, rule.Interval, err = parseRepeatUnit(unit)
+	return rule, err
+}
+
+// parseRepeatUnit parses "weekly", "2w", "3 days", or "daily:2" into a
+// frequency and interval
+func parseRepeatUnit(s string) (model.RecurrencePattern, int, error) {
+	unit, count := s, ""
+	if name, n, ok := strings.Cut(s, ":"); ok {
+		unit, count = name, n
+	} else {
+		i := strings.IndexFunc(s, func(c rune) bool { return !unicode.IsDigit(c) })
+		if i < 0 {
+			i = len(s)
+		}
+		count, unit = s[:i], strings.TrimSpace(s[i:])
+	}
+
+	freq, ok := repeatUnits[unit]
+	if !ok {
+		return "", 0, fmt.Errorf("unknown unit %q", unit)
+	}
+	interval := 1
+	if count != "" {
+		n, err := strconv.Atoi(count)
+		if err != nil || n < 1 {
+			return "", 0, fmt.Errorf("invalid interval %q", count)
+		}
+		interval = n
+	}
+	return freq, interval, nil
+}
+
+// parseRepeatDays adds a list of days such as "mon,wed", "last fri",
+// "1, 15", or "last weekday" to rule
+func parseRepeatDays(rule *model.RRule, s string) error {
+	s = strings.ReplaceAll(strings.ReplaceAll(s, " and ", ","), "the ", "")
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		// "mon wed fri" lists weekdays without commas
+		words := strings.Fields(item)
+		if len(words) > 1 && !slices.ContainsFunc(words, func(w string) bool { _, ok := dates.Weekday(w); return !ok }) {
+			items = append(items, words...)
+		} else if item != "" {
+			items = append(items, item)
+		}
+	}
+	if len(items) == 0 {
+		return fmt.Errorf("no days given")
+	}
+
+	for _, item := range items {
+		n := 0
+		name := item
+		if first, rest, ok := strings.Cut(item, " "); ok {
+			var valid bool
+			if n, valid = parseOrdinal(first); !valid {
+				return fmt.Errorf("unknown day %q", item)
+			}
+			name = rest
+		}
+
+		switch name {
+		case "weekday", "weekdays":
+			rule.ByDay = append(rule.ByDay, model.Weekdays...)
+			if n != 0 {
+				rule.BySetPos = append(rule.BySetPos, n)
+			}
+			continue
+		case "weekend", "weekends":
+			if n != 0 {
+				return fmt.Errorf("unknown day %q", item)
+			}
+			rule.ByDay = append(rule.ByDay, model.WeekdayNum{Day: time.Saturday}, model.WeekdayNum{Day: time.Sunday})
+			continue
+		case "day":
+			if n == 0 {
+				return fmt.Errorf("unknown day %q", item)
+			}
+			rule.ByMonthDay = append(rule.ByMonthDay, n)
+			continue
+		}
+
+		if wd, ok := dates.Weekday(name); ok {
+			rule.ByDay = append(rule.ByDay, model.WeekdayNum{N: n, Day: wd})
+			continue
+		}
+		// A day of the month: "15", "15th", "last"
+		day, ok := parseOrdinal(name)
+		if !ok || n != 0 || day < -31 || day > 31 {
+			return fmt.Errorf("unknown day %q", item)
+		}
+		rule.ByMonthDay = append(rule.ByMonthDay, day)
+	}
+	return nil
+}
+
+var ordinals = map[string]int{
+	"first": 1, "second": 2, "third": 3, "fourth": 4, "fifth": 5, "last": -1,
+}
+
+// parseOrdinal parses "2", "2nd", "second", or "last"
+func parseOrdinal(s string) (int, bool) {
+	if n, ok := ordinals[s]; ok {
+		return n, true
+	}
+	for _, suffix := range []string{"st", "nd", "rd", "th"} {
+		s = strings.TrimSuffix(s, suffix)
+	}
+	n, err := strconv.Atoi(s)
+	return n, err == nil && n > 0
+}
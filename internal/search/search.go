@@ -0,0 +1,237 @@
+// Package search translates task search queries into SQLite FTS5 queries.
+//
+// Words match any word they start, so "meet" finds "meeting". Quoted
+// phrases match exactly, "a OR b" matches either, "-word" or "NOT word"
+// excludes, and parentheses group. Prefixing a term with title:, desc:,
+// tag:, or project: limits it to that field.
+package search
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Markers delimit matched text in titles and snippets returned by the store
+const (
+	MarkStart = "\x02"
+	MarkEnd   = "\x03"
+)
+
+// Mark renders the marked spans of s using style
+func Mark(s string, style func(string) string) string {
+	var b strings.Builder
+	for {
+		start := strings.Index(s, MarkStart)
+		if start < 0 {
+			break
+		}
+		end := strings.Index(s[start:], MarkEnd)
+		if end < 0 {
+			break
+		}
+		b.WriteString(s[:start])
+		b.WriteString(style(s[start+len(MarkStart) : start+end]))
+		s = s[start+end+len(MarkEnd):]
+	}
+	b.WriteString(s)
+	return b.String()
+}
+
+// columns maps field prefixes to the FTS columns they search
+var columns = map[string]string{
+	"title": "title", "desc": "description", "description": "description",
+	"tag": "tags", "tags": "tags", "project": "project",
+}
+
+type token struct {
+	text   string // operator, parenthesis, word, or phrase without quotes
+	phrase bool
+	column string
+	not    bool // written as -word
+}
+
+// tokenize splits a query into words, quoted phrases, and parentheses
+func tokenize(input string) []token {
+	var tokens []token
+	runes := []rune(input)
+	for i := 0; i < len(runes); {
+		c := runes[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, token{text: string(c)})
+			i++
+		default:
+			var tok token
+			if c == '-' {
+				tok.not = true
+				i++
+			}
+			// Field prefix: tag:home, title:"weekly review"
+			start := i
+			for i < len(runes) && unicode.IsLetter(runes[i]) {
+				i++
+			}
+			if i < len(runes) && runes[i] == ':' {
+				if col, ok := columns[strings.ToLower(string(runes[start:i]))]; ok {
+					tok.column = col
+					i++
+					start = i
+				}
+			}
+			i = start
+
+			if i < len(runes) && runes[i] == '"' {
+				end := i + 1
+				for end < len(runes) && runes[end] != '"' {
+					end++
+				}
+				tok.text, tok.phrase = string(runes[i+1:end]), true
+				i = min(end+1, len(runes))
+			} else {
+				for i < len(runes) && !unicode.IsSpace(runes[i]) && runes[i] != '(' && runes[i] != ')' {
+					i++
+				}
+				tok.text = string(runes[start:i])
+			}
+			if tok.text != "" || tok.phrase {
+				tokens = append(tokens, tok)
+			}
+		}
+	}
+	return tokens
+}
+
+var wordRe = regexp.MustCompile(`[\pL\pN]+`)
+
+// Query translates a search into an FTS5 MATCH expression
+func Query(input string) (string, error) {
+	var parts []string
+	positive := false
+	depth := 0
+	expectTerm := true // whether a binary operator would be out of place
+	for _, tok := range tokenize(input) {
+		if !tok.phrase && tok.column == "" && !tok.not {
+			switch strings.ToUpper(tok.text) {
+			case "OR", "AND", "NOT":
+				if expectTerm {
+					return "", fmt.Errorf("%s needs a term before it", strings.ToUpper(tok.text))
+				}
+				parts = append(parts, strings.ToUpper(tok.text))
+				expectTerm = true
+				continue
+			case "(":
+				depth++
+				parts = append(parts, "(")
+				expectTerm = true
+				continue
+			case ")":
+				if depth == 0 || expectTerm {
+					return "", fmt.Errorf("unbalanced parentheses")
+				}
+				depth--
+				parts = append(parts, ")")
+				continue
+			}
+		}
+
+		// Quote every term so punctuation can't break the FTS syntax
+		words := wordRe.FindAllString(tok.text, -1)
+		if len(words) == 0 {
+			continue
+		}
+		term := `"` + strings.Join(words, " ") + `"`
+		if !tok.phrase {
+			term += "*"
+		}
+		if tok.column != "" {
+			term = tok.column + ":" + term
+		}
+
+		if tok.not {
+			if expectTerm {
+				return "", fmt.Errorf("-%s needs a term before it", tok.text)
+			}
+			parts = append(parts, "NOT")
+		} else if parts != nil && parts[len(parts)-1] == "NOT" {
+			// NOT's right-hand side doesn't make the query match anything
+		} else {
+			positive = true
+		}
+		parts = append(parts, term)
+		expectTerm = false
+	}
+
+	if depth != 0 {
+		return "", fmt.Errorf("unbalanced parentheses")
+	}
+	if expectTerm && len(parts) > 0 {
+		return "", fmt.Errorf("%s needs a term after it", parts[len(parts)-1])
+	}
+	if !positive {
+		return "", fmt.Errorf("search needs at least one term to match")
+	}
+	return strings.Join(parts, " "), nil
+}
+
+// Terms returns the words a search looks for in task titles, for
+// highlighting. Excluded terms and other fields are left out.
+func Terms(input string) []string {
+	var terms []string
+	negate := false
+	for _, tok := range tokenize(input) {
+		if !tok.phrase && tok.column == "" && strings.ToUpper(tok.text) == "NOT" {
+			negate = true
+			continue
+		}
+		if !tok.not && !negate && (tok.column == "" || tok.column == "title") {
+			for _, w := range wordRe.FindAllString(tok.text, -1) {
+				if u := strings.ToUpper(w); u != "OR" && u != "AND" {
+					terms = append(terms, strings.ToLower(w))
+				}
+			}
+		}
+		negate = false
+	}
+	return terms
+}
+
+// Highlight renders the words of s that start with one of terms using
+// style, ignoring case
+func Highlight(s string, terms []string, style func(string) string) string {
+	if len(terms) == 0 {
+		return s
+	}
+	var b strings.Builder
+	last := 0
+	for _, loc := range wordRe.FindAllStringIndex(s, -1) {
+		word := s[loc[0]:loc[1]]
+		for _, term := range terms {
+			if strings.HasPrefix(strings.ToLower(word), term) {
+				// Only the matched prefix is highlighted
+				end := loc[0] + prefixLen(word, utf8.RuneCountInString(term))
+				b.WriteString(s[last:loc[0]])
+				b.WriteString(style(s[loc[0]:end]))
+				last = end
+				break
+			}
+		}
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// prefixLen returns the byte length of the first n runes of s
+func prefixLen(s string, n int) int {
+	for i := range s {
+		if n == 0 {
+			return i
+		}
+		n--
+	}
+	return len(s)
+}
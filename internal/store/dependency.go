@@ -0,0 +1,115 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/hwanchang/tsk/internal/model"
+)
+
+var ErrDependencyCycle = errors.New("dependency cycle")
+
+// blockedExpr is true for a task (t) with at least one unfinished blocker
+const blockedExpr = `EXISTS (
+	SELECT 1 FROM task_dependencies d
+	JOIN tasks b ON b.id = d.blocked_by_id
+	WHERE d.task_id = t.id AND b.status != 'done' AND b.deleted_at IS NULL
+)`
+
+// AddDependency marks taskID as blocked by blockedByID. Dependencies that
+// would form a cycle are rejected with ErrDependencyCycle.
+func (s *SQLiteStore) AddDependency(taskID, blockedByID int64) error {
+	if taskID == blockedByID {
+		return fmt.Errorf("task #%d can't block itself: %w", taskID, ErrDependencyCycle)
+	}
+
+	return s.withTx(func(tx *SQLiteStore) error {
+		blocker, err := tx.GetTask(blockedByID)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.GetTask(taskID); err != nil {
+			return err
+		}
+
+		// Walk everything the blocker waits on; reaching taskID means a cycle
+		var cycle bool
+		err = tx.q.QueryRow(`
+			WITH RECURSIVE upstream(id) AS (
+				SELECT ?
+				UNION
+				SELECT d.blocked_by_id FROM task_dependencies d JOIN upstream u ON d.task_id = u.id
+			)
+			SELECT EXISTS (SELECT 1 FROM upstream WHERE id = ?)
+		`, blockedByID, taskID).Scan(&cycle)
+		if err != nil {
+			return fmt.Errorf("check dependency cycle: %w", err)
+		}
+		if cycle {
+			return fmt.Errorf("task #%d already depends on #%d: %w", blockedByID, taskID, ErrDependencyCycle)
+		}
+
+		result, err := tx.q.Exec(`
+			INSERT OR IGNORE INTO task_dependencies (task_id, blocked_by_id) VALUES (?, ?)
+		`, taskID, blockedByID)
+		if err != nil {
+			return fmt.Errorf("add dependency: %w", err)
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			return nil // already blocked by it
+		}
+		return tx.recordEvent(taskID, model.EventBlocked, "blocked_by", "", fmt.Sprintf("#%d %s", blocker.ID, blocker.Title))
+	})
+}
+
+func (s *SQLiteStore) RemoveDependency(taskID, blockedByID int64) error {
+	return s.withTx(func(tx *SQLiteStore) error {
+		result, err := tx.q.Exec(`
+			DELETE FROM task_dependencies WHERE task_id = ? AND blocked_by_id = ?
+		`, taskID, blockedByID)
+		if err != nil {
+			return fmt.Errorf("remove dependency: %w", err)
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			return nil // wasn't blocked by it
+		}
+
+		title, err := tx.taskTitle(blockedByID)
+		if err != nil {
+			return err
+		}
+		return tx.recordEvent(taskID, model.EventUnblocked, "blocked_by", fmt.Sprintf("#%d %s", blockedByID, title), "")
+	})
+}
+
+// GetBlockers returns the tasks that taskID is blocked by, including finished ones
+func (s *SQLiteStore) GetBlockers(taskID int64) ([]model.Task, error) {
+	ids, err := s.queryIDs(`
+		SELECT d.blocked_by_id FROM task_dependencies d
+		JOIN tasks b ON b.id = d.blocked_by_id
+		WHERE d.task_id = ? AND b.deleted_at IS NULL
+		ORDER BY d.blocked_by_id
+	`, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	var blockers []model.Task
+	for _, id := range ids {
+		t, err := s.GetTask(id)
+		if err != nil {
+			return nil, err
+		}
+		blockers = append(blockers, *t)
+	}
+	return blockers, nil
+}
+
+// taskTitle returns the title of a task, including trashed ones
+func (s *SQLiteStore) taskTitle(id int64) (string, error) {
+	var title string
+	if err := s.q.QueryRow("SELECT title FROM tasks WHERE id = ?", id).Scan(&title); err != nil {
+		return "", fmt.Errorf("get task title: %w", err)
+	}
+	return title, nil
+}
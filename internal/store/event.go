@@ -0,0 +1,152 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+	"time"
+
+	"github.com/hwanchang/tsk/internal/model"
+)
+
+// currentActor identifies who is making changes, for the task history.
+// TSK_USER overrides the OS user name.
+func currentActor() string {
+	if name := os.Getenv("TSK_USER"); name != "" {
+		return name
+	}
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+func (s *SQLiteStore) recordEvent(taskID int64, kind model.EventKind, field, oldValue, newValue string) error {
+	_, err := s.q.Exec(`
+		INSERT INTO task_events (task_id, kind, field, old_value, new_value, actor, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, taskID, kind, field, oldValue, newValue, s.actor, time.Now())
+	if err != nil {
+		return fmt.Errorf("record task event: %w", err)
+	}
+	return nil
+}
+
+// recordChanges records an event for every field that differs between old and updated
+func (s *SQLiteStore) recordChanges(old, updated *model.Task) error {
+	if old.Status != updated.Status {
+		if err := s.recordEvent(updated.ID, model.EventStatus, "status", string(old.Status), string(updated.Status)); err != nil {
+			return err
+		}
+	}
+
+	if !sameID(old.ProjectID, updated.ProjectID) {
+		oldName, err := s.projectName(old.ProjectID)
+		if err != nil {
+			return err
+		}
+		newName, err := s.projectName(updated.ProjectID)
+		if err != nil {
+			return err
+		}
+		if err := s.recordEvent(updated.ID, model.EventMoved, "project", oldName, newName); err != nil {
+			return err
+		}
+	}
+
+	fields := []struct {
+		name     string
+		old, new string
+	}{
+		{"title", old.Title, updated.Title},
+		{"description", old.Description, updated.Description},
+		{"priority", old.Priority.String(), updated.Priority.String()},
+		{"due", formatEventTime(old.DueDate), formatEventTime(updated.DueDate)},
+		{"parent", formatEventID(old.ParentID), formatEventID(updated.ParentID)},
+	}
+	for _, f := range fields {
+		if f.old == f.new {
+			continue
+		}
+		if err := s.recordEvent(updated.ID, model.EventChanged, f.name, f.old, f.new); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *SQLiteStore) projectName(id *int64) (string, error) {
+	if id == nil {
+		return "", nil
+	}
+	var name string
+	err := s.q.QueryRow("SELECT name FROM projects WHERE id = ?", *id).Scan(&name)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("get project name: %w", err)
+	}
+	return name, nil
+}
+
+// GetTaskEvents returns a task's history, oldest first
+func (s *SQLiteStore) GetTaskEvents(taskID int64) ([]model.TaskEvent, error) {
+	return s.queryEvents(`
+		SELECT id, task_id, kind, field, old_value, new_value, actor, created_at
+		FROM task_events WHERE task_id = ?
+		ORDER BY id
+	`, taskID)
+}
+
+// ListEvents returns the most recent events across all tasks, oldest first
+func (s *SQLiteStore) ListEvents(limit int) ([]model.TaskEvent, error) {
+	return s.queryEvents(`
+		SELECT * FROM (
+			SELECT id, task_id, kind, field, old_value, new_value, actor, created_at
+			FROM task_events ORDER BY id DESC LIMIT ?
+		) ORDER BY id
+	`, limit)
+}
+
+func (s *SQLiteStore) queryEvents(query string, args ...any) ([]model.TaskEvent, error) {
+	rows, err := s.q.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query task events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []model.TaskEvent
+	for rows.Next() {
+		var e model.TaskEvent
+		err := rows.Scan(&e.ID, &e.TaskID, &e.Kind, &e.Field, &e.OldValue, &e.NewValue, &e.Actor, &e.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("scan task event row: %w", err)
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+func sameID(a, b *int64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func formatEventTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format("2006-01-02 15:04")
+}
+
+func formatEventID(id *int64) string {
+	if id == nil {
+		return ""
+	}
+	return "#" + strconv.FormatInt(*id, 10)
+}
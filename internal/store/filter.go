@@ -0,0 +1,205 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hwanchang/tsk/internal/filter"
+	"github.com/hwanchang/tsk/internal/search"
+)
+
+// sqlTimeLayout is the UTC form sqlTime compares
+const sqlTimeLayout = "2006-01-02 15:04:05"
+
+// sqlTime normalizes a DATETIME column to UTC "YYYY-MM-DD HH:MM:SS". Times
+// are stored with their offset, such as "2026-10-18 09:00:00+02:00", or in
+// UTC without one, like SQLite's CURRENT_TIMESTAMP.
+func sqlTime(col string) string {
+	return "datetime(" + col + ")"
+}
+
+// dateColumns are the task columns of the filter date fields
+var dateColumns = map[string]string{
+	"due":       "t.due_date",
+	"created":   "t.created_at",
+	"completed": "t.completed_at",
+}
+
+// compileFilter translates a filter expression into a condition on tasks (t),
+// expanding the saved views it refers to
+func (s *SQLiteStore) compileFilter(e filter.Expr) (string, []any, error) {
+	e, err := filter.Expand(e, func(name string) (string, error) {
+		view, err := s.GetView(name)
+		if err != nil {
+			return "", err
+		}
+		return view.Query, nil
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return compileExpr(e)
+}
+
+// compileExpr translates an expression without views
+func compileExpr(e filter.Expr) (string, []any, error) {
+	switch e := e.(type) {
+	case filter.And:
+		return compileFilters(e, " AND ")
+	case filter.Or:
+		return compileFilters(e, " OR ")
+	case filter.Not:
+		cond, args, err := compileExpr(e.X)
+		return not(cond), args, err
+	case filter.Text:
+		match, err := search.Query(e.Query)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid search %s: %w", e.Query, err)
+		}
+		return "t.id IN (SELECT rowid FROM tasks_fts WHERE tasks_fts MATCH ?)", []any{match}, nil
+	case filter.Cond:
+		cond, args := compileCond(e)
+		return cond, args, nil
+	}
+	return "", nil, fmt.Errorf("unknown filter expression %T", e)
+}
+
+// not negates cond, matching tasks whose empty columns make it NULL, such
+// as tasks without a project for NOT project IN (...)
+func not(cond string) string {
+	return "NOT COALESCE(" + cond + ", 0)"
+}
+
+func compileFilters(exprs []filter.Expr, sep string) (string, []any, error) {
+	if len(exprs) == 0 {
+		return "1", nil, nil // an empty view matches every task
+	}
+	conds := make([]string, len(exprs))
+	var args []any
+	for i, e := range exprs {
+		cond, a, err := compileExpr(e)
+		if err != nil {
+			return "", nil, err
+		}
+		conds[i] = cond
+		args = append(args, a...)
+	}
+	return "(" + strings.Join(conds, sep) + ")", args, nil
+}
+
+// compileCond translates a field condition
+func compileCond(c filter.Cond) (string, []any) {
+	args := make([]any, len(c.Values))
+	for i, v := range c.Values {
+		args[i] = v
+	}
+	in := "IN (" + placeholders(len(c.Values)) + ")"
+	negate := func(cond string) string {
+		if c.Op == "!=" {
+			return not(cond)
+		}
+		return cond
+	}
+
+	switch c.Field {
+	case "status":
+		var statuses []any
+		for _, v := range c.Values {
+			if v == "open" {
+				statuses = append(statuses, "todo", "doing")
+			} else {
+				statuses = append(statuses, v)
+			}
+		}
+		return negate("t.status IN (" + placeholders(len(statuses)) + ")"), statuses
+	case "project":
+		return negate("t.project_id IN (SELECT id FROM projects WHERE name COLLATE NOCASE " + in + ")"), args
+	case "tag":
+		const hasTag = `EXISTS (SELECT 1 FROM task_tags tt JOIN tags g ON g.id = tt.tag_id
+			WHERE tt.task_id = t.id AND g.deleted_at IS NULL`
+		switch c.Values[0] {
+		case "none":
+			return negate("NOT " + hasTag + ")"), nil
+		case "any":
+			return negate(hasTag + ")"), nil
+		}
+		return negate(hasTag + " AND g.name COLLATE NOCASE " + in + ")"), args
+	case "priority", "id":
+		col := "t." + c.Field
+		if c.Op == ":" || c.Op == "=" || c.Op == "!=" {
+			return negate(col + " " + in), args
+		}
+		return col + " " + c.Op + " ?", args
+	case "parent":
+		switch c.Values[0] {
+		case "none":
+			return negate("t.parent_id IS NULL"), nil
+		case "any":
+			return negate("t.parent_id IS NOT NULL"), nil
+		}
+		return negate("t.parent_id " + in), args
+	case "is":
+		conds := make([]string, len(c.Values))
+		for i, v := range c.Values {
+			switch v {
+			case "blocked":
+				conds[i] = "(t.status != 'done' AND " + blockedExpr + ")"
+			case "overdue":
+				conds[i] = "(t.status != 'done' AND " + sqlTime("t.due_date") + " < datetime('now'))"
+			case "recurring":
+				conds[i] = "EXISTS (SELECT 1 FROM recurrences r WHERE r.task_id = t.id)"
+			case "actionable":
+				conds[i] = "(t.status != 'done' AND NOT " + blockedExpr + ")"
+			case "subtask":
+				conds[i] = "t.parent_id IS NOT NULL"
+			}
+		}
+		return "(" + strings.Join(conds, " OR ") + ")", nil
+	}
+	return compileDate(c)
+}
+
+// compileDate translates a condition on a date field. Dates cover their
+// whole day, so due<fri is due before Friday and due<=fri by the end of it.
+func compileDate(c filter.Cond) (string, []any) {
+	col := dateColumns[c.Field]
+	switch c.Values[0] {
+	case "none":
+		if c.Op == "!=" {
+			return col + " IS NOT NULL", nil
+		}
+		return col + " IS NULL", nil
+	case "any":
+		if c.Op == "!=" {
+			return col + " IS NULL", nil
+		}
+		return col + " IS NOT NULL", nil
+	}
+
+	value := sqlTime(col)
+	utc := func(t time.Time) any { return t.UTC().Format(sqlTimeLayout) }
+	switch c.Op {
+	case "<":
+		return value + " < ?", []any{utc(c.Times[0].Start)}
+	case "<=":
+		return value + " <= ?", []any{utc(c.Times[0].End)}
+	case ">":
+		return value + " > ?", []any{utc(c.Times[0].End)}
+	case ">=":
+		return value + " >= ?", []any{utc(c.Times[0].Start)}
+	}
+
+	conds := make([]string, len(c.Times))
+	var args []any
+	for i, span := range c.Times {
+		conds[i] = value + " BETWEEN ? AND ?"
+		args = append(args, utc(span.Start), utc(span.End))
+	}
+	cond := "(" + strings.Join(conds, " OR ") + ")"
+	if c.Op == "!=" {
+		// Tasks without the date aren't on it either
+		return "(" + col + " IS NULL OR NOT " + cond + ")", args
+	}
+	return cond, args
+}
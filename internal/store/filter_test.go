@@ -0,0 +1,137 @@
+package store
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hwanchang/tsk/internal/dates"
+	"github.com/hwanchang/tsk/internal/filter"
+	"github.com/hwanchang/tsk/internal/model"
+)
+
+// populateFilterTasks adds tasks with a bit of everything filters look at,
+// with dates relative to today
+func populateFilterTasks(t *testing.T, s *SQLiteStore) {
+	t.Helper()
+	check := func(err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	today := time.Now()
+	day := func(n int) *time.Time {
+		d := dates.EndOfDay(today.AddDate(0, 0, n))
+		return &d
+	}
+
+	home := model.NewProject("Home")
+	check(s.CreateProject(home))
+	work := model.NewProject("Work")
+	check(s.CreateProject(work))
+
+	milk := model.NewTask("Buy milk")
+	milk.ProjectID = &home.ID
+	milk.Priority = model.PriorityHigh
+	milk.DueDate = day(-1)
+	check(s.CreateTask(milk))
+
+	report := model.NewTask("Write report")
+	report.ProjectID = &work.ID
+	report.Status = model.StatusDoing
+	report.Priority = model.PriorityMedium
+	report.DueDate = day(2)
+	check(s.CreateTask(report))
+
+	taxes := model.NewTask("File taxes")
+	taxes.ProjectID = &work.ID
+	taxes.Status = model.StatusDone
+	taxes.Priority = model.PriorityLow
+	taxes.CompletedAt = day(-1)
+	check(s.CreateTask(taxes))
+
+	trip := model.NewTask("Plan trip")
+	trip.DueDate = day(10)
+	check(s.CreateTask(trip))
+	check(s.AddDependency(trip.ID, report.ID))
+
+	flights := model.NewTask("Book flights")
+	flights.ParentID = &trip.ID
+	check(s.CreateTask(flights))
+
+	for task, name := range map[int64]string{milk.ID: "errands", report.ID: "urgent", trip.ID: "later"} {
+		tag, err := s.GetOrCreateTag(name)
+		check(err)
+		check(s.AddTagToTask(task, tag.ID))
+	}
+	check(s.SaveView(&model.View{Name: "focus", Query: "status:open priority>=medium"}))
+}
+
+func TestListTasksQuery(t *testing.T) {
+	s := newTestStore(t)
+	populateFilterTasks(t, s)
+
+	tests := []struct {
+		query string
+		want  string // titles, sorted
+	}{
+		{"status:todo,doing", "Book flights, Buy milk, Plan trip, Write report"},
+		{"status:open", "Book flights, Buy milk, Plan trip, Write report"},
+		{"status!=done", "Book flights, Buy milk, Plan trip, Write report"},
+		{"priority>=medium", "Buy milk, Write report"},
+		{"priority<medium", "Book flights, File taxes, Plan trip"},
+		{"priority:none", "Book flights, Plan trip"},
+		{"due:none", "Book flights, File taxes"},
+		{"due:any", "Buy milk, Plan trip, Write report"},
+		{"due<tomorrow", "Buy milk"},
+		{"due<+2d", "Buy milk"},
+		{"due<=+2d", "Buy milk, Write report"},
+		{"due<+3d", "Buy milk, Write report"},
+		{"due>+2d", "Plan trip"},
+		{"due:+2d", "Write report"},
+		{"due!=+2d", "Book flights, Buy milk, File taxes, Plan trip"},
+		{"completed>=-1d", "File taxes"},
+		{"is:overdue", "Buy milk"},
+		{"is:blocked", "Plan trip"},
+		{"is:actionable is:subtask", "Book flights"},
+		{"parent:none", "Buy milk, File taxes, Plan trip, Write report"},
+		{"project:WORK", "File taxes, Write report"},
+		{"project:work and not status:done", "Write report"},
+		{"tag:none", "Book flights, File taxes"},
+		{"tag:URGENT or tag:errands", "Buy milk, Write report"},
+		{"(project:home or tag:later) status:todo", "Buy milk, Plan trip"},
+		{"project:home or tag:later status:done", "Buy milk"},
+		{"-tag:later -tag:urgent", "Book flights, Buy milk, File taxes"},
+		{"milk or flights", "Book flights, Buy milk"},
+		{"@focus", "Buy milk, Write report"},
+		{"@focus -is:overdue", "Write report"},
+
+		// Negations match tasks without the field
+		{"-project:work", "Book flights, Buy milk, Plan trip"},
+		{"project!=work", "Book flights, Buy milk, Plan trip"},
+		{"-due<+3d", "Book flights, File taxes, Plan trip"},
+		{"not is:overdue status:open", "Book flights, Plan trip, Write report"},
+	}
+	for _, tt := range tests {
+		e, err := filter.Parse(tt.query)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.query, err)
+			continue
+		}
+		tasks, err := s.ListTasks(TaskFilter{Query: e, AllLevels: true})
+		if err != nil {
+			t.Errorf("ListTasks(%q): %v", tt.query, err)
+			continue
+		}
+		var titles []string
+		for _, task := range tasks {
+			titles = append(titles, task.Title)
+		}
+		slices.Sort(titles)
+		if got := strings.Join(titles, ", "); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.query, got, tt.want)
+		}
+	}
+}
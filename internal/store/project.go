@@ -2,35 +2,54 @@ package store
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/hwanchang/tsk/internal/model"
 )
 
+// inboxID is the default project created by the initial migration
+var inboxID int64 = 1
+
+// ErrProjectNotFound is returned when no project has the name looked up
+var ErrProjectNotFound = errors.New("project not found")
+
 func (s *SQLiteStore) CreateProject(p *model.Project) error {
-	result, err := s.db.Exec(`
-		INSERT INTO projects (name, description) VALUES (?, ?)
-	`, p.Name, p.Description)
-	if err != nil {
-		return fmt.Errorf("insert project: %w", err)
-	}
+	return s.withTx(func(tx *SQLiteStore) error {
+		// A trashed project keeps its name until it's restored or purged
+		trashed, err := tx.trashedID("projects", "name", p.Name)
+		if err != nil {
+			return err
+		}
+		if trashed != 0 {
+			return fmt.Errorf("project %q is %w; restore it with tsk trash restore --project %q, or empty the trash with tsk trash purge", p.Name, ErrInTrash, p.Name)
+		}
 
-	id, err := result.LastInsertId()
-	if err != nil {
-		return fmt.Errorf("get last insert id: %w", err)
-	}
-	p.ID = id
-	return nil
+		result, err := tx.q.Exec(`
+			INSERT INTO projects (name, description) VALUES (?, ?)
+		`, p.Name, p.Description)
+		if err != nil {
+			return fmt.Errorf("insert project: %w", err)
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("get last insert id: %w", err)
+		}
+		p.ID = id
+		return nil
+	})
 }
 
 func (s *SQLiteStore) GetProject(id int64) (*model.Project, error) {
-	row := s.db.QueryRow(`
+	row := s.q.QueryRow(`
 		SELECT p.id, p.name, p.description, p.created_at,
 			   COUNT(t.id) as task_count,
 			   SUM(CASE WHEN t.status = 'done' THEN 1 ELSE 0 END) as done_count
 		FROM projects p
-		LEFT JOIN tasks t ON p.id = t.project_id AND t.parent_id IS NULL
-		WHERE p.id = ?
+		LEFT JOIN tasks t ON p.id = t.project_id AND t.parent_id IS NULL AND t.deleted_at IS NULL
+		WHERE p.id = ? AND p.deleted_at IS NULL
 		GROUP BY p.id
 	`, id)
 
@@ -49,13 +68,38 @@ func (s *SQLiteStore) GetProject(id int64) (*model.Project, error) {
 	return p, nil
 }
 
+// GetProjectByName looks up a project by name, ignoring case. A project
+// that's only in the trash is reported with ErrInTrash.
+func (s *SQLiteStore) GetProjectByName(name string) (*model.Project, error) {
+	var id int64
+	var stored string
+	var deletedAt *time.Time
+	err := s.q.QueryRow(`
+		SELECT id, name, deleted_at FROM projects
+		WHERE name = ? COLLATE NOCASE
+		ORDER BY deleted_at IS NOT NULL, id
+		LIMIT 1
+	`, name).Scan(&id, &stored, &deletedAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", ErrProjectNotFound, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("scan project: %w", err)
+	}
+	if deletedAt != nil {
+		return nil, fmt.Errorf("project %q is %w; restore it with tsk trash restore --project %q", stored, ErrInTrash, stored)
+	}
+	return s.GetProject(id)
+}
+
 func (s *SQLiteStore) ListProjects() ([]model.Project, error) {
-	rows, err := s.db.Query(`
+	rows, err := s.q.Query(`
 		SELECT p.id, p.name, p.description, p.created_at,
 			   COUNT(t.id) as task_count,
 			   SUM(CASE WHEN t.status = 'done' THEN 1 ELSE 0 END) as done_count
 		FROM projects p
-		LEFT JOIN tasks t ON p.id = t.project_id AND t.parent_id IS NULL
+		LEFT JOIN tasks t ON p.id = t.project_id AND t.parent_id IS NULL AND t.deleted_at IS NULL
+		WHERE p.deleted_at IS NULL
 		GROUP BY p.id
 		ORDER BY p.id
 	`)
@@ -80,21 +124,43 @@ func (s *SQLiteStore) ListProjects() ([]model.Project, error) {
 	return projects, nil
 }
 
+// DeleteProject moves a project to the trash. Its tasks are moved to Inbox.
 func (s *SQLiteStore) DeleteProject(id int64) error {
 	// Don't allow deleting the default Inbox project
-	if id == 1 {
+	if id == inboxID {
 		return fmt.Errorf("cannot delete default project")
 	}
 
-	// Move tasks to Inbox before deleting
-	_, err := s.db.Exec("UPDATE tasks SET project_id = 1 WHERE project_id = ?", id)
-	if err != nil {
-		return fmt.Errorf("move tasks to inbox: %w", err)
-	}
+	return s.withTx(func(tx *SQLiteStore) error {
+		project, err := tx.GetProject(id)
+		if err != nil {
+			return err
+		}
+		inbox, err := tx.projectName(&inboxID)
+		if err != nil {
+			return err
+		}
 
-	_, err = s.db.Exec("DELETE FROM projects WHERE id = ?", id)
-	if err != nil {
-		return fmt.Errorf("delete project: %w", err)
-	}
-	return nil
+		// Move tasks to Inbox before deleting
+		taskIDs, err := tx.queryIDs("SELECT id FROM tasks WHERE project_id = ?", id)
+		if err != nil {
+			return err
+		}
+		for _, taskID := range taskIDs {
+			if err := tx.recordEvent(taskID, model.EventMoved, "project", project.Name, inbox); err != nil {
+				return err
+			}
+		}
+
+		_, err = tx.q.Exec("UPDATE tasks SET project_id = ? WHERE project_id = ?", inboxID, id)
+		if err != nil {
+			return fmt.Errorf("move tasks to inbox: %w", err)
+		}
+
+		_, err = tx.q.Exec("UPDATE projects SET deleted_at = ? WHERE id = ?", time.Now(), id)
+		if err != nil {
+			return fmt.Errorf("delete project: %w", err)
+		}
+		return nil
+	})
 }
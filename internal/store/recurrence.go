@@ -1,44 +1,125 @@
 package store
 
 import (
-	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/hwanchang/tsk/internal/model"
 )
 
+// SetRecurrence sets a task's recurrence. An empty anchor or catch-up keeps
+// the task's current one, or the default for a new recurrence.
 func (s *SQLiteStore) SetRecurrence(r *model.Recurrence) error {
-	_, err := s.db.Exec(`
-		INSERT OR REPLACE INTO recurrences (task_id, pattern, interval, next_due)
-		VALUES (?, ?, ?, ?)
-	`, r.TaskID, r.Pattern, r.Interval, r.NextDue)
-	if err != nil {
-		return fmt.Errorf("set recurrence: %w", err)
-	}
-	return nil
+	return s.withTx(func(tx *SQLiteStore) error {
+		_, err := tx.q.Exec(`
+			INSERT OR REPLACE INTO recurrences (task_id, rule, occurrence, next_due, anchor, catch_up)
+			VALUES (?, ?, ?, ?,
+				COALESCE(NULLIF(?, ''), (SELECT anchor FROM recurrences WHERE task_id = ?), 'due'),
+				COALESCE(NULLIF(?, ''), (SELECT catch_up FROM recurrences WHERE task_id = ?), 'skip'))
+		`, r.TaskID, r.Rule.String(), max(r.Occurrence, 1), r.NextDue,
+			r.Anchor, r.TaskID, r.CatchUp, r.TaskID)
+		if err != nil {
+			return fmt.Errorf("set recurrence: %w", err)
+		}
+		return nil
+	})
 }
 
 func (s *SQLiteStore) GetRecurrence(taskID int64) (*model.Recurrence, error) {
-	row := s.db.QueryRow(`
-		SELECT id, task_id, pattern, interval, next_due
+	recs, err := s.queryRecurrences(`
+		SELECT id, task_id, rule, occurrence, next_due, anchor, catch_up
 		FROM recurrences WHERE task_id = ?
 	`, taskID)
-
-	r := &model.Recurrence{}
-	err := row.Scan(&r.ID, &r.TaskID, &r.Pattern, &r.Interval, &r.NextDue)
-	if err == sql.ErrNoRows {
-		return nil, nil // No recurrence set
+	if err != nil || len(recs) == 0 {
+		return nil, err // nil if no recurrence set
 	}
+	return &recs[0], nil
+}
+
+// ListRecurrences returns the recurrences of open tasks, soonest due first
+func (s *SQLiteStore) ListRecurrences() ([]model.Recurrence, error) {
+	return s.queryRecurrences(`
+		SELECT r.id, r.task_id, r.rule, r.occurrence, r.next_due, r.anchor, r.catch_up
+		FROM recurrences r
+		JOIN tasks t ON t.id = r.task_id
+		WHERE t.deleted_at IS NULL AND t.status != 'done'
+		ORDER BY t.due_date IS NULL, t.due_date, r.task_id
+	`)
+}
+
+func (s *SQLiteStore) queryRecurrences(query string, args ...any) ([]model.Recurrence, error) {
+	rows, err := s.q.Query(query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("scan recurrence: %w", err)
+		return nil, fmt.Errorf("query recurrences: %w", err)
 	}
-	return r, nil
+	defer rows.Close()
+
+	var recs []model.Recurrence
+	for rows.Next() {
+		var r model.Recurrence
+		var rule string
+		if err := rows.Scan(&r.ID, &r.TaskID, &rule, &r.Occurrence, &r.NextDue, &r.Anchor, &r.CatchUp); err != nil {
+			return nil, fmt.Errorf("scan recurrence: %w", err)
+		}
+		if r.Rule, err = model.ParseRRule(rule); err != nil {
+			return nil, fmt.Errorf("parse recurrence of task #%d: %w", r.TaskID, err)
+		}
+		recs = append(recs, r)
+	}
+	return recs, rows.Err()
 }
 
 func (s *SQLiteStore) DeleteRecurrence(taskID int64) error {
-	_, err := s.db.Exec("DELETE FROM recurrences WHERE task_id = ?", taskID)
-	if err != nil {
-		return fmt.Errorf("delete recurrence: %w", err)
+	return s.withTx(func(tx *SQLiteStore) error {
+		_, err := tx.q.Exec("DELETE FROM recurrences WHERE task_id = ?", taskID)
+		if err != nil {
+			return fmt.Errorf("delete recurrence: %w", err)
+		}
+		return nil
+	})
+}
+
+// SkipRecurrence moves a recurring task to its next occurrence without
+// completing it
+func (s *SQLiteStore) SkipRecurrence(taskID int64) error {
+	return s.withTx(func(tx *SQLiteStore) error {
+		task, err := tx.GetTask(taskID)
+		if err != nil {
+			return err
+		}
+		if task.Status == model.StatusDone {
+			return fmt.Errorf("task #%d is already done", taskID)
+		}
+		rec, err := tx.GetRecurrence(taskID)
+		if err != nil {
+			return err
+		}
+		if rec == nil {
+			return fmt.Errorf("task #%d has no recurrence", taskID)
+		}
+
+		next, ok := rec.Skip(task.DueDate, time.Now())
+		if !ok {
+			return fmt.Errorf("task #%d is the last occurrence of its series", taskID)
+		}
+		task.DueDate = &next
+		if err := tx.UpdateTask(task); err != nil {
+			return err
+		}
+		return tx.SetRecurrence(rec)
+	})
+}
+
+// rescheduleRecurrence recomputes a recurring task's next occurrence after
+// its due date changed
+func (s *SQLiteStore) rescheduleRecurrence(t *model.Task) error {
+	rec, err := s.GetRecurrence(t.ID)
+	if err != nil || rec == nil {
+		return err
+	}
+	rec.Schedule(t.DueDate)
+	if _, err := s.q.Exec("UPDATE recurrences SET next_due = ? WHERE id = ?", rec.NextDue, rec.ID); err != nil {
+		return fmt.Errorf("reschedule recurrence: %w", err)
 	}
 	return nil
 }
@@ -0,0 +1,129 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/hwanchang/tsk/internal/model"
+)
+
+func (s *SQLiteStore) AddReminder(r *model.Reminder) error {
+	return s.withTx(func(tx *SQLiteStore) error {
+		if _, err := tx.GetTask(r.TaskID); err != nil {
+			return err
+		}
+
+		var offset *int64
+		if r.At == nil {
+			minutes := int64(r.Before / time.Minute)
+			offset = &minutes
+		}
+		r.CreatedAt = time.Now()
+		result, err := tx.q.Exec(`
+			INSERT INTO reminders (task_id, remind_at, offset_minutes, created_at)
+			VALUES (?, ?, ?, ?)
+		`, r.TaskID, r.At, offset, r.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("add reminder: %w", err)
+		}
+		r.ID, err = result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("get reminder id: %w", err)
+		}
+		return nil
+	})
+}
+
+func (s *SQLiteStore) DeleteReminder(id int64) error {
+	return s.withTx(func(tx *SQLiteStore) error {
+		result, err := tx.q.Exec("DELETE FROM reminders WHERE id = ?", id)
+		if err != nil {
+			return fmt.Errorf("delete reminder: %w", err)
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			return fmt.Errorf("reminder not found: %d", id)
+		}
+		return nil
+	})
+}
+
+// ListReminders returns a task's reminders, including fired ones
+func (s *SQLiteStore) ListReminders(taskID int64) ([]model.Reminder, error) {
+	return s.queryReminders(`
+		SELECT id, task_id, remind_at, offset_minutes, fired_at, created_at
+		FROM reminders WHERE task_id = ?
+		ORDER BY id
+	`, taskID)
+}
+
+// DueReminders returns the unfired reminders of open tasks that are due to
+// fire at or before now, along with their tasks
+func (s *SQLiteStore) DueReminders(now time.Time) ([]model.Reminder, []model.Task, error) {
+	pending, err := s.queryReminders(`
+		SELECT r.id, r.task_id, r.remind_at, r.offset_minutes, r.fired_at, r.created_at
+		FROM reminders r
+		JOIN tasks t ON t.id = r.task_id
+		WHERE r.fired_at IS NULL AND t.deleted_at IS NULL AND t.status != 'done'
+		ORDER BY r.id
+	`)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var reminders []model.Reminder
+	var tasks []model.Task
+	for _, r := range pending {
+		task, err := s.GetTask(r.TaskID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if at, ok := r.Time(task.DueDate); ok && !at.After(now) {
+			reminders = append(reminders, r)
+			tasks = append(tasks, *task)
+		}
+	}
+	return reminders, tasks, nil
+}
+
+// MarkReminderFired records that a reminder was delivered. Firing happens in
+// the background, so it isn't recorded for undo.
+func (s *SQLiteStore) MarkReminderFired(id int64, at time.Time) error {
+	if _, err := s.q.Exec("UPDATE reminders SET fired_at = ? WHERE id = ?", at, id); err != nil {
+		return fmt.Errorf("mark reminder fired: %w", err)
+	}
+	return nil
+}
+
+// resetDueReminders re-arms a task's fired reminders that are relative to
+// its due date, after the due date changed
+func (s *SQLiteStore) resetDueReminders(taskID int64) error {
+	_, err := s.q.Exec(`
+		UPDATE reminders SET fired_at = NULL
+		WHERE task_id = ? AND offset_minutes IS NOT NULL AND fired_at IS NOT NULL
+	`, taskID)
+	if err != nil {
+		return fmt.Errorf("reset reminders: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) queryReminders(query string, args ...any) ([]model.Reminder, error) {
+	rows, err := s.q.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query reminders: %w", err)
+	}
+	defer rows.Close()
+
+	var reminders []model.Reminder
+	for rows.Next() {
+		var r model.Reminder
+		var offset sql.NullInt64
+		if err := rows.Scan(&r.ID, &r.TaskID, &r.At, &offset, &r.FiredAt, &r.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan reminder: %w", err)
+		}
+		r.Before = time.Duration(offset.Int64) * time.Minute
+		reminders = append(reminders, r)
+	}
+	return reminders, rows.Err()
+}
@@ -0,0 +1,78 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hwanchang/tsk/internal/model"
+	"github.com/hwanchang/tsk/internal/search"
+)
+
+// SearchResult is a task matching a search. Title and Snippet have the
+// matched terms wrapped in search.MarkStart and search.MarkEnd; Snippet is
+// an excerpt of the first other field that matched, or empty when only
+// the title matched.
+type SearchResult struct {
+	Task    model.Task
+	Title   string
+	Snippet string
+}
+
+// SearchTasks returns the live tasks matching a search, most relevant first
+func (s *SQLiteStore) SearchTasks(query string, limit int) ([]SearchResult, error) {
+	match, err := search.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid search: %w", err)
+	}
+
+	rows, err := s.q.Query(`
+		SELECT t.id,
+		       highlight(tasks_fts, 0, ?1, ?2),
+		       snippet(tasks_fts, 1, ?1, ?2, '…', 12),
+		       snippet(tasks_fts, 2, ?1, ?2, '…', 12),
+		       snippet(tasks_fts, 3, ?1, ?2, '…', 12)
+		FROM tasks_fts f
+		JOIN tasks t ON t.id = f.rowid
+		WHERE tasks_fts MATCH ?3 AND t.deleted_at IS NULL
+		ORDER BY f.rank
+		LIMIT ?4
+	`, search.MarkStart, search.MarkEnd, match, limit)
+	if err != nil {
+		return nil, fmt.Errorf("search tasks: %w", err)
+	}
+
+	var results []SearchResult
+	var ids []int64
+	for rows.Next() {
+		var r SearchResult
+		var id int64
+		var desc, tags, project string
+		if err := rows.Scan(&id, &r.Title, &desc, &tags, &project); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan search result: %w", err)
+		}
+		// The first other field with a match, so the snippet doesn't
+		// repeat the title
+		for _, snippet := range []string{desc, tags, project} {
+			if strings.Contains(snippet, search.MarkStart) {
+				r.Snippet = snippet
+				break
+			}
+		}
+		results = append(results, r)
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i, id := range ids {
+		task, err := s.GetTask(id)
+		if err != nil {
+			return nil, err
+		}
+		results[i].Task = *task
+	}
+	return results, nil
+}
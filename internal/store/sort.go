@@ -0,0 +1,99 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SortKey orders tasks by a field: priority, due, created, completed, title,
+// project, or urgency
+type SortKey struct {
+	Field string
+	Desc  bool
+}
+
+// sortFields maps sort fields to their SQL expressions and whether they
+// sort descending by default
+var sortFields = map[string]struct {
+	expr string
+	desc bool
+}{
+	"priority":  {"t.priority", true},
+	"due":       {sqlTime("t.due_date"), false},
+	"created":   {sqlTime("t.created_at"), true},
+	"completed": {sqlTime("t.completed_at"), true},
+	"title":     {"t.title COLLATE NOCASE", false},
+	"project":   {"(SELECT name FROM projects WHERE id = t.project_id) COLLATE NOCASE", false},
+	"urgency":   {"", true}, // weighed by model.Task.Urgency after loading
+}
+
+// SortFields lists the fields tasks can be sorted by
+var SortFields = []string{"priority", "due", "created", "completed", "title", "project", "urgency"}
+
+// ParseSort parses a comma-separated list of sort fields, each optionally
+// followed by + for ascending or - for descending, e.g. "priority-,due".
+// Fields without a direction use their natural one: high priority, soon
+// due, recently created or completed, A to Z, and most urgent first.
+func ParseSort(s string) ([]SortKey, error) {
+	var keys []SortKey
+	for _, item := range strings.Split(s, ",") {
+		item = strings.ToLower(strings.TrimSpace(item))
+		if item == "" {
+			continue
+		}
+		name := strings.TrimRight(item, "+-")
+		field, ok := sortFields[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown sort field %q (use %s)", name, strings.Join(SortFields, ", "))
+		}
+		key := SortKey{Field: name, Desc: field.desc}
+		switch item[len(name):] {
+		case "":
+		case "+":
+			key.Desc = false
+		case "-":
+			key.Desc = true
+		default:
+			return nil, fmt.Errorf("invalid sort direction in %q (use + or -)", item)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// FormatSort formats sort keys as ParseSort accepts them
+func FormatSort(keys []SortKey) string {
+	items := make([]string, len(keys))
+	for i, k := range keys {
+		items[i] = k.Field
+		switch {
+		case k.Desc == sortFields[k.Field].desc:
+		case k.Desc:
+			items[i] += "-"
+		default:
+			items[i] += "+"
+		}
+	}
+	return strings.Join(items, ",")
+}
+
+// orderBy returns the ORDER BY terms for sort keys, which don't include
+// urgency. Tasks without a date sort after those with one either way.
+func orderBy(keys []SortKey) string {
+	terms := make([]string, 0, len(keys))
+	for _, k := range keys {
+		expr := sortFields[k.Field].expr
+		dir := "ASC"
+		if k.Desc {
+			dir = "DESC"
+		}
+		switch k.Field {
+		case "due", "completed":
+			col := map[string]string{"due": "t.due_date", "completed": "t.completed_at"}[k.Field]
+			terms = append(terms, col+" IS NULL", expr+" "+dir)
+		default:
+			terms = append(terms, expr+" "+dir)
+		}
+	}
+	return strings.Join(terms, ", ")
+}
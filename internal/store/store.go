@@ -1,7 +1,12 @@
 package store
 
 import (
+	"database/sql"
+	"fmt"
+	"time"
+
 	"github.com/hwanchang/tsk/internal/db"
+	"github.com/hwanchang/tsk/internal/filter"
 	"github.com/hwanchang/tsk/internal/model"
 )
 
@@ -9,9 +14,14 @@ type TaskFilter struct {
 	ProjectID  *int64
 	Status     *model.Status
 	ParentID   *int64
+	AllLevels  bool // include subtasks, not just top-level tasks
 	TagIDs     []int64
 	HasDueDate *bool
 	Search     string
+	Query      filter.Expr // a parsed filter expression, see package filter
+	Trashed    bool        // list trashed tasks instead of live ones
+	Actionable bool        // only unfinished tasks that aren't blocked
+	Sort       []SortKey   // order, before the manual order; see ParseSort
 	Limit      int
 }
 
@@ -19,14 +29,18 @@ type Store interface {
 	// Tasks
 	CreateTask(t *model.Task) error
 	GetTask(id int64) (*model.Task, error)
+	GetTaskByUID(uid string) (*model.Task, error)
 	ListTasks(filter TaskFilter) ([]model.Task, error)
 	UpdateTask(t *model.Task) error
 	DeleteTask(id int64) error
 	GetSubtasks(parentID int64) ([]model.Task, error)
+	CompleteTaskWithRecurrence(taskID int64) error
+	SearchTasks(query string, limit int) ([]SearchResult, error)
 
 	// Projects
 	CreateProject(p *model.Project) error
 	GetProject(id int64) (*model.Project, error)
+	GetProjectByName(name string) (*model.Project, error)
 	ListProjects() ([]model.Project, error)
 	DeleteProject(id int64) error
 
@@ -34,6 +48,7 @@ type Store interface {
 	CreateTag(t *model.Tag) error
 	GetTag(id int64) (*model.Tag, error)
 	GetTagByName(name string) (*model.Tag, error)
+	GetOrCreateTag(name string) (*model.Tag, error)
 	ListTags() ([]model.Tag, error)
 	DeleteTag(id int64) error
 	AddTagToTask(taskID, tagID int64) error
@@ -43,20 +58,184 @@ type Store interface {
 	// Recurrence
 	SetRecurrence(r *model.Recurrence) error
 	GetRecurrence(taskID int64) (*model.Recurrence, error)
+	ListRecurrences() ([]model.Recurrence, error)
 	DeleteRecurrence(taskID int64) error
+	SkipRecurrence(taskID int64) error
+
+	// Dependencies
+	AddDependency(taskID, blockedByID int64) error
+	RemoveDependency(taskID, blockedByID int64) error
+	GetBlockers(taskID int64) ([]model.Task, error)
+
+	// Time tracking
+	StartTimer(taskID int64) (*model.TimeEntry, error)
+	StopTimer() (*model.TimeEntry, error)
+	RunningTimer() (*model.TimeEntry, error)
+	ListTimeEntries(taskID int64) ([]model.TimeEntry, error)
+	TrackedTime(taskID int64) (time.Duration, error)
+	TimeReport(since, until time.Time, by string) ([]model.TimeTotal, error)
+
+	// Reminders
+	AddReminder(r *model.Reminder) error
+	DeleteReminder(id int64) error
+	ListReminders(taskID int64) ([]model.Reminder, error)
+	DueReminders(now time.Time) ([]model.Reminder, []model.Task, error)
+	MarkReminderFired(id int64, at time.Time) error
+
+	// Views
+	SaveView(v *model.View) error
+	GetView(name string) (*model.View, error)
+	ListViews() ([]model.View, error)
+	DeleteView(name string) error
+
+	// Trash
+	RestoreTask(id int64) error
+	RestoreProject(id int64) error
+	RestoreTag(id int64) error
+	ListTrashedProjects() ([]model.Project, error)
+	ListTrashedTags() ([]model.Tag, error)
+	PurgeTrash(before time.Time) (int, error)
+
+	// History
+	GetTaskEvents(taskID int64) ([]model.TaskEvent, error)
+	ListEvents(limit int) ([]model.TaskEvent, error)
+
+	// Transactions and undo
+	WithTx(fn func(tx Store) error) error
+	Undo() (string, error)
+	Redo() (string, error)
 
 	// Close
 	Close() error
 }
 
+// querier is implemented by both *sql.DB and *sql.Tx
+type querier interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
 type SQLiteStore struct {
-	db *db.DB
+	db    *db.DB
+	q     querier // db, or the transaction when inside WithTx
+	tx    *sql.Tx
+	depth int    // of nested transactions, each a savepoint
+	actor string // recorded in task history
+
+	autoCompleteParents bool                      // complete a parent when its last subtask is done
+	urgency             model.UrgencyCoefficients // weigh the urgency sort
 }
 
 func New(database *db.DB) *SQLiteStore {
-	return &SQLiteStore{db: database}
+	return &SQLiteStore{db: database, q: database, actor: currentActor(), urgency: model.DefaultUrgencyCoefficients()}
+}
+
+// SetAutoCompleteParents sets whether completing the last open subtask of a
+// task also completes the task
+func (s *SQLiteStore) SetAutoCompleteParents(on bool) {
+	s.autoCompleteParents = on
+}
+
+// SetUrgencyCoefficients sets the coefficients tasks are sorted by urgency with
+func (s *SQLiteStore) SetUrgencyCoefficients(c model.UrgencyCoefficients) {
+	s.urgency = c
+}
+
+// WithTx runs fn in a transaction, committing if it returns nil and rolling
+// back otherwise. Nested calls join the enclosing transaction through a
+// savepoint, so a nested call that fails leaves no changes behind even if
+// the enclosing fn carries on. Each outermost transaction is recorded as
+// one undoable operation.
+func (s *SQLiteStore) WithTx(fn func(tx Store) error) error {
+	return s.withTx(func(tx *SQLiteStore) error {
+		return fn(tx)
+	})
+}
+
+func (s *SQLiteStore) withTx(fn func(tx *SQLiteStore) error) error {
+	return s.transact(true, fn)
+}
+
+// transact runs fn in a transaction, recording its row changes for undo if record is set
+func (s *SQLiteStore) transact(record bool, fn func(tx *SQLiteStore) error) error {
+	if s.tx != nil {
+		return s.savepoint(fn)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	txStore := &SQLiteStore{db: s.db, q: tx, tx: tx, actor: s.actor,
+		autoCompleteParents: s.autoCompleteParents, urgency: s.urgency}
+
+	var groupID int64
+	if record {
+		if groupID, err = txStore.beginUndoGroup(); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if err := fn(txStore); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if record {
+		if err := txStore.endUndoGroup(groupID); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// savepoint runs fn inside the current transaction, rolling back only its
+// changes if it fails
+func (s *SQLiteStore) savepoint(fn func(tx *SQLiteStore) error) error {
+	s.depth++
+	defer func() { s.depth-- }()
+	name := fmt.Sprintf("nested_%d", s.depth)
+
+	if _, err := s.q.Exec("SAVEPOINT " + name); err != nil {
+		return fmt.Errorf("begin savepoint: %w", err)
+	}
+	if err := fn(s); err != nil {
+		s.q.Exec("ROLLBACK TO " + name)
+		s.q.Exec("RELEASE " + name)
+		return err
+	}
+	if _, err := s.q.Exec("RELEASE " + name); err != nil {
+		return fmt.Errorf("release savepoint: %w", err)
+	}
+	return nil
 }
 
 func (s *SQLiteStore) Close() error {
 	return s.db.Close()
 }
+
+// queryIDs runs a query returning a single integer column
+func (s *SQLiteStore) queryIDs(query string, args ...any) ([]int64, error) {
+	rows, err := s.q.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query ids: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
@@ -0,0 +1,297 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hwanchang/tsk/internal/db"
+	"github.com/hwanchang/tsk/internal/model"
+)
+
+func newTestStore(t *testing.T) *SQLiteStore {
+	t.Helper()
+	database, err := db.New(filepath.Join(t.TempDir(), "tsk.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close() })
+	if err := database.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	return New(database)
+}
+
+// snapshotTables are the tables whose contents a failed operation mustn't change
+var snapshotTables = []string{
+	"projects", "tasks", "tags", "task_tags", "recurrences", "task_events", "undo_groups", "undo_changes",
+}
+
+// snapshot dumps the rows of snapshotTables
+func snapshot(t *testing.T, s *SQLiteStore) string {
+	t.Helper()
+	var b strings.Builder
+	for _, table := range snapshotTables {
+		rows, err := s.db.Query("SELECT * FROM " + table + " ORDER BY 1, 2")
+		if err != nil {
+			t.Fatal(err)
+		}
+		columns, _ := rows.Columns()
+		for rows.Next() {
+			values := make([]any, len(columns))
+			ptrs := make([]any, len(columns))
+			for i := range values {
+				ptrs[i] = &values[i]
+			}
+			if err := rows.Scan(ptrs...); err != nil {
+				t.Fatal(err)
+			}
+			fmt.Fprintf(&b, "%s %v\n", table, values)
+		}
+		if err := rows.Err(); err != nil {
+			t.Fatal(err)
+		}
+		rows.Close()
+	}
+	return b.String()
+}
+
+// failOn makes every following statement of kind ("INSERT", "UPDATE", ...)
+// on table fail
+func failOn(t *testing.T, s *SQLiteStore, kind, table string) {
+	t.Helper()
+	_, err := s.db.Exec(fmt.Sprintf(`CREATE TRIGGER inject_failure BEFORE %s ON %s
+		BEGIN SELECT RAISE(ABORT, 'injected failure'); END`, kind, table))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// assertUnchanged checks that err is the injected failure and that the
+// tables are as they were
+func assertUnchanged(t *testing.T, s *SQLiteStore, before string, err error) {
+	t.Helper()
+	if err == nil || !strings.Contains(err.Error(), "injected failure") {
+		t.Fatalf("got error %v, want the injected failure", err)
+	}
+	if after := snapshot(t, s); after != before {
+		t.Errorf("partial changes remain:\nbefore:\n%safter:\n%s", before, after)
+	}
+}
+
+func TestCompleteRecurringTaskRollsBack(t *testing.T) {
+	for _, step := range []struct{ name, kind, table string }{
+		{"complete", "UPDATE", "tasks"},
+		{"next occurrence", "INSERT", "tasks"},
+		{"tag copy", "INSERT", "task_tags"},
+		{"recurrence move", "INSERT", "recurrences"},
+	} {
+		t.Run(step.name, func(t *testing.T) {
+			s := newTestStore(t)
+			due := time.Now().AddDate(0, 0, 1)
+			task := model.NewTask("Water plants")
+			task.DueDate = &due
+			if err := s.CreateTask(task); err != nil {
+				t.Fatal(err)
+			}
+			tag := model.NewTag("home")
+			if err := s.CreateTag(tag); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.AddTagToTask(task.ID, tag.ID); err != nil {
+				t.Fatal(err)
+			}
+			rec := model.NewRecurrence(task.ID, model.RRule{Freq: model.Weekly, Interval: 1})
+			rec.Schedule(task.DueDate)
+			if err := s.SetRecurrence(rec); err != nil {
+				t.Fatal(err)
+			}
+
+			before := snapshot(t, s)
+			failOn(t, s, step.kind, step.table)
+			assertUnchanged(t, s, before, s.CompleteTaskWithRecurrence(task.ID))
+		})
+	}
+}
+
+func TestDeleteProjectRollsBack(t *testing.T) {
+	for _, step := range []struct{ name, kind, table string }{
+		{"history", "INSERT", "task_events"},
+		{"move to inbox", "UPDATE", "tasks"},
+		{"trash project", "UPDATE", "projects"},
+	} {
+		t.Run(step.name, func(t *testing.T) {
+			s := newTestStore(t)
+			project := model.NewProject("Garden")
+			if err := s.CreateProject(project); err != nil {
+				t.Fatal(err)
+			}
+			for _, title := range []string{"Rake leaves", "Plant bulbs"} {
+				task := model.NewTask(title)
+				task.ProjectID = &project.ID
+				if err := s.CreateTask(task); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			before := snapshot(t, s)
+			failOn(t, s, step.kind, step.table)
+			assertUnchanged(t, s, before, s.DeleteProject(project.ID))
+		})
+	}
+}
+
+// addTask adds a subtask with a new tag and a recurrence in one transaction,
+// as tsk add does
+func addTask(s Store, parentID int64) error {
+	return s.WithTx(func(tx Store) error {
+		task := model.NewTask("Buy seeds")
+		task.ParentID = &parentID
+		if err := tx.CreateTask(task); err != nil {
+			return err
+		}
+		tag := model.NewTag("errands")
+		if err := tx.CreateTag(tag); err != nil {
+			return err
+		}
+		if err := tx.AddTagToTask(task.ID, tag.ID); err != nil {
+			return err
+		}
+		return tx.SetRecurrence(model.NewRecurrence(task.ID, model.RRule{Freq: model.Monthly, Interval: 1}))
+	})
+}
+
+func TestAddTaskRollsBack(t *testing.T) {
+	for _, step := range []struct{ name, kind, table string }{
+		{"tag", "INSERT", "tags"},
+		{"tagging", "INSERT", "task_tags"},
+		{"recurrence", "INSERT", "recurrences"},
+	} {
+		t.Run(step.name, func(t *testing.T) {
+			s := newTestStore(t)
+			parent := model.NewTask("Spring garden")
+			if err := s.CreateTask(parent); err != nil {
+				t.Fatal(err)
+			}
+
+			before := snapshot(t, s)
+			failOn(t, s, step.kind, step.table)
+			assertUnchanged(t, s, before, addTask(s, parent.ID))
+		})
+	}
+}
+
+func TestNestedWithTxJoinsOuter(t *testing.T) {
+	s := newTestStore(t)
+	before := snapshot(t, s)
+
+	errOuter := errors.New("outer failed")
+	err := s.WithTx(func(tx Store) error {
+		if err := tx.CreateProject(model.NewProject("Outer")); err != nil {
+			return err
+		}
+		err := tx.WithTx(func(inner Store) error {
+			return inner.CreateProject(model.NewProject("Inner"))
+		})
+		if err != nil {
+			return err
+		}
+
+		// The inner transaction isn't committed on its own
+		projects, err := s.ListProjects()
+		if err != nil {
+			return err
+		}
+		for _, p := range projects {
+			if p.Name == "Inner" {
+				t.Error("inner transaction committed before the outer one")
+			}
+		}
+		return errOuter
+	})
+	if !errors.Is(err, errOuter) {
+		t.Fatalf("got error %v, want %v", err, errOuter)
+	}
+	if after := snapshot(t, s); after != before {
+		t.Errorf("nested transaction wasn't rolled back:\nbefore:\n%safter:\n%s", before, after)
+	}
+}
+
+func TestNestedWithTxRollsBackOnItsOwn(t *testing.T) {
+	s := newTestStore(t)
+	errInner := errors.New("inner failed")
+	err := s.WithTx(func(tx Store) error {
+		if err := tx.CreateProject(model.NewProject("Outer")); err != nil {
+			return err
+		}
+		err := tx.WithTx(func(inner Store) error {
+			if err := inner.CreateProject(model.NewProject("Inner")); err != nil {
+				return err
+			}
+			return errInner
+		})
+		if !errors.Is(err, errInner) {
+			t.Errorf("got error %v from the nested call, want %v", err, errInner)
+		}
+		// Carrying on after a failed nested call keeps only the outer changes
+		return tx.CreateProject(model.NewProject("After"))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	projects, err := s.ListProjects()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, p := range projects {
+		names = append(names, p.Name)
+	}
+	if got := strings.Join(names, ", "); got != "Inbox, Outer, After" {
+		t.Errorf("got projects %s, want Inbox, Outer, After", got)
+	}
+
+	// Nor is the failed call's change left in the undo journal
+	if _, err := s.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if projects, _ = s.ListProjects(); len(projects) != 1 {
+		t.Errorf("got %d projects after undo, want only Inbox", len(projects))
+	}
+}
+
+func TestNestedWithTxIsOneUndoStep(t *testing.T) {
+	s := newTestStore(t)
+	err := s.WithTx(func(tx Store) error {
+		if err := tx.CreateProject(model.NewProject("Outer")); err != nil {
+			return err
+		}
+		return tx.WithTx(func(inner Store) error {
+			return inner.CreateProject(model.NewProject("Inner"))
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	projects, err := s.ListProjects()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(projects) != 3 {
+		t.Fatalf("got %d projects, want Inbox, Outer, and Inner", len(projects))
+	}
+
+	if _, err := s.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if projects, _ = s.ListProjects(); len(projects) != 1 {
+		t.Errorf("got %d projects after undo, want only Inbox", len(projects))
+	}
+	if _, err := s.Undo(); !errors.Is(err, ErrNothingToUndo) {
+		t.Errorf("got %v from a second undo, want %v", err, ErrNothingToUndo)
+	}
+}
@@ -3,28 +3,40 @@ package store
 import (
 	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/hwanchang/tsk/internal/model"
 )
 
 func (s *SQLiteStore) CreateTag(t *model.Tag) error {
-	result, err := s.db.Exec(`
-		INSERT INTO tags (name, color) VALUES (?, ?)
-	`, t.Name, t.Color)
-	if err != nil {
-		return fmt.Errorf("insert tag: %w", err)
-	}
+	return s.withTx(func(tx *SQLiteStore) error {
+		// A trashed tag keeps its name until it's restored or purged
+		trashed, err := tx.trashedID("tags", "name", t.Name)
+		if err != nil {
+			return err
+		}
+		if trashed != 0 {
+			return fmt.Errorf("tag %q is %w; restore it with tsk trash restore --tag %q, or empty the trash with tsk trash purge", t.Name, ErrInTrash, t.Name)
+		}
 
-	id, err := result.LastInsertId()
-	if err != nil {
-		return fmt.Errorf("get last insert id: %w", err)
-	}
-	t.ID = id
-	return nil
+		result, err := tx.q.Exec(`
+			INSERT INTO tags (name, color) VALUES (?, ?)
+		`, t.Name, t.Color)
+		if err != nil {
+			return fmt.Errorf("insert tag: %w", err)
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("get last insert id: %w", err)
+		}
+		t.ID = id
+		return nil
+	})
 }
 
 func (s *SQLiteStore) GetTag(id int64) (*model.Tag, error) {
-	row := s.db.QueryRow("SELECT id, name, color FROM tags WHERE id = ?", id)
+	row := s.q.QueryRow("SELECT id, name, color FROM tags WHERE id = ? AND deleted_at IS NULL", id)
 
 	t := &model.Tag{}
 	err := row.Scan(&t.ID, &t.Name, &t.Color)
@@ -37,8 +49,14 @@ func (s *SQLiteStore) GetTag(id int64) (*model.Tag, error) {
 	return t, nil
 }
 
+// GetTagByName looks up a tag by name, ignoring case, and returns nil if
+// there's none
 func (s *SQLiteStore) GetTagByName(name string) (*model.Tag, error) {
-	row := s.db.QueryRow("SELECT id, name, color FROM tags WHERE name = ?", name)
+	row := s.q.QueryRow(`
+		SELECT id, name, color FROM tags
+		WHERE name = ? COLLATE NOCASE AND deleted_at IS NULL
+		ORDER BY id LIMIT 1
+	`, name)
 
 	t := &model.Tag{}
 	err := row.Scan(&t.ID, &t.Name, &t.Color)
@@ -51,8 +69,25 @@ func (s *SQLiteStore) GetTagByName(name string) (*model.Tag, error) {
 	return t, nil
 }
 
+// GetOrCreateTag returns the tag with the given name, ignoring case,
+// creating it if there's none. A tag that's only in the trash is reported
+// with ErrInTrash, as CreateTag does.
+func (s *SQLiteStore) GetOrCreateTag(name string) (*model.Tag, error) {
+	var tag *model.Tag
+	err := s.withTx(func(tx *SQLiteStore) error {
+		var err error
+		tag, err = tx.GetTagByName(name)
+		if err != nil || tag != nil {
+			return err
+		}
+		tag = model.NewTag(name)
+		return tx.CreateTag(tag)
+	})
+	return tag, err
+}
+
 func (s *SQLiteStore) ListTags() ([]model.Tag, error) {
-	rows, err := s.db.Query("SELECT id, name, color FROM tags ORDER BY name")
+	rows, err := s.q.Query("SELECT id, name, color FROM tags WHERE deleted_at IS NULL ORDER BY name")
 	if err != nil {
 		return nil, fmt.Errorf("query tags: %w", err)
 	}
@@ -69,39 +104,78 @@ func (s *SQLiteStore) ListTags() ([]model.Tag, error) {
 	return tags, nil
 }
 
+// DeleteTag moves a tag to the trash. It stays linked to its tasks but is
+// hidden until restored.
 func (s *SQLiteStore) DeleteTag(id int64) error {
-	// task_tags are automatically deleted via ON DELETE CASCADE
-	_, err := s.db.Exec("DELETE FROM tags WHERE id = ?", id)
-	if err != nil {
-		return fmt.Errorf("delete tag: %w", err)
-	}
-	return nil
+	return s.withTx(func(tx *SQLiteStore) error {
+		tag, err := tx.GetTag(id)
+		if err != nil {
+			return err
+		}
+
+		// Record removal from every task that had the tag
+		taskIDs, err := tx.taggedTaskIDs(id)
+		if err != nil {
+			return err
+		}
+		for _, taskID := range taskIDs {
+			if err := tx.recordEvent(taskID, model.EventTagRemoved, "tag", tag.Name, ""); err != nil {
+				return err
+			}
+		}
+
+		_, err = tx.q.Exec("UPDATE tags SET deleted_at = ? WHERE id = ?", time.Now(), id)
+		if err != nil {
+			return fmt.Errorf("delete tag: %w", err)
+		}
+		return nil
+	})
 }
 
 func (s *SQLiteStore) AddTagToTask(taskID, tagID int64) error {
-	_, err := s.db.Exec(`
-		INSERT OR IGNORE INTO task_tags (task_id, tag_id) VALUES (?, ?)
-	`, taskID, tagID)
-	if err != nil {
-		return fmt.Errorf("add tag to task: %w", err)
-	}
-	return nil
+	return s.withTx(func(tx *SQLiteStore) error {
+		result, err := tx.q.Exec(`
+			INSERT OR IGNORE INTO task_tags (task_id, tag_id) VALUES (?, ?)
+		`, taskID, tagID)
+		if err != nil {
+			return fmt.Errorf("add tag to task: %w", err)
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			return nil // already tagged
+		}
+
+		tag, err := tx.GetTag(tagID)
+		if err != nil {
+			return err
+		}
+		return tx.recordEvent(taskID, model.EventTagAdded, "tag", "", tag.Name)
+	})
 }
 
 func (s *SQLiteStore) RemoveTagFromTask(taskID, tagID int64) error {
-	_, err := s.db.Exec("DELETE FROM task_tags WHERE task_id = ? AND tag_id = ?", taskID, tagID)
-	if err != nil {
-		return fmt.Errorf("remove tag from task: %w", err)
-	}
-	return nil
+	return s.withTx(func(tx *SQLiteStore) error {
+		result, err := tx.q.Exec("DELETE FROM task_tags WHERE task_id = ? AND tag_id = ?", taskID, tagID)
+		if err != nil {
+			return fmt.Errorf("remove tag from task: %w", err)
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			return nil // wasn't tagged
+		}
+
+		tag, err := tx.GetTag(tagID)
+		if err != nil {
+			return err
+		}
+		return tx.recordEvent(taskID, model.EventTagRemoved, "tag", tag.Name, "")
+	})
 }
 
 func (s *SQLiteStore) GetTaskTags(taskID int64) ([]model.Tag, error) {
-	rows, err := s.db.Query(`
+	rows, err := s.q.Query(`
 		SELECT t.id, t.name, t.color
 		FROM tags t
 		JOIN task_tags tt ON t.id = tt.tag_id
-		WHERE tt.task_id = ?
+		WHERE tt.task_id = ? AND t.deleted_at IS NULL
 		ORDER BY t.name
 	`, taskID)
 	if err != nil {
@@ -119,3 +193,12 @@ func (s *SQLiteStore) GetTaskTags(taskID int64) ([]model.Tag, error) {
 	}
 	return tags, nil
 }
+
+// taggedTaskIDs returns the live tasks linked to a tag
+func (s *SQLiteStore) taggedTaskIDs(tagID int64) ([]int64, error) {
+	return s.queryIDs(`
+		SELECT tt.task_id FROM task_tags tt
+		JOIN tasks t ON t.id = tt.task_id
+		WHERE tt.tag_id = ? AND t.deleted_at IS NULL
+	`, tagID)
+}
@@ -3,39 +3,71 @@ package store
 import (
 	"database/sql"
 	"fmt"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/google/uuid"
+
 	"github.com/hwanchang/tsk/internal/model"
+	"github.com/hwanchang/tsk/internal/search"
 )
 
+// subtaskCountExprs count the live direct subtasks of a task (t), and those that are done
+const subtaskCountExprs = `
+	(SELECT COUNT(*) FROM tasks s WHERE s.parent_id = t.id AND s.deleted_at IS NULL),
+	(SELECT COUNT(*) FROM tasks s WHERE s.parent_id = t.id AND s.deleted_at IS NULL AND s.status = 'done')`
+
 func (s *SQLiteStore) CreateTask(t *model.Task) error {
-	result, err := s.db.Exec(`
-		INSERT INTO tasks (project_id, parent_id, title, description, status, priority, due_date, position)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
-	`, t.ProjectID, t.ParentID, t.Title, t.Description, t.Status, t.Priority, t.DueDate, t.Position)
-	if err != nil {
-		return fmt.Errorf("insert task: %w", err)
-	}
+	return s.withTx(func(tx *SQLiteStore) error {
+		if t.UID == "" {
+			t.UID = uuid.NewString()
+		} else {
+			// A trashed task keeps its UID until it's restored or purged
+			trashed, err := tx.trashedID("tasks", "uid", t.UID)
+			if err != nil {
+				return err
+			}
+			if trashed != 0 {
+				return fmt.Errorf("task #%d with UID %s is %w; restore it with tsk trash restore %d, or empty the trash with tsk trash purge", trashed, t.UID, ErrInTrash, trashed)
+			}
+		}
 
-	id, err := result.LastInsertId()
-	if err != nil {
-		return fmt.Errorf("get last insert id: %w", err)
-	}
-	t.ID = id
-	return nil
+		// Stored like CURRENT_TIMESTAMP, which it defaults to
+		var createdAt any
+		if !t.CreatedAt.IsZero() {
+			createdAt = t.CreatedAt.UTC().Format(sqlTimeLayout)
+		}
+		result, err := tx.q.Exec(`
+			INSERT INTO tasks (uid, project_id, parent_id, title, description, status, priority, due_date, created_at, completed_at, position)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, COALESCE(?, CURRENT_TIMESTAMP), ?, ?)
+		`, t.UID, t.ProjectID, t.ParentID, t.Title, t.Description, t.Status, t.Priority, t.DueDate, createdAt, t.CompletedAt, t.Position)
+		if err != nil {
+			return fmt.Errorf("insert task: %w", err)
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("get last insert id: %w", err)
+		}
+		t.ID = id
+		return tx.recordEvent(t.ID, model.EventCreated, "", "", t.Title)
+	})
 }
 
 func (s *SQLiteStore) GetTask(id int64) (*model.Task, error) {
-	row := s.db.QueryRow(`
-		SELECT id, project_id, parent_id, title, description, status, priority, due_date, created_at, completed_at, position
-		FROM tasks WHERE id = ?
+	row := s.q.QueryRow(`
+		SELECT id, COALESCE(uid, ''), project_id, parent_id, title, description, status, priority, due_date, created_at, completed_at, position,
+		       `+blockedExpr+`, `+subtaskCountExprs+`
+		FROM tasks t WHERE id = ? AND deleted_at IS NULL
 	`, id)
 
 	t := &model.Task{}
 	err := row.Scan(
-		&t.ID, &t.ProjectID, &t.ParentID, &t.Title, &t.Description,
-		&t.Status, &t.Priority, &t.DueDate, &t.CreatedAt, &t.CompletedAt, &t.Position,
+		&t.ID, &t.UID, &t.ProjectID, &t.ParentID, &t.Title, &t.Description,
+		&t.Status, &t.Priority, &t.DueDate, &t.CreatedAt, &t.CompletedAt, &t.Position, &t.Blocked,
+		&t.SubtaskCount, &t.SubtasksDone,
 	)
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("task not found: %d", id)
@@ -54,13 +86,39 @@ func (s *SQLiteStore) GetTask(id int64) (*model.Task, error) {
 	return t, nil
 }
 
+// GetTaskByUID returns the live task with a UID, or nil if there is none
+func (s *SQLiteStore) GetTaskByUID(uid string) (*model.Task, error) {
+	var id int64
+	err := s.q.QueryRow("SELECT id FROM tasks WHERE uid = ? AND deleted_at IS NULL", uid).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, nil // Not found, but not an error
+	}
+	if err != nil {
+		return nil, fmt.Errorf("find task: %w", err)
+	}
+	return s.GetTask(id)
+}
+
 func (s *SQLiteStore) ListTasks(filter TaskFilter) ([]model.Task, error) {
 	query := strings.Builder{}
 	args := []interface{}{}
 
+	// Urgency is weighed in Go once the tasks are loaded. The keys before it
+	// rank the tasks into groups it orders within; those after it break ties.
+	sortKeys, urgency := filter.Sort, -1
+	group := "0"
+	if i := slices.IndexFunc(sortKeys, func(k SortKey) bool { return k.Field == "urgency" }); i >= 0 {
+		urgency = i
+		if i > 0 {
+			group = "DENSE_RANK() OVER (ORDER BY " + orderBy(sortKeys[:i]) + ")"
+		}
+		sortKeys = sortKeys[i+1:]
+	}
+
 	query.WriteString(`
-		SELECT DISTINCT t.id, t.project_id, t.parent_id, t.title, t.description,
-		       t.status, t.priority, t.due_date, t.created_at, t.completed_at, t.position
+		SELECT DISTINCT t.id, COALESCE(t.uid, ''), t.project_id, t.parent_id, t.title, t.description,
+		       t.status, t.priority, t.due_date, t.created_at, t.completed_at, t.position, t.deleted_at,
+		       ` + blockedExpr + `, ` + subtaskCountExprs + `, ` + group + `
 		FROM tasks t
 	`)
 
@@ -69,8 +127,24 @@ func (s *SQLiteStore) ListTasks(filter TaskFilter) ([]model.Task, error) {
 		query.WriteString(" JOIN task_tags tt ON t.id = tt.task_id")
 	}
 
+	// Join the full-text index for searches, which rank the results
+	if filter.Search != "" {
+		match, err := search.Query(filter.Search)
+		if err != nil {
+			return nil, fmt.Errorf("invalid search: %w", err)
+		}
+		query.WriteString(" JOIN tasks_fts f ON f.rowid = t.id AND tasks_fts MATCH ?")
+		args = append(args, match)
+	}
+
 	query.WriteString(" WHERE 1=1")
 
+	if filter.Trashed {
+		query.WriteString(" AND t.deleted_at IS NOT NULL")
+	} else {
+		query.WriteString(" AND t.deleted_at IS NULL")
+	}
+
 	if filter.ProjectID != nil {
 		query.WriteString(" AND t.project_id = ?")
 		args = append(args, *filter.ProjectID)
@@ -84,11 +158,20 @@ func (s *SQLiteStore) ListTasks(filter TaskFilter) ([]model.Task, error) {
 	if filter.ParentID != nil {
 		query.WriteString(" AND t.parent_id = ?")
 		args = append(args, *filter.ParentID)
+	} else if filter.AllLevels {
+		// No parent condition
+	} else if filter.Trashed {
+		// Subtasks trashed along with their parent are listed under it
+		query.WriteString(" AND (t.parent_id IS NULL OR t.parent_id NOT IN (SELECT id FROM tasks WHERE deleted_at IS NOT NULL))")
 	} else {
 		// By default, only show top-level tasks
 		query.WriteString(" AND t.parent_id IS NULL")
 	}
 
+	if filter.Actionable {
+		query.WriteString(" AND t.status != 'done' AND NOT " + blockedExpr)
+	}
+
 	if filter.HasDueDate != nil {
 		if *filter.HasDueDate {
 			query.WriteString(" AND t.due_date IS NOT NULL")
@@ -97,10 +180,13 @@ func (s *SQLiteStore) ListTasks(filter TaskFilter) ([]model.Task, error) {
 		}
 	}
 
-	if filter.Search != "" {
-		query.WriteString(" AND (t.title LIKE ? OR t.description LIKE ?)")
-		search := "%" + filter.Search + "%"
-		args = append(args, search, search)
+	if filter.Query != nil {
+		cond, condArgs, err := s.compileFilter(filter.Query)
+		if err != nil {
+			return nil, err
+		}
+		query.WriteString(" AND " + cond)
+		args = append(args, condArgs...)
 	}
 
 	if len(filter.TagIDs) > 0 {
@@ -112,25 +198,38 @@ func (s *SQLiteStore) ListTasks(filter TaskFilter) ([]model.Task, error) {
 		query.WriteString(" AND tt.tag_id IN (" + strings.Join(placeholders, ",") + ")")
 	}
 
-	query.WriteString(" ORDER BY t.position ASC, t.created_at DESC")
+	query.WriteString(" ORDER BY ")
+	if len(sortKeys) > 0 {
+		query.WriteString(orderBy(sortKeys) + ", ")
+	}
+	if filter.Trashed {
+		query.WriteString("t.deleted_at DESC")
+	} else if filter.Search != "" {
+		query.WriteString("f.rank, t.position ASC")
+	} else {
+		query.WriteString("t.position ASC, t.created_at DESC")
+	}
 
-	if filter.Limit > 0 {
+	if filter.Limit > 0 && urgency < 0 {
 		query.WriteString(" LIMIT ?")
 		args = append(args, filter.Limit)
 	}
 
-	rows, err := s.db.Query(query.String(), args...)
+	rows, err := s.q.Query(query.String(), args...)
 	if err != nil {
 		return nil, fmt.Errorf("query tasks: %w", err)
 	}
 	defer rows.Close()
 
 	var tasks []model.Task
+	groups := make(map[int64]int64) // task ID → rank by the keys before urgency
 	for rows.Next() {
 		var t model.Task
+		var rank int64
 		err := rows.Scan(
-			&t.ID, &t.ProjectID, &t.ParentID, &t.Title, &t.Description,
-			&t.Status, &t.Priority, &t.DueDate, &t.CreatedAt, &t.CompletedAt, &t.Position,
+			&t.ID, &t.UID, &t.ProjectID, &t.ParentID, &t.Title, &t.Description,
+			&t.Status, &t.Priority, &t.DueDate, &t.CreatedAt, &t.CompletedAt, &t.Position, &t.DeletedAt, &t.Blocked,
+			&t.SubtaskCount, &t.SubtasksDone, &rank,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("scan task row: %w", err)
@@ -143,32 +242,155 @@ func (s *SQLiteStore) ListTasks(filter TaskFilter) ([]model.Task, error) {
 		}
 		t.Tags = tags
 
+		groups[t.ID] = rank
 		tasks = append(tasks, t)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
+	if urgency >= 0 {
+		now := time.Now()
+		desc := filter.Sort[urgency].Desc
+		scores := make(map[int64]float64, len(tasks))
+		for _, t := range tasks {
+			scores[t.ID] = t.Urgency(s.urgency, now)
+		}
+		sort.SliceStable(tasks, func(i, j int) bool {
+			a, b := tasks[i], tasks[j]
+			if groups[a.ID] != groups[b.ID] {
+				return groups[a.ID] < groups[b.ID]
+			}
+			if desc {
+				return scores[a.ID] > scores[b.ID]
+			}
+			return scores[a.ID] < scores[b.ID]
+		})
+		if filter.Limit > 0 && len(tasks) > filter.Limit {
+			tasks = tasks[:filter.Limit]
+		}
+	}
 	return tasks, nil
 }
 
 func (s *SQLiteStore) UpdateTask(t *model.Task) error {
-	_, err := s.db.Exec(`
-		UPDATE tasks SET
-			project_id = ?, parent_id = ?, title = ?, description = ?,
-			status = ?, priority = ?, due_date = ?, completed_at = ?, position = ?
-		WHERE id = ?
-	`, t.ProjectID, t.ParentID, t.Title, t.Description,
-		t.Status, t.Priority, t.DueDate, t.CompletedAt, t.Position, t.ID)
+	return s.withTx(func(tx *SQLiteStore) error {
+		old, err := tx.GetTask(t.ID)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.q.Exec(`
+			UPDATE tasks SET
+				project_id = ?, parent_id = ?, title = ?, description = ?,
+				status = ?, priority = ?, due_date = ?, completed_at = ?, position = ?
+			WHERE id = ?
+		`, t.ProjectID, t.ParentID, t.Title, t.Description,
+			t.Status, t.Priority, t.DueDate, t.CompletedAt, t.Position, t.ID)
+		if err != nil {
+			return fmt.Errorf("update task: %w", err)
+		}
+		if err := tx.recordChanges(old, t); err != nil {
+			return err
+		}
+
+		if old.Status != model.StatusDone && t.Status == model.StatusDone {
+			if err := tx.stopTaskTimer(t.ID, time.Now()); err != nil {
+				return err
+			}
+		}
+		if formatEventTime(old.DueDate) != formatEventTime(t.DueDate) {
+			if err := tx.resetDueReminders(t.ID); err != nil {
+				return err
+			}
+			if err := tx.rescheduleRecurrence(t); err != nil {
+				return err
+			}
+		}
+
+		if tx.autoCompleteParents && t.ParentID != nil &&
+			old.Status != model.StatusDone && t.Status == model.StatusDone {
+			// A recurring subtask is about to be replaced by its next occurrence
+			rec, err := tx.GetRecurrence(t.ID)
+			if err != nil {
+				return err
+			}
+			if rec == nil {
+				return tx.completeParentIfDone(*t.ParentID)
+			}
+		}
+		return nil
+	})
+}
+
+// completeParentIfDone completes a task once all of its subtasks are done.
+// Completing it may in turn complete its own parent.
+func (s *SQLiteStore) completeParentIfDone(id int64) error {
+	parent, err := s.GetTask(id)
 	if err != nil {
-		return fmt.Errorf("update task: %w", err)
+		return err
 	}
-	return nil
+	if parent.Status == model.StatusDone || parent.SubtasksDone < parent.SubtaskCount {
+		return nil
+	}
+	return s.completeTaskWithRecurrence(id)
 }
 
+// DeleteTask moves a task and its subtasks to the trash
 func (s *SQLiteStore) DeleteTask(id int64) error {
-	_, err := s.db.Exec("DELETE FROM tasks WHERE id = ?", id)
+	return s.withTx(func(tx *SQLiteStore) error {
+		if _, err := tx.GetTask(id); err != nil {
+			return err
+		}
+
+		// Subtasks already in the trash keep their own deletion time, so
+		// they stay there when this task is restored
+		deleted, err := tx.subtree(id, "t.deleted_at IS NULL")
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		for _, t := range deleted {
+			if _, err := tx.q.Exec("UPDATE tasks SET deleted_at = ? WHERE id = ?", now, t.ID); err != nil {
+				return fmt.Errorf("delete task: %w", err)
+			}
+			if err := tx.stopTaskTimer(t.ID, now); err != nil {
+				return err
+			}
+			if err := tx.recordEvent(t.ID, model.EventDeleted, "", t.Title, ""); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// subtree returns a task and its descendants, following only subtasks (t)
+// that match cond
+func (s *SQLiteStore) subtree(id int64, cond string, args ...any) ([]model.Task, error) {
+	rows, err := s.q.Query(`
+		WITH RECURSIVE subtree(id) AS (
+			SELECT ?
+			UNION ALL
+			SELECT t.id FROM tasks t JOIN subtree ON t.parent_id = subtree.id
+			WHERE `+cond+`
+		)
+		SELECT t.id, t.title FROM tasks t JOIN subtree ON t.id = subtree.id
+	`, append([]any{id}, args...)...)
 	if err != nil {
-		return fmt.Errorf("delete task: %w", err)
+		return nil, fmt.Errorf("query subtasks: %w", err)
 	}
-	return nil
+	defer rows.Close()
+
+	var tasks []model.Task
+	for rows.Next() {
+		var t model.Task
+		if err := rows.Scan(&t.ID, &t.Title); err != nil {
+			return nil, fmt.Errorf("scan subtask row: %w", err)
+		}
+		tasks = append(tasks, t)
+	}
+	return tasks, rows.Err()
 }
 
 func (s *SQLiteStore) GetSubtasks(parentID int64) ([]model.Task, error) {
@@ -176,6 +398,12 @@ func (s *SQLiteStore) GetSubtasks(parentID int64) ([]model.Task, error) {
 }
 
 func (s *SQLiteStore) CompleteTaskWithRecurrence(taskID int64) error {
+	return s.withTx(func(tx *SQLiteStore) error {
+		return tx.completeTaskWithRecurrence(taskID)
+	})
+}
+
+func (s *SQLiteStore) completeTaskWithRecurrence(taskID int64) error {
 	task, err := s.GetTask(taskID)
 	if err != nil {
 		return err
@@ -191,35 +419,54 @@ func (s *SQLiteStore) CompleteTaskWithRecurrence(taskID int64) error {
 
 	// Check for recurrence
 	rec, err := s.GetRecurrence(taskID)
-	if err != nil || rec == nil {
+	if err != nil {
+		return err
+	}
+	if rec == nil {
 		return nil // No recurrence, done
 	}
 
-	// Create next occurrence
-	newTask := &model.Task{
-		ProjectID:   task.ProjectID,
-		ParentID:    task.ParentID,
-		Title:       task.Title,
-		Description: task.Description,
-		Status:      model.StatusTodo,
-		Priority:    task.Priority,
-		Position:    task.Position,
+	// Create the next occurrence, and any missed ones it catches up on
+	dues := rec.Advance(task.DueDate, now)
+	if len(dues) == 0 {
+		// The series ended with this occurrence
+		if err := s.DeleteRecurrence(taskID); err != nil {
+			return err
+		}
+		if s.autoCompleteParents && task.ParentID != nil {
+			return s.completeParentIfDone(*task.ParentID)
+		}
+		return nil
 	}
 
-	nextDue := rec.CalculateNextDue(now)
-	newTask.DueDate = &nextDue
+	var newTask *model.Task
+	for _, due := range dues {
+		newTask = &model.Task{
+			ProjectID:   task.ProjectID,
+			ParentID:    task.ParentID,
+			Title:       task.Title,
+			Description: task.Description,
+			Status:      model.StatusTodo,
+			Priority:    task.Priority,
+			Position:    task.Position,
+			DueDate:     &due,
+		}
+		if err := s.CreateTask(newTask); err != nil {
+			return err
+		}
 
-	if err := s.CreateTask(newTask); err != nil {
-		return err
+		// Copy tags
+		for _, tag := range task.Tags {
+			if err := s.AddTagToTask(newTask.ID, tag.ID); err != nil {
+				return err
+			}
+		}
 	}
 
-	// Copy tags
-	for _, tag := range task.Tags {
-		s.AddTagToTask(newTask.ID, tag.ID)
+	// Move recurrence to the latest task
+	if err := s.DeleteRecurrence(taskID); err != nil {
+		return err
 	}
-
-	// Update recurrence to point to new task
 	rec.TaskID = newTask.ID
-	rec.NextDue = nextDue
 	return s.SetRecurrence(rec)
 }
@@ -0,0 +1,203 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hwanchang/tsk/internal/model"
+)
+
+var ErrNoTimer = errors.New("no timer running")
+
+// StartTimer starts tracking time on a task, stopping any other running timer.
+// If the task's own timer is already running, that entry is returned.
+func (s *SQLiteStore) StartTimer(taskID int64) (*model.TimeEntry, error) {
+	var entry *model.TimeEntry
+	err := s.withTx(func(tx *SQLiteStore) error {
+		if _, err := tx.GetTask(taskID); err != nil {
+			return err
+		}
+
+		running, err := tx.RunningTimer()
+		if err != nil {
+			return err
+		}
+		if running != nil && running.TaskID == taskID {
+			entry = running
+			return nil
+		}
+
+		now := time.Now()
+		if running != nil {
+			if err := tx.stopTaskTimer(running.TaskID, now); err != nil {
+				return err
+			}
+		}
+
+		result, err := tx.q.Exec(`
+			INSERT INTO time_entries (task_id, started_at) VALUES (?, ?)
+		`, taskID, now)
+		if err != nil {
+			return fmt.Errorf("start timer: %w", err)
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("get time entry id: %w", err)
+		}
+		entry = &model.TimeEntry{ID: id, TaskID: taskID, StartedAt: now}
+		return nil
+	})
+	return entry, err
+}
+
+// StopTimer stops the running timer and returns the finished entry
+func (s *SQLiteStore) StopTimer() (*model.TimeEntry, error) {
+	var entry *model.TimeEntry
+	err := s.withTx(func(tx *SQLiteStore) error {
+		running, err := tx.RunningTimer()
+		if err != nil {
+			return err
+		}
+		if running == nil {
+			return ErrNoTimer
+		}
+
+		now := time.Now()
+		if err := tx.stopTaskTimer(running.TaskID, now); err != nil {
+			return err
+		}
+		running.EndedAt = &now
+		entry = running
+		return nil
+	})
+	return entry, err
+}
+
+// stopTaskTimer stops a task's timer if it's running
+func (s *SQLiteStore) stopTaskTimer(taskID int64, at time.Time) error {
+	_, err := s.q.Exec(`
+		UPDATE time_entries SET ended_at = ? WHERE task_id = ? AND ended_at IS NULL
+	`, at, taskID)
+	if err != nil {
+		return fmt.Errorf("stop timer: %w", err)
+	}
+	return nil
+}
+
+// RunningTimer returns the running timer, or nil if none is running
+func (s *SQLiteStore) RunningTimer() (*model.TimeEntry, error) {
+	var e model.TimeEntry
+	err := s.q.QueryRow(`
+		SELECT id, task_id, started_at, ended_at FROM time_entries
+		WHERE ended_at IS NULL
+		ORDER BY id DESC LIMIT 1
+	`).Scan(&e.ID, &e.TaskID, &e.StartedAt, &e.EndedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get running timer: %w", err)
+	}
+	return &e, nil
+}
+
+// ListTimeEntries returns a task's time entries, oldest first
+func (s *SQLiteStore) ListTimeEntries(taskID int64) ([]model.TimeEntry, error) {
+	rows, err := s.q.Query(`
+		SELECT id, task_id, started_at, ended_at FROM time_entries
+		WHERE task_id = ?
+		ORDER BY started_at, id
+	`, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("query time entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []model.TimeEntry
+	for rows.Next() {
+		var e model.TimeEntry
+		if err := rows.Scan(&e.ID, &e.TaskID, &e.StartedAt, &e.EndedAt); err != nil {
+			return nil, fmt.Errorf("scan time entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// TrackedTime returns the total time tracked on a task, including a running timer
+func (s *SQLiteStore) TrackedTime(taskID int64) (time.Duration, error) {
+	entries, err := s.ListTimeEntries(taskID)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	var total time.Duration
+	for _, e := range entries {
+		total += e.Duration(now)
+	}
+	return total, nil
+}
+
+// timeReportGroups are the SQL for each way of grouping a time report: the
+// group name and any joins it needs. Tasks with several tags count toward each.
+var timeReportGroups = map[string][2]string{
+	"project": {"COALESCE(p.name, '')", "LEFT JOIN projects p ON p.id = t.project_id"},
+	"tag": {"COALESCE(g.name, '')", `LEFT JOIN (
+		SELECT tt.task_id, g.name FROM task_tags tt
+		JOIN tags g ON g.id = tt.tag_id
+		WHERE g.deleted_at IS NULL
+	) g ON g.task_id = t.id`},
+	"task": {"'#' || t.id || ' ' || t.title", ""},
+}
+
+// TimeReport totals the time tracked between since and until, grouped by
+// "project", "tag", or "task", largest first. Entries are clipped to the range.
+// Tasks without a project or tag are grouped under an empty name.
+func (s *SQLiteStore) TimeReport(since, until time.Time, by string) ([]model.TimeTotal, error) {
+	group, ok := timeReportGroups[by]
+	if !ok {
+		return nil, fmt.Errorf("unknown report grouping: %s", by)
+	}
+
+	rows, err := s.q.Query(`
+		SELECT e.started_at, e.ended_at, ` + group[0] + `
+		FROM time_entries e
+		JOIN tasks t ON t.id = e.task_id
+		` + group[1])
+	if err != nil {
+		return nil, fmt.Errorf("query time report: %w", err)
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	totals := map[string]time.Duration{}
+	for rows.Next() {
+		var e model.TimeEntry
+		var name string
+		if err := rows.Scan(&e.StartedAt, &e.EndedAt, &name); err != nil {
+			return nil, fmt.Errorf("scan time entry: %w", err)
+		}
+		if d := e.Within(since, until, now); d > 0 {
+			totals[name] += d
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var report []model.TimeTotal
+	for name, d := range totals {
+		report = append(report, model.TimeTotal{Name: name, Duration: d})
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].Duration != report[j].Duration {
+			return report[i].Duration > report[j].Duration
+		}
+		return report[i].Name < report[j].Name
+	})
+	return report, nil
+}
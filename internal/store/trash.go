@@ -0,0 +1,225 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hwanchang/tsk/internal/model"
+)
+
+// ErrInTrash is returned when creating a project, tag, or task whose name
+// or UID a trashed one still holds
+var ErrInTrash = errors.New("in the trash")
+
+// trashedID returns the ID of the trashed row of table whose column holds
+// value, or 0 if there is none
+func (s *SQLiteStore) trashedID(table, column string, value any) (int64, error) {
+	var id int64
+	err := s.q.QueryRow("SELECT id FROM "+table+" WHERE "+column+" = ? AND deleted_at IS NOT NULL", value).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("check trash: %w", err)
+	}
+	return id, nil
+}
+
+// RestoreTask takes a task out of the trash, along with the subtasks that
+// were trashed with it
+func (s *SQLiteStore) RestoreTask(id int64) error {
+	return s.withTx(func(tx *SQLiteStore) error {
+		var parentID *int64
+		var deletedAt *time.Time
+		err := tx.q.QueryRow("SELECT parent_id, deleted_at FROM tasks WHERE id = ?", id).Scan(&parentID, &deletedAt)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("task not found: %d", id)
+		}
+		if err != nil {
+			return fmt.Errorf("scan task: %w", err)
+		}
+		if deletedAt == nil {
+			return fmt.Errorf("task #%d is not in the trash", id)
+		}
+
+		if parentID != nil {
+			var parentDeletedAt *time.Time
+			err := tx.q.QueryRow("SELECT deleted_at FROM tasks WHERE id = ?", *parentID).Scan(&parentDeletedAt)
+			if err != nil {
+				return fmt.Errorf("scan parent task: %w", err)
+			}
+			if parentDeletedAt != nil {
+				return fmt.Errorf("parent task #%d is also in the trash; restore it instead", *parentID)
+			}
+		}
+
+		restored, err := tx.subtree(id, "t.deleted_at = (SELECT deleted_at FROM tasks WHERE id = ?)", id)
+		if err != nil {
+			return err
+		}
+		for _, t := range restored {
+			if _, err := tx.q.Exec("UPDATE tasks SET deleted_at = NULL WHERE id = ?", t.ID); err != nil {
+				return fmt.Errorf("restore task: %w", err)
+			}
+			if err := tx.recordEvent(t.ID, model.EventRestored, "", "", t.Title); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// RestoreProject takes a project out of the trash. Tasks moved to Inbox when
+// it was deleted stay there.
+func (s *SQLiteStore) RestoreProject(id int64) error {
+	return s.withTx(func(tx *SQLiteStore) error {
+		result, err := tx.q.Exec("UPDATE projects SET deleted_at = NULL WHERE id = ? AND deleted_at IS NOT NULL", id)
+		if err != nil {
+			return fmt.Errorf("restore project: %w", err)
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			return fmt.Errorf("project not in trash: %d", id)
+		}
+		return nil
+	})
+}
+
+// RestoreTag takes a tag out of the trash, re-attaching it to its tasks
+func (s *SQLiteStore) RestoreTag(id int64) error {
+	return s.withTx(func(tx *SQLiteStore) error {
+		result, err := tx.q.Exec("UPDATE tags SET deleted_at = NULL WHERE id = ? AND deleted_at IS NOT NULL", id)
+		if err != nil {
+			return fmt.Errorf("restore tag: %w", err)
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			return fmt.Errorf("tag not in trash: %d", id)
+		}
+
+		tag, err := tx.GetTag(id)
+		if err != nil {
+			return err
+		}
+		taskIDs, err := tx.taggedTaskIDs(id)
+		if err != nil {
+			return err
+		}
+		for _, taskID := range taskIDs {
+			if err := tx.recordEvent(taskID, model.EventTagAdded, "tag", "", tag.Name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *SQLiteStore) ListTrashedProjects() ([]model.Project, error) {
+	rows, err := s.q.Query(`
+		SELECT id, name, description, created_at, deleted_at
+		FROM projects WHERE deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query trashed projects: %w", err)
+	}
+	defer rows.Close()
+
+	var projects []model.Project
+	for rows.Next() {
+		var p model.Project
+		if err := rows.Scan(&p.ID, &p.Name, &p.Description, &p.CreatedAt, &p.DeletedAt); err != nil {
+			return nil, fmt.Errorf("scan project row: %w", err)
+		}
+		projects = append(projects, p)
+	}
+	return projects, rows.Err()
+}
+
+func (s *SQLiteStore) ListTrashedTags() ([]model.Tag, error) {
+	rows, err := s.q.Query(`
+		SELECT id, name, color, deleted_at
+		FROM tags WHERE deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query trashed tags: %w", err)
+	}
+	defer rows.Close()
+
+	var tags []model.Tag
+	for rows.Next() {
+		var t model.Tag
+		if err := rows.Scan(&t.ID, &t.Name, &t.Color, &t.DeletedAt); err != nil {
+			return nil, fmt.Errorf("scan tag row: %w", err)
+		}
+		tags = append(tags, t)
+	}
+	return tags, rows.Err()
+}
+
+// PurgeTrash permanently deletes tasks, projects, and tags that were moved to
+// the trash before the given time, and returns how many were deleted.
+// A subtree is purged by its root's trash time: the subtasks of a purged
+// task go with it, even if they were trashed later.
+func (s *SQLiteStore) PurgeTrash(before time.Time) (int, error) {
+	purged := 0
+	err := s.withTx(func(tx *SQLiteStore) error {
+		// Subtasks are removed via ON DELETE CASCADE, task_tags via the
+		// task or tag, and recurrences via the task
+		for _, table := range []string{"tasks", "tags", "projects"} {
+			ids, err := tx.trashedBefore(table, before)
+			if err != nil {
+				return err
+			}
+			for _, id := range ids {
+				// SQLite doesn't count cascaded deletes, so count the
+				// subtree first. A subtask already purged with its
+				// parent has none.
+				n := 1
+				if table == "tasks" {
+					subtree, err := tx.subtree(id, "1")
+					if err != nil {
+						return err
+					}
+					n = len(subtree)
+				}
+				res, err := tx.q.Exec("DELETE FROM "+table+" WHERE id = ?", id)
+				if err != nil {
+					return fmt.Errorf("purge %s: %w", table, err)
+				}
+				affected, err := res.RowsAffected()
+				if err != nil {
+					return fmt.Errorf("purge %s: %w", table, err)
+				}
+				if affected > 0 {
+					purged += n
+				}
+			}
+		}
+		return nil
+	})
+	return purged, err
+}
+
+// trashedBefore returns the ids of rows in table that were trashed before the given time
+func (s *SQLiteStore) trashedBefore(table string, before time.Time) ([]int64, error) {
+	rows, err := s.q.Query("SELECT id, deleted_at FROM " + table + " WHERE deleted_at IS NOT NULL")
+	if err != nil {
+		return nil, fmt.Errorf("query trashed %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		var deletedAt time.Time
+		if err := rows.Scan(&id, &deletedAt); err != nil {
+			return nil, fmt.Errorf("scan trashed row: %w", err)
+		}
+		if deletedAt.Before(before) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, rows.Err()
+}
@@ -0,0 +1,458 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/hwanchang/tsk/internal/db"
+	"github.com/hwanchang/tsk/internal/model"
+)
+
+// maxUndoGroups is how many operations are kept in the undo history
+const maxUndoGroups = 200
+
+var (
+	ErrNothingToUndo = errors.New("nothing to undo")
+	ErrNothingToRedo = errors.New("nothing to redo")
+)
+
+// rowChange is one journaled row change. Rows are keyed by column name.
+type rowChange struct {
+	table  string
+	op     string
+	oldRow map[string]any
+	newRow map[string]any
+}
+
+// beginUndoGroup starts recording row changes made in the current transaction
+func (s *SQLiteStore) beginUndoGroup() (int64, error) {
+	result, err := s.q.Exec("INSERT INTO undo_groups (created_at) VALUES (?)", time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("insert undo group: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("get last insert id: %w", err)
+	}
+	if _, err := s.q.Exec("UPDATE undo_state SET recording = ? WHERE id = 1", id); err != nil {
+		return 0, fmt.Errorf("start undo recording: %w", err)
+	}
+	return id, nil
+}
+
+// endUndoGroup stops recording. Groups without changes are dropped; otherwise
+// the redo history is discarded and old groups are trimmed.
+func (s *SQLiteStore) endUndoGroup(id int64) error {
+	if _, err := s.q.Exec("UPDATE undo_state SET recording = NULL WHERE id = 1"); err != nil {
+		return fmt.Errorf("stop undo recording: %w", err)
+	}
+
+	// Updates that didn't change anything, e.g. saving an unmodified task
+	_, err := s.q.Exec("DELETE FROM undo_changes WHERE group_id = ? AND op = 'update' AND old_row = new_row", id)
+	if err != nil {
+		return fmt.Errorf("drop no-op undo changes: %w", err)
+	}
+
+	var count int
+	if err := s.q.QueryRow("SELECT COUNT(*) FROM undo_changes WHERE group_id = ?", id).Scan(&count); err != nil {
+		return fmt.Errorf("count undo changes: %w", err)
+	}
+	if count == 0 {
+		if _, err := s.q.Exec("DELETE FROM undo_groups WHERE id = ?", id); err != nil {
+			return fmt.Errorf("delete undo group: %w", err)
+		}
+		return nil
+	}
+
+	if _, err := s.q.Exec("DELETE FROM undo_groups WHERE undone = 1"); err != nil {
+		return fmt.Errorf("clear redo history: %w", err)
+	}
+	_, err = s.q.Exec(`
+		DELETE FROM undo_groups WHERE id NOT IN (
+			SELECT id FROM undo_groups ORDER BY id DESC LIMIT ?
+		)
+	`, maxUndoGroups)
+	if err != nil {
+		return fmt.Errorf("trim undo history: %w", err)
+	}
+	return nil
+}
+
+// Undo reverts the most recent operation and returns a short description of it
+func (s *SQLiteStore) Undo() (string, error) {
+	var label string
+	err := s.transact(false, func(tx *SQLiteStore) error {
+		var id int64
+		err := tx.q.QueryRow("SELECT id FROM undo_groups WHERE undone = 0 ORDER BY id DESC LIMIT 1").Scan(&id)
+		if err == sql.ErrNoRows {
+			return ErrNothingToUndo
+		}
+		if err != nil {
+			return fmt.Errorf("get undo group: %w", err)
+		}
+
+		changes, err := tx.undoChanges(id)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.q.Exec("PRAGMA defer_foreign_keys = ON"); err != nil {
+			return fmt.Errorf("defer foreign keys: %w", err)
+		}
+		for i := len(changes) - 1; i >= 0; i-- {
+			if err := tx.revertChange(changes[i]); err != nil {
+				return err
+			}
+		}
+
+		if _, err := tx.q.Exec("UPDATE undo_groups SET undone = 1 WHERE id = ?", id); err != nil {
+			return fmt.Errorf("mark undo group: %w", err)
+		}
+		label = describeChanges(changes)
+		return tx.recordUndoEvents(changes, model.EventUndone, label)
+	})
+	return label, err
+}
+
+// Redo re-applies the most recently undone operation and returns a short
+// description of it
+func (s *SQLiteStore) Redo() (string, error) {
+	var label string
+	err := s.transact(false, func(tx *SQLiteStore) error {
+		var id int64
+		err := tx.q.QueryRow("SELECT id FROM undo_groups WHERE undone = 1 ORDER BY id LIMIT 1").Scan(&id)
+		if err == sql.ErrNoRows {
+			return ErrNothingToRedo
+		}
+		if err != nil {
+			return fmt.Errorf("get redo group: %w", err)
+		}
+
+		changes, err := tx.undoChanges(id)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.q.Exec("PRAGMA defer_foreign_keys = ON"); err != nil {
+			return fmt.Errorf("defer foreign keys: %w", err)
+		}
+		for _, c := range changes {
+			if err := tx.applyChange(c); err != nil {
+				return err
+			}
+		}
+
+		if _, err := tx.q.Exec("UPDATE undo_groups SET undone = 0 WHERE id = ?", id); err != nil {
+			return fmt.Errorf("mark redo group: %w", err)
+		}
+		label = describeChanges(changes)
+		return tx.recordUndoEvents(changes, model.EventRedone, label)
+	})
+	return label, err
+}
+
+// recordUndoEvents adds an undone or redone event to the history of every
+// task the changes touched. The history itself isn't journaled, so it keeps
+// the events that were undone.
+func (s *SQLiteStore) recordUndoEvents(changes []rowChange, kind model.EventKind, label string) error {
+	var taskIDs []int64
+	for _, c := range changes {
+		key := "task_id"
+		if c.table == "tasks" {
+			key = "id"
+		}
+		if id, ok := c.row()[key].(int64); ok && !slices.Contains(taskIDs, id) {
+			taskIDs = append(taskIDs, id)
+		}
+	}
+	for _, id := range taskIDs {
+		if err := s.recordEvent(id, kind, "", "", label); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *SQLiteStore) undoChanges(groupID int64) ([]rowChange, error) {
+	rows, err := s.q.Query(`
+		SELECT tbl, op, old_row, new_row FROM undo_changes
+		WHERE group_id = ? ORDER BY id
+	`, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("query undo changes: %w", err)
+	}
+	defer rows.Close()
+
+	var changes []rowChange
+	for rows.Next() {
+		var c rowChange
+		var oldRow, newRow sql.NullString
+		if err := rows.Scan(&c.table, &c.op, &oldRow, &newRow); err != nil {
+			return nil, fmt.Errorf("scan undo change: %w", err)
+		}
+		if !slices.Contains(db.JournalTables, c.table) {
+			return nil, fmt.Errorf("undo change for unknown table %q", c.table)
+		}
+		if c.oldRow, err = decodeRow(oldRow); err != nil {
+			return nil, err
+		}
+		if c.newRow, err = decodeRow(newRow); err != nil {
+			return nil, err
+		}
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
+
+func decodeRow(data sql.NullString) (map[string]any, error) {
+	if !data.Valid {
+		return nil, nil
+	}
+
+	dec := json.NewDecoder(strings.NewReader(data.String))
+	dec.UseNumber()
+	var raw map[string]any
+	if err := dec.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("decode undo row: %w", err)
+	}
+
+	row := make(map[string]any, len(raw))
+	for k, v := range raw {
+		if n, ok := v.(json.Number); ok {
+			if i, err := n.Int64(); err == nil {
+				v = i
+			} else if f, err := n.Float64(); err == nil {
+				v = f
+			}
+		}
+		row[k] = v
+	}
+	return row, nil
+}
+
+// revertChange undoes a single row change
+func (s *SQLiteStore) revertChange(c rowChange) error {
+	switch c.op {
+	case "insert":
+		return s.deleteRow(c.table, c.newRow)
+	case "update":
+		return s.updateRow(c.table, c.oldRow)
+	default:
+		return s.insertRow(c.table, c.oldRow)
+	}
+}
+
+// applyChange re-applies a single row change
+func (s *SQLiteStore) applyChange(c rowChange) error {
+	switch c.op {
+	case "insert":
+		return s.insertRow(c.table, c.newRow)
+	case "update":
+		return s.updateRow(c.table, c.newRow)
+	default:
+		return s.deleteRow(c.table, c.oldRow)
+	}
+}
+
+func (s *SQLiteStore) insertRow(table string, row map[string]any) error {
+	columns, err := s.rowColumns(table, row)
+	if err != nil {
+		return err
+	}
+
+	args := make([]any, len(columns))
+	for i, c := range columns {
+		args[i] = row[c]
+	}
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		table, strings.Join(columns, ", "), placeholders(len(columns)))
+	if _, err := s.q.Exec(query, args...); err != nil {
+		return fmt.Errorf("restore %s row: %w", table, err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) updateRow(table string, row map[string]any) error {
+	columns, err := s.rowColumns(table, row)
+	if err != nil {
+		return err
+	}
+	keys, err := s.primaryKey(table)
+	if err != nil {
+		return err
+	}
+
+	var sets []string
+	var args []any
+	for _, c := range columns {
+		sets = append(sets, c+" = ?")
+		args = append(args, row[c])
+	}
+	where, keyArgs := keyCondition(keys, row)
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE %s", table, strings.Join(sets, ", "), where)
+	if _, err := s.q.Exec(query, append(args, keyArgs...)...); err != nil {
+		return fmt.Errorf("restore %s row: %w", table, err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) deleteRow(table string, row map[string]any) error {
+	keys, err := s.primaryKey(table)
+	if err != nil {
+		return err
+	}
+
+	where, args := keyCondition(keys, row)
+	if _, err := s.q.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s", table, where), args...); err != nil {
+		return fmt.Errorf("remove %s row: %w", table, err)
+	}
+	return nil
+}
+
+// rowColumns returns the columns of row that still exist in table, so that
+// rows journaled before a schema change can be restored
+func (s *SQLiteStore) rowColumns(table string, row map[string]any) ([]string, error) {
+	all, err := s.db.Columns(table)
+	if err != nil {
+		return nil, err
+	}
+
+	var columns []string
+	for _, c := range all {
+		if _, ok := row[c]; ok {
+			columns = append(columns, c)
+		}
+	}
+	return columns, nil
+}
+
+func (s *SQLiteStore) primaryKey(table string) ([]string, error) {
+	rows, err := s.q.Query("SELECT name FROM pragma_table_info(?) WHERE pk > 0 ORDER BY pk", table)
+	if err != nil {
+		return nil, fmt.Errorf("read primary key of %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("scan column: %w", err)
+		}
+		keys = append(keys, name)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("table %s has no primary key", table)
+	}
+	return keys, rows.Err()
+}
+
+func keyCondition(keys []string, row map[string]any) (string, []any) {
+	conds := make([]string, len(keys))
+	args := make([]any, len(keys))
+	for i, k := range keys {
+		conds[i] = k + " = ?"
+		args[i] = row[k]
+	}
+	return strings.Join(conds, " AND "), args
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// describeChanges summarizes a group by its most significant change, e.g. a
+// deleted project rather than the tasks moved out of it, or a deleted task
+// rather than its subtasks
+func describeChanges(changes []rowChange) string {
+	taskIDs := map[any]bool{}
+	for _, c := range changes {
+		if c.table == "tasks" {
+			taskIDs[c.row()["id"]] = true
+		}
+	}
+
+	for _, table := range []string{"projects", "tasks", "tags", "recurrences", "task_tags", "task_dependencies", "time_entries", "reminders", "views"} {
+		var found *rowChange
+		for i, c := range changes {
+			if c.table != table {
+				continue
+			}
+			if table == "tasks" && taskIDs[c.row()["parent_id"]] {
+				continue
+			}
+			// Prefer e.g. a created tag over the trashed one it replaced, and
+			// a started timer over the one it stopped
+			if found == nil || found.op == "delete" && c.op != "delete" ||
+				table == "time_entries" && c.op == "insert" {
+				found = &changes[i]
+			}
+		}
+		if found != nil {
+			return describeChange(*found)
+		}
+	}
+	return "change"
+}
+
+// row returns the row as it was after the change, or before it for deletes
+func (c rowChange) row() map[string]any {
+	if c.op == "delete" {
+		return c.oldRow
+	}
+	return c.newRow
+}
+
+func describeChange(c rowChange) string {
+	row := c.row()
+	verb := map[string]string{"insert": "create", "update": "edit", "delete": "purge"}[c.op]
+	if c.op == "update" {
+		switch {
+		case c.oldRow["deleted_at"] == nil && c.newRow["deleted_at"] != nil:
+			verb = "delete"
+		case c.oldRow["deleted_at"] != nil && c.newRow["deleted_at"] == nil:
+			verb = "restore"
+		}
+	}
+	switch c.table {
+	case "projects":
+		return fmt.Sprintf("%s project %q", verb, row["name"])
+	case "tags":
+		return fmt.Sprintf("%s tag %q", verb, row["name"])
+	case "tasks":
+		if verb == "edit" && c.oldRow["status"] != c.newRow["status"] {
+			return fmt.Sprintf("mark task %q %s", row["title"], row["status"])
+		}
+		return fmt.Sprintf("%s task %q", verb, row["title"])
+	case "recurrences":
+		verb = map[string]string{"insert": "set", "update": "change", "delete": "remove"}[c.op]
+		return fmt.Sprintf("%s recurrence of task #%v", verb, row["task_id"])
+	case "task_dependencies":
+		if c.op == "insert" {
+			return fmt.Sprintf("block task #%v by #%v", row["task_id"], row["blocked_by_id"])
+		}
+		return fmt.Sprintf("unblock task #%v from #%v", row["task_id"], row["blocked_by_id"])
+	case "time_entries":
+		switch {
+		case c.op == "insert":
+			return fmt.Sprintf("start timer on task #%v", row["task_id"])
+		case c.op == "update" && c.oldRow["ended_at"] == nil && c.newRow["ended_at"] != nil:
+			return fmt.Sprintf("stop timer on task #%v", row["task_id"])
+		}
+		return fmt.Sprintf("%s time entry on task #%v", verb, row["task_id"])
+	case "reminders":
+		verb = map[string]string{"insert": "add", "update": "change", "delete": "remove"}[c.op]
+		return fmt.Sprintf("%s reminder on task #%v", verb, row["task_id"])
+	case "views":
+		verb = map[string]string{"insert": "save", "update": "change", "delete": "remove"}[c.op]
+		return fmt.Sprintf("%s view @%v", verb, row["name"])
+	default:
+		if c.op == "insert" {
+			return fmt.Sprintf("tag task #%v", row["task_id"])
+		}
+		return fmt.Sprintf("untag task #%v", row["task_id"])
+	}
+}
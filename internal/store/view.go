@@ -0,0 +1,83 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/hwanchang/tsk/internal/model"
+)
+
+// SaveView creates a view, or replaces the query of the view with its name
+func (s *SQLiteStore) SaveView(v *model.View) error {
+	if err := model.ValidateViewName(v.Name); err != nil {
+		return err
+	}
+	return s.withTx(func(tx *SQLiteStore) error {
+		existing, err := tx.GetView(v.Name)
+		if err == nil {
+			if _, err := tx.q.Exec("UPDATE views SET query = ? WHERE id = ?", v.Query, existing.ID); err != nil {
+				return fmt.Errorf("update view: %w", err)
+			}
+			v.ID, v.Name, v.Position = existing.ID, existing.Name, existing.Position
+			return nil
+		}
+
+		result, err := tx.q.Exec(`
+			INSERT INTO views (name, query, position)
+			VALUES (?, ?, (SELECT COALESCE(MAX(position), 0) + 1 FROM views))
+		`, v.Name, v.Query)
+		if err != nil {
+			return fmt.Errorf("insert view: %w", err)
+		}
+		v.ID, err = result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("get view id: %w", err)
+		}
+		return tx.q.QueryRow("SELECT position FROM views WHERE id = ?", v.ID).Scan(&v.Position)
+	})
+}
+
+// GetView returns the view with a name, ignoring case
+func (s *SQLiteStore) GetView(name string) (*model.View, error) {
+	v := &model.View{}
+	err := s.q.QueryRow("SELECT id, name, query, position FROM views WHERE name = ?", name).
+		Scan(&v.ID, &v.Name, &v.Query, &v.Position)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("view not found: %s", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("scan view: %w", err)
+	}
+	return v, nil
+}
+
+func (s *SQLiteStore) ListViews() ([]model.View, error) {
+	rows, err := s.q.Query("SELECT id, name, query, position FROM views ORDER BY position, id")
+	if err != nil {
+		return nil, fmt.Errorf("query views: %w", err)
+	}
+	defer rows.Close()
+
+	var views []model.View
+	for rows.Next() {
+		var v model.View
+		if err := rows.Scan(&v.ID, &v.Name, &v.Query, &v.Position); err != nil {
+			return nil, fmt.Errorf("scan view row: %w", err)
+		}
+		views = append(views, v)
+	}
+	return views, rows.Err()
+}
+
+func (s *SQLiteStore) DeleteView(name string) error {
+	return s.withTx(func(tx *SQLiteStore) error {
+		result, err := tx.q.Exec("DELETE FROM views WHERE name = ?", name)
+		if err != nil {
+			return fmt.Errorf("delete view: %w", err)
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			return fmt.Errorf("view not found: %s", name)
+		}
+		return nil
+	})
+}
@@ -58,6 +58,9 @@ var (
 		Foreground(MutedDark).
 		Strikethrough(true)
 
+	TaskTitleBlocked = lipgloss.NewStyle().
+		Foreground(MutedDark)
+
 	TaskMeta = lipgloss.NewStyle().
 		Foreground(Muted).
 		MarginLeft(2)
@@ -202,6 +205,12 @@ var (
 		Background(Accent).
 		Padding(0, 1)
 
+	// Search matches in task titles
+	SearchMatch = lipgloss.NewStyle().
+		Foreground(Accent).
+		Bold(true).
+		Underline(true)
+
 	// Overlay styles
 	Overlay = lipgloss.NewStyle().
 		Border(lipgloss.RoundedBorder()).
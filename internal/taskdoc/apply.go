@@ -0,0 +1,211 @@
+package taskdoc
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/hwanchang/tsk/internal/model"
+	"github.com/hwanchang/tsk/internal/quickadd"
+	"github.com/hwanchang/tsk/internal/store"
+)
+
+// Load reads a task and its related data from the store as a document
+func Load(st store.Store, taskID int64) (*Doc, error) {
+	task, err := st.GetTask(taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	project := ""
+	if task.ProjectID != nil {
+		if p, err := st.GetProject(*task.ProjectID); err == nil {
+			project = p.Name
+		}
+	}
+
+	rec, err := st.GetRecurrence(taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	subtasks, err := st.GetSubtasks(taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	return New(task, project, rec, subtasks), nil
+}
+
+// Apply writes the changes in an edited document to the store in a single
+// transaction. Problems with the document's contents are reported as *ParseError.
+func Apply(st store.Store, d *Doc) error {
+	return st.WithTx(func(tx store.Store) error {
+		return apply(tx, d)
+	})
+}
+
+func apply(st store.Store, d *Doc) error {
+	task, err := st.GetTask(d.TaskID)
+	if err != nil {
+		return err
+	}
+
+	// Resolve references before writing anything
+	var projectID *int64
+	if d.Project != "" {
+		project, err := st.GetProjectByName(d.Project)
+		if errors.Is(err, store.ErrProjectNotFound) || errors.Is(err, store.ErrInTrash) {
+			return &ParseError{Msg: err.Error()}
+		}
+		if err != nil {
+			return err
+		}
+		projectID = &project.ID
+	}
+
+	subtasks, err := st.GetSubtasks(task.ID)
+	if err != nil {
+		return err
+	}
+	existing := make(map[int64]model.Task, len(subtasks))
+	for _, s := range subtasks {
+		existing[s.ID] = s
+	}
+	listed := make(map[int64]bool, len(d.Subtasks))
+	for _, s := range d.Subtasks {
+		if _, ok := existing[s.ID]; s.ID > 0 && !ok {
+			return &ParseError{Msg: fmt.Sprintf("#%d is not a subtask of this task", s.ID)}
+		}
+		listed[s.ID] = true
+	}
+	// Subtasks are only trashed when marked, so a line deleted by mistake
+	// doesn't take one with it
+	for _, s := range subtasks {
+		if !listed[s.ID] {
+			return &ParseError{Msg: fmt.Sprintf("subtask #%d %q is missing; mark it \"- [-]\" to move it to the trash", s.ID, s.Title)}
+		}
+	}
+
+	// Fields
+	complete := d.Status == model.StatusDone && task.Status != model.StatusDone
+	task.Title = d.Title
+	task.Description = d.Description
+	task.Priority = d.Priority
+	task.DueDate = d.Due
+	task.ProjectID = projectID
+	if d.Status != task.Status && !complete {
+		switch d.Status {
+		case model.StatusTodo:
+			task.MarkTodo()
+		case model.StatusDoing:
+			task.MarkDoing()
+		}
+	}
+	if err := st.UpdateTask(task); err != nil {
+		return err
+	}
+
+	// Tags
+	wanted := make(map[string]bool, len(d.Tags))
+	for _, name := range d.Tags {
+		wanted[name] = true
+	}
+	for _, tag := range task.Tags {
+		if wanted[tag.Name] {
+			delete(wanted, tag.Name)
+			continue
+		}
+		if err := st.RemoveTagFromTask(task.ID, tag.ID); err != nil {
+			return err
+		}
+	}
+	for _, name := range d.Tags {
+		if !wanted[name] {
+			continue
+		}
+		tag, err := st.GetOrCreateTag(name)
+		if err != nil {
+			return err
+		}
+		if err := st.AddTagToTask(task.ID, tag.ID); err != nil {
+			return err
+		}
+	}
+
+	// Recurrence
+	rec, err := st.GetRecurrence(task.ID)
+	if err != nil {
+		return err
+	}
+	current := ""
+	if rec != nil {
+		current = FormatRepeat(rec.Rule)
+	}
+	if d.Repeat != current {
+		if d.Repeat == "" {
+			if err := st.DeleteRecurrence(task.ID); err != nil {
+				return err
+			}
+			rec = nil
+		} else {
+			rule, err := quickadd.ParseRepeat(d.Repeat)
+			if err != nil {
+				return &ParseError{Msg: err.Error()}
+			}
+			rec = model.NewRecurrence(task.ID, rule)
+			rec.Schedule(task.DueDate)
+			if err := st.SetRecurrence(rec); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Subtasks: update listed ones, create new ones, trash marked ones
+	for _, s := range d.Subtasks {
+		if s.Trash {
+			if s.ID > 0 {
+				if err := st.DeleteTask(s.ID); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if s.ID == 0 {
+			sub := model.NewTask(s.Title)
+			sub.ParentID = &task.ID
+			sub.ProjectID = task.ProjectID
+			if s.Done {
+				sub.MarkDone()
+			}
+			if err := st.CreateTask(sub); err != nil {
+				return err
+			}
+			continue
+		}
+
+		sub := existing[s.ID]
+		wasDone := sub.Status == model.StatusDone
+		if sub.Title == s.Title && wasDone == s.Done {
+			continue
+		}
+		sub.Title = s.Title
+		if s.Done && !wasDone {
+			sub.MarkDone()
+		} else if !s.Done && wasDone {
+			sub.MarkTodo()
+		}
+		if err := st.UpdateTask(&sub); err != nil {
+			return err
+		}
+	}
+	// Complete last so a recurring task's next occurrence picks up the edits
+	if complete {
+		if rec != nil {
+			return st.CompleteTaskWithRecurrence(task.ID)
+		}
+		task.MarkDone()
+		return st.UpdateTask(task)
+	}
+
+	return nil
+}
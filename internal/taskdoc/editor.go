@@ -0,0 +1,38 @@
+package taskdoc
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// EditorCmd returns a command that opens path in the user's editor
+// ($VISUAL, then $EDITOR, falling back to vi)
+func EditorCmd(path string) *exec.Cmd {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	// Allow editors with arguments, e.g. "code --wait"
+	args := strings.Fields(editor)
+	return exec.Command(args[0], append(args[1:], path)...)
+}
+
+// WriteTemp writes content to a new temporary Markdown file and returns its path
+func WriteTemp(content []byte) (string, error) {
+	f, err := os.CreateTemp("", "tsk-*.md")
+	if err != nil {
+		return "", fmt.Errorf("create temp file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(content); err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("write temp file: %w", err)
+	}
+	return f.Name(), nil
+}
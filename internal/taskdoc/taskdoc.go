@@ -0,0 +1,332 @@
+// Package taskdoc converts a task to and from an editable text document:
+// a front-matter header followed by a Markdown description and subtask list.
+package taskdoc
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hwanchang/tsk/internal/model"
+	"github.com/hwanchang/tsk/internal/quickadd"
+)
+
+const subtasksHeading = "## Subtasks"
+
+// Subtask is a single checklist entry in the document
+type Subtask struct {
+	ID    int64 // 0 for subtasks added in the editor
+	Title string
+	Done  bool
+	Trash bool // marked "- [-]" to move it to the trash
+}
+
+// Doc is the editable representation of a task
+type Doc struct {
+	TaskID      int64
+	Title       string
+	Status      model.Status
+	Priority    model.Priority
+	Due         *time.Time
+	Project     string
+	Tags        []string
+	Repeat      string // same syntax as --repeat, e.g. "weekly", "daily:2", or an RRULE
+	Description string
+	Subtasks    []Subtask
+}
+
+// ParseError describes a problem in an edited document
+type ParseError struct {
+	Line int // 0 if not tied to a line
+	Msg  string
+}
+
+func (e *ParseError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+	}
+	return e.Msg
+}
+
+// New builds a document from a task and its related data
+func New(t *model.Task, project string, rec *model.Recurrence, subtasks []model.Task) *Doc {
+	d := &Doc{
+		TaskID:      t.ID,
+		Title:       t.Title,
+		Status:      t.Status,
+		Priority:    t.Priority,
+		Due:         t.DueDate,
+		Project:     project,
+		Description: t.Description,
+	}
+	for _, tag := range t.Tags {
+		d.Tags = append(d.Tags, tag.Name)
+	}
+	if rec != nil {
+		d.Repeat = FormatRepeat(rec.Rule)
+	}
+	for _, s := range subtasks {
+		d.Subtasks = append(d.Subtasks, Subtask{
+			ID:    s.ID,
+			Title: s.Title,
+			Done:  s.Status == model.StatusDone,
+		})
+	}
+	return d
+}
+
+// Marshal renders the document as text
+func (d *Doc) Marshal() []byte {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# Editing task #%d. Save and quit to apply, or clear the file to cancel.\n", d.TaskID)
+	b.WriteString("# status: todo/doing/done  priority: none/low/medium/high  due: YYYY-MM-DD [HH:MM]\n")
+	b.WriteString("# Mark a subtask \"- [-]\" to move it to the trash.\n")
+	b.WriteString("---\n")
+	writeField(&b, "title", d.Title)
+	writeField(&b, "status", string(d.Status))
+	writeField(&b, "priority", formatPriority(d.Priority))
+	writeField(&b, "due", formatDue(d.Due))
+	writeField(&b, "project", d.Project)
+	writeField(&b, "tags", strings.Join(d.Tags, ", "))
+	writeField(&b, "repeat", d.Repeat)
+	b.WriteString("---\n\n")
+
+	if d.Description != "" {
+		b.WriteString(strings.TrimSpace(d.Description))
+		b.WriteString("\n\n")
+	}
+
+	b.WriteString(subtasksHeading + "\n\n")
+	for _, s := range d.Subtasks {
+		check := " "
+		if s.Done {
+			check = "x"
+		}
+		if s.ID > 0 {
+			fmt.Fprintf(&b, "- [%s] #%d %s\n", check, s.ID, s.Title)
+		} else {
+			fmt.Fprintf(&b, "- [%s] %s\n", check, s.Title)
+		}
+	}
+
+	return []byte(b.String())
+}
+
+// Parse reads an edited document. Blank and "#" comment lines before the
+// front matter and "#" lines inside it are ignored.
+func Parse(data []byte) (*Doc, error) {
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+
+	// Skip leading comments
+	i := 0
+	for i < len(lines) {
+		line := strings.TrimSpace(lines[i])
+		if line != "" && !strings.HasPrefix(line, "#") {
+			break
+		}
+		i++
+	}
+	if i >= len(lines) || strings.TrimSpace(lines[i]) != "---" {
+		return nil, &ParseError{Line: i + 1, Msg: "missing front matter (expected ---)"}
+	}
+	i++
+
+	d := &Doc{Status: model.StatusTodo}
+	seen := map[string]bool{}
+	closed := false
+	for ; i < len(lines); i++ {
+		lineNo := i + 1
+		line := strings.TrimSpace(lines[i])
+		if line == "---" {
+			closed = true
+			i++
+			break
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, &ParseError{Line: lineNo, Msg: fmt.Sprintf("expected 'key: value', got %q", line)}
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		if seen[key] {
+			return nil, &ParseError{Line: lineNo, Msg: fmt.Sprintf("duplicate field %q", key)}
+		}
+		seen[key] = true
+
+		if err := d.setField(key, value); err != nil {
+			return nil, &ParseError{Line: lineNo, Msg: err.Error()}
+		}
+	}
+	if !closed {
+		return nil, &ParseError{Line: len(lines), Msg: "front matter is not closed (expected ---)"}
+	}
+	if d.Title == "" {
+		return nil, &ParseError{Msg: "title is required"}
+	}
+
+	// Body: description, then an optional subtask section
+	body := lines[i:]
+	split := len(body)
+	for j := len(body) - 1; j >= 0; j-- {
+		if strings.EqualFold(strings.TrimSpace(body[j]), subtasksHeading) {
+			split = j
+			break
+		}
+	}
+	d.Description = strings.TrimSpace(strings.Join(body[:split], "\n"))
+
+	for j := split + 1; j < len(body); j++ {
+		lineNo := i + j + 1
+		line := strings.TrimSpace(body[j])
+		if line == "" {
+			continue
+		}
+		s, err := parseSubtask(line)
+		if err != nil {
+			return nil, &ParseError{Line: lineNo, Msg: err.Error()}
+		}
+		d.Subtasks = append(d.Subtasks, s)
+	}
+
+	return d, nil
+}
+
+// Annotate prefixes an edited document with the error that prevented it from
+// being applied, replacing any previous annotation
+func Annotate(data []byte, err error) []byte {
+	lines := strings.Split(string(data), "\n")
+	for len(lines) > 0 && strings.HasPrefix(lines[0], "# ERROR") {
+		lines = lines[1:]
+	}
+	header := fmt.Sprintf("# ERROR: %s\n# ERROR: fix the problem and save again, or clear the file to cancel.\n", err)
+	return []byte(header + strings.Join(lines, "\n"))
+}
+
+func writeField(b *strings.Builder, key, value string) {
+	if value == "" {
+		fmt.Fprintf(b, "%s:\n", key)
+		return
+	}
+	fmt.Fprintf(b, "%s: %s\n", key, value)
+}
+
+func (d *Doc) setField(key, value string) error {
+	switch key {
+	case "title":
+		d.Title = value
+	case "status":
+		s := model.Status(strings.ToLower(value))
+		if !s.IsValid() {
+			return fmt.Errorf("invalid status %q (use todo/doing/done)", value)
+		}
+		d.Status = s
+	case "priority":
+		p, err := model.ParsePriorityName(value)
+		if err != nil {
+			return err
+		}
+		d.Priority = p
+	case "due":
+		due, err := parseDue(value)
+		if err != nil {
+			return err
+		}
+		d.Due = due
+	case "project":
+		d.Project = value
+	case "tags":
+		d.Tags = nil
+		for _, tag := range strings.Split(value, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				d.Tags = append(d.Tags, tag)
+			}
+		}
+	case "repeat":
+		if value != "" {
+			if _, err := quickadd.ParseRepeat(value); err != nil {
+				return err
+			}
+		}
+		d.Repeat = value
+	default:
+		return fmt.Errorf("unknown field %q", key)
+	}
+	return nil
+}
+
+func parseSubtask(line string) (Subtask, error) {
+	var s Subtask
+	switch {
+	case strings.HasPrefix(line, "- [ ]"):
+	case strings.HasPrefix(line, "- [x]"), strings.HasPrefix(line, "- [X]"):
+		s.Done = true
+	case strings.HasPrefix(line, "- [-]"):
+		s.Trash = true
+	default:
+		return s, fmt.Errorf("expected subtask '- [ ] title', '- [x] title', or '- [-] title', got %q", line)
+	}
+
+	rest := strings.TrimSpace(line[len("- [ ]"):])
+	if strings.HasPrefix(rest, "#") {
+		idStr, title, _ := strings.Cut(rest[1:], " ")
+		if id, err := strconv.ParseInt(idStr, 10, 64); err == nil {
+			s.ID = id
+			rest = strings.TrimSpace(title)
+		}
+	}
+	if rest == "" {
+		return s, fmt.Errorf("subtask title is required")
+	}
+	s.Title = rest
+	return s, nil
+}
+
+func formatPriority(p model.Priority) string {
+	if p == model.PriorityNone {
+		return "none"
+	}
+	return strings.ToLower(p.String())
+}
+
+func formatDue(due *time.Time) string {
+	if due == nil {
+		return ""
+	}
+	if due.Hour() == 23 && due.Minute() == 59 {
+		return due.Format("2006-01-02")
+	}
+	return due.Format("2006-01-02 15:04")
+}
+
+func parseDue(s string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04", s, time.Local); err == nil {
+		return &t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("invalid due date %q (use YYYY-MM-DD or YYYY-MM-DD HH:MM)", s)
+	}
+	t = time.Date(t.Year(), t.Month(), t.Day(), 23, 59, 59, 0, time.Local)
+	return &t, nil
+}
+
+// FormatRepeat renders a recurrence in --repeat syntax: "weekly" or
+// "daily:2" for plain rules, an RRULE otherwise
+func FormatRepeat(rule model.RRule) string {
+	switch {
+	case !rule.IsSimple():
+		return rule.String()
+	case rule.Interval <= 1:
+		return string(rule.Freq)
+	}
+	return fmt.Sprintf("%s:%d", rule.Freq, rule.Interval)
+}
@@ -0,0 +1,634 @@
+package transfer
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/hwanchang/tsk/internal/model"
+	"github.com/hwanchang/tsk/internal/store"
+)
+
+// Export reads the live projects, tags, and tasks from the store. Tasks are
+// numbered from 1 in the order they were created.
+func Export(st store.Store) (*Document, error) {
+	doc := &Document{Version: Version, Projects: []Project{}, Tags: []Tag{}, Tasks: []Task{}}
+
+	projects, err := st.ListProjects()
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range projects {
+		doc.Projects = append(doc.Projects, Project{Name: p.Name, Description: p.Description})
+	}
+
+	tags, err := st.ListTags()
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tags {
+		doc.Tags = append(doc.Tags, Tag{Name: t.Name, Color: t.Color})
+	}
+
+	tasks, err := st.ListTasks(store.TaskFilter{AllLevels: true})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+	ids := make(map[int64]int64, len(tasks)) // store → document IDs
+	for i, t := range tasks {
+		ids[t.ID] = int64(i + 1)
+	}
+	if err := addTasks(st, doc, tasks, ids); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// ExportTasks makes a document of some tasks, such as those a query listed,
+// followed by their loaded subtasks. The tasks keep their store IDs, so
+// that they can be passed to other commands; parents and blockers outside
+// them are left out.
+func ExportTasks(st store.Store, tasks []model.Task) (*Document, error) {
+	var flat []model.Task
+	var walk func(tasks []model.Task)
+	walk = func(tasks []model.Task) {
+		for _, t := range tasks {
+			flat = append(flat, t)
+			walk(t.Subtasks)
+		}
+	}
+	walk(tasks)
+
+	ids := make(map[int64]int64, len(flat))
+	for _, t := range flat {
+		ids[t.ID] = t.ID
+	}
+	doc := &Document{Version: Version, Projects: []Project{}, Tags: []Tag{}, Tasks: []Task{}}
+	if err := addTasks(st, doc, flat, ids); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// addTasks adds tasks to a document under the given IDs
+func addTasks(st store.Store, doc *Document, tasks []model.Task, ids map[int64]int64) error {
+	projects, err := st.ListProjects()
+	if err != nil {
+		return err
+	}
+	projectNames := make(map[int64]string, len(projects))
+	for _, p := range projects {
+		projectNames[p.ID] = p.Name
+	}
+
+	for _, t := range tasks {
+		task := Task{
+			ID:          ids[t.ID],
+			UID:         t.UID,
+			Title:       t.Title,
+			Description: t.Description,
+			Status:      string(t.Status),
+			Priority:    strings.ToLower(t.Priority.String()),
+			Due:         t.DueDate,
+			Created:     t.CreatedAt,
+			Completed:   t.CompletedAt,
+			Position:    t.Position,
+		}
+		if t.ParentID != nil {
+			task.Parent = ids[*t.ParentID]
+		}
+		if t.ProjectID != nil {
+			task.Project = projectNames[*t.ProjectID]
+		}
+		for _, tag := range t.Tags {
+			task.Tags = append(task.Tags, tag.Name)
+		}
+
+		blockers, err := st.GetBlockers(t.ID)
+		if err != nil {
+			return err
+		}
+		for _, b := range blockers {
+			if id, ok := ids[b.ID]; ok {
+				task.BlockedBy = append(task.BlockedBy, id)
+			}
+		}
+
+		rec, err := st.GetRecurrence(t.ID)
+		if err != nil {
+			return err
+		}
+		if rec != nil {
+			task.Recurrence = &Recurrence{
+				Rule:       rec.Rule.String(),
+				Occurrence: rec.Occurrence,
+				NextDue:    rec.NextDue,
+				Anchor:     string(rec.Anchor),
+				CatchUp:    string(rec.CatchUp),
+			}
+		}
+
+		doc.Tasks = append(doc.Tasks, task)
+	}
+	return nil
+}
+
+// Options control an import
+type Options struct {
+	Replace bool // move existing tasks, projects, and tags to the trash first
+	DryRun  bool // check and count the import without writing anything
+}
+
+// Summary reports what an import created
+type Summary struct {
+	Tasks       int
+	Updated     int // existing tasks matched by UID, included in Tasks
+	NewProjects []string
+	NewTags     []string
+	Trashed     int // existing tasks moved to the trash by Replace
+	Restored    int // tasks, projects, and tags the document named taken out of the trash
+}
+
+var errDryRun = errors.New("dry run")
+
+// Import adds a document's tasks to the store in a single transaction,
+// reusing projects and tags with the same names. Tasks whose UIDs already
+// exist are updated instead, and tasks, projects, and tags it names that
+// are in the trash are restored. The document is checked before anything
+// is written.
+func Import(st store.Store, doc *Document, opts Options) (*Summary, error) {
+	if err := Validate(doc); err != nil {
+		return nil, err
+	}
+
+	sum := &Summary{}
+	err := st.WithTx(func(tx store.Store) error {
+		if opts.Replace {
+			if err := trashMissing(tx, doc, sum); err != nil {
+				return err
+			}
+		}
+		if err := importDoc(tx, doc, sum); err != nil {
+			return err
+		}
+		if opts.DryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		return nil, err
+	}
+	return sum, nil
+}
+
+// Validate checks that a document's tasks are complete and refer to each other
+func Validate(doc *Document) error {
+	tasks := make(map[int64]*Task, len(doc.Tasks))
+	uids := make(map[string]bool, len(doc.Tasks))
+	for i := range doc.Tasks {
+		t := &doc.Tasks[i]
+		if t.ID <= 0 {
+			return fmt.Errorf("task %q: missing id", t.Title)
+		}
+		if tasks[t.ID] != nil {
+			return fmt.Errorf("duplicate task id %d", t.ID)
+		}
+		tasks[t.ID] = t
+		if t.UID != "" {
+			if uids[t.UID] {
+				return fmt.Errorf("duplicate task uid %q", t.UID)
+			}
+			uids[t.UID] = true
+		}
+	}
+
+	for _, t := range doc.Tasks {
+		if strings.TrimSpace(t.Title) == "" {
+			return fmt.Errorf("task %d: missing title", t.ID)
+		}
+		if t.Status != "" && !model.Status(t.Status).IsValid() {
+			return fmt.Errorf("task %d: invalid status %q (use todo, doing, or done)", t.ID, t.Status)
+		}
+		if _, err := model.ParsePriorityName(t.Priority); err != nil {
+			return fmt.Errorf("task %d: %w", t.ID, err)
+		}
+		if t.Parent != 0 && tasks[t.Parent] == nil {
+			return fmt.Errorf("task %d: unknown parent %d", t.ID, t.Parent)
+		}
+		for _, b := range t.BlockedBy {
+			if tasks[b] == nil {
+				return fmt.Errorf("task %d: unknown blocker %d", t.ID, b)
+			}
+		}
+		if t.Recurrence != nil {
+			if _, err := model.ParseRRule(t.Recurrence.Rule); err != nil {
+				return fmt.Errorf("task %d: %w", t.ID, err)
+			}
+		}
+
+		// Walking up from a task must reach the top
+		for p, steps := t.Parent, 0; p != 0; p, steps = tasks[p].Parent, steps+1 {
+			if steps > len(tasks) {
+				return fmt.Errorf("task %d: subtask of itself", t.ID)
+			}
+		}
+	}
+	return nil
+}
+
+// trashMissing moves what the document doesn't have to the trash: tasks
+// whose UIDs it doesn't list, and projects and tags it doesn't name. Inbox
+// stays.
+func trashMissing(st store.Store, doc *Document, sum *Summary) error {
+	uids := make(map[string]bool, len(doc.Tasks))
+	projectNames := make(map[string]bool)
+	tagNames := make(map[string]bool)
+	for _, p := range doc.Projects {
+		projectNames[strings.ToLower(p.Name)] = true
+	}
+	for _, t := range doc.Tags {
+		tagNames[strings.ToLower(t.Name)] = true
+	}
+	for _, t := range doc.Tasks {
+		if t.UID != "" {
+			uids[t.UID] = true
+		}
+		projectNames[strings.ToLower(t.Project)] = true
+		for _, tag := range t.Tags {
+			tagNames[strings.ToLower(tag)] = true
+		}
+	}
+
+	tasks, err := st.ListTasks(store.TaskFilter{})
+	if err != nil {
+		return err
+	}
+	if err := trashTasks(st, tasks, uids, sum); err != nil {
+		return err
+	}
+
+	projects, err := st.ListProjects()
+	if err != nil {
+		return err
+	}
+	for _, p := range projects {
+		if p.ID == 1 || projectNames[strings.ToLower(p.Name)] {
+			continue // Inbox can't be deleted
+		}
+		if err := st.DeleteProject(p.ID); err != nil {
+			return err
+		}
+	}
+
+	tags, err := st.ListTags()
+	if err != nil {
+		return err
+	}
+	for _, t := range tags {
+		if tagNames[strings.ToLower(t.Name)] {
+			continue
+		}
+		if err := st.DeleteTag(t.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// trashTasks moves tasks whose UIDs aren't kept to the trash with their
+// subtasks, and looks through the subtasks of those that are
+func trashTasks(st store.Store, tasks []model.Task, keep map[string]bool, sum *Summary) error {
+	for _, t := range tasks {
+		if keep[t.UID] {
+			subtasks, err := st.GetSubtasks(t.ID)
+			if err != nil {
+				return err
+			}
+			if err := trashTasks(st, subtasks, keep, sum); err != nil {
+				return err
+			}
+			continue
+		}
+
+		subtree, err := countTasks(st, t.ID)
+		if err != nil {
+			return err
+		}
+		if err := st.DeleteTask(t.ID); err != nil {
+			return err
+		}
+		sum.Trashed += subtree
+	}
+	return nil
+}
+
+// countTasks counts a task and its live descendants
+func countTasks(st store.Store, id int64) (int, error) {
+	subtasks, err := st.GetSubtasks(id)
+	if err != nil {
+		return 0, err
+	}
+	n := 1
+	for _, s := range subtasks {
+		c, err := countTasks(st, s.ID)
+		if err != nil {
+			return 0, err
+		}
+		n += c
+	}
+	return n, nil
+}
+
+func importDoc(st store.Store, doc *Document, sum *Summary) error {
+	projectIDs, err := importProjects(st, doc, sum)
+	if err != nil {
+		return err
+	}
+	tagIDs, err := importTags(st, doc, sum)
+	if err != nil {
+		return err
+	}
+
+	trashedTasks, err := st.ListTasks(store.TaskFilter{Trashed: true, AllLevels: true})
+	if err != nil {
+		return err
+	}
+	trashed := make(map[string]int64, len(trashedTasks)) // UID → store ID
+	for _, t := range trashedTasks {
+		trashed[t.UID] = t.ID
+	}
+
+	ids := make(map[int64]int64, len(doc.Tasks)) // document → store IDs
+	var orphans []Task                           // subtasks listed before their parent
+	updated := make(map[int64]bool)              // document IDs of existing tasks
+	for _, dt := range doc.Tasks {
+		var existing *model.Task
+		if dt.UID != "" {
+			existing, err = st.GetTaskByUID(dt.UID)
+			if err != nil {
+				return err
+			}
+			if id, ok := trashed[dt.UID]; ok && existing == nil {
+				// The document still has the task, so it comes back
+				if err := st.RestoreTask(id); err != nil {
+					return err
+				}
+				if existing, err = st.GetTask(id); err != nil {
+					return err
+				}
+				sum.Restored++
+			}
+		}
+
+		t := &model.Task{
+			UID:         dt.UID,
+			Title:       dt.Title,
+			Description: dt.Description,
+			Status:      model.StatusTodo,
+			Priority:    model.ParsePriority(dt.Priority),
+			DueDate:     dt.Due,
+			CreatedAt:   dt.Created,
+			CompletedAt: dt.Completed,
+			Position:    dt.Position,
+		}
+		if dt.Status != "" {
+			t.Status = model.Status(dt.Status)
+		}
+		if dt.Project != "" {
+			id, err := lookup(projectIDs, dt.Project, func() (int64, error) {
+				p := model.NewProject(dt.Project)
+				err := st.CreateProject(p)
+				sum.NewProjects = append(sum.NewProjects, p.Name)
+				return p.ID, err
+			})
+			if err != nil {
+				return err
+			}
+			t.ProjectID = &id
+		}
+		if parentID, ok := ids[dt.Parent]; ok {
+			t.ParentID = &parentID
+		} else if dt.Parent != 0 {
+			orphans = append(orphans, dt)
+		}
+		if existing != nil {
+			t.ID = existing.ID
+			if err := st.UpdateTask(t); err != nil {
+				return err
+			}
+			updated[dt.ID] = true
+			sum.Updated++
+		} else if err := st.CreateTask(t); err != nil {
+			return err
+		}
+		ids[dt.ID] = t.ID
+		sum.Tasks++
+
+		tagged := make(map[int64]bool, len(dt.Tags))
+		for _, name := range dt.Tags {
+			tagID, err := lookup(tagIDs, name, func() (int64, error) {
+				tag := model.NewTag(name)
+				err := st.CreateTag(tag)
+				sum.NewTags = append(sum.NewTags, tag.Name)
+				return tag.ID, err
+			})
+			if err != nil {
+				return err
+			}
+			if err := st.AddTagToTask(t.ID, tagID); err != nil {
+				return err
+			}
+			tagged[tagID] = true
+		}
+		if existing != nil {
+			for _, tag := range existing.Tags {
+				if !tagged[tag.ID] {
+					if err := st.RemoveTagFromTask(t.ID, tag.ID); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+
+	for _, dt := range orphans {
+		t, err := st.GetTask(ids[dt.ID])
+		if err != nil {
+			return err
+		}
+		parentID := ids[dt.Parent]
+		t.ParentID = &parentID
+		if err := st.UpdateTask(t); err != nil {
+			return err
+		}
+	}
+
+	// Updated tasks lose the dependencies and recurrences the document
+	// doesn't give them
+	for _, dt := range doc.Tasks {
+		if !updated[dt.ID] {
+			continue
+		}
+		blockers, err := st.GetBlockers(ids[dt.ID])
+		if err != nil {
+			return err
+		}
+		for _, b := range blockers {
+			if !slices.ContainsFunc(dt.BlockedBy, func(id int64) bool { return ids[id] == b.ID }) {
+				if err := st.RemoveDependency(ids[dt.ID], b.ID); err != nil {
+					return err
+				}
+			}
+		}
+		if dt.Recurrence == nil {
+			if err := st.DeleteRecurrence(ids[dt.ID]); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, dt := range doc.Tasks {
+		for _, b := range dt.BlockedBy {
+			if err := st.AddDependency(ids[dt.ID], ids[b]); err != nil {
+				return fmt.Errorf("task %d: %w", dt.ID, err)
+			}
+		}
+		if dt.Recurrence != nil {
+			rule, _ := model.ParseRRule(dt.Recurrence.Rule) // checked by Validate
+			rec := model.NewRecurrence(ids[dt.ID], rule)
+			rec.Occurrence = dt.Recurrence.Occurrence
+			rec.Anchor = model.RecurrenceAnchor(dt.Recurrence.Anchor)
+			rec.CatchUp = model.CatchUp(dt.Recurrence.CatchUp)
+			rec.NextDue = dt.Recurrence.NextDue
+			if rec.NextDue.IsZero() {
+				rec.Schedule(dt.Due)
+			}
+			if err := st.SetRecurrence(rec); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// importProjects restores the trashed projects the document names and
+// creates those that don't exist yet, returning the IDs of all projects by lowercase name
+func importProjects(st store.Store, doc *Document, sum *Summary) (map[string]int64, error) {
+	projects, err := st.ListProjects()
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[string]int64, len(projects))
+	for _, p := range projects {
+		ids[strings.ToLower(p.Name)] = p.ID
+	}
+
+	named := make(map[string]bool) // lowercase names the document uses
+	for _, dp := range doc.Projects {
+		named[strings.ToLower(dp.Name)] = true
+	}
+	for _, dt := range doc.Tasks {
+		named[strings.ToLower(dt.Project)] = true
+	}
+	trashed, err := st.ListTrashedProjects()
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range trashed {
+		key := strings.ToLower(p.Name)
+		if _, ok := ids[key]; ok || !named[key] {
+			continue
+		}
+		if err := st.RestoreProject(p.ID); err != nil {
+			return nil, err
+		}
+		ids[key] = p.ID
+		sum.Restored++
+	}
+
+	for _, dp := range doc.Projects {
+		_, err := lookup(ids, dp.Name, func() (int64, error) {
+			p := &model.Project{Name: dp.Name, Description: dp.Description}
+			err := st.CreateProject(p)
+			sum.NewProjects = append(sum.NewProjects, p.Name)
+			return p.ID, err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ids, nil
+}
+
+// importTags restores the trashed tags the document names and creates
+// those that don't exist yet, returning the IDs of all tags by lowercase name
+func importTags(st store.Store, doc *Document, sum *Summary) (map[string]int64, error) {
+	tags, err := st.ListTags()
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[string]int64, len(tags))
+	for _, t := range tags {
+		ids[strings.ToLower(t.Name)] = t.ID
+	}
+
+	named := make(map[string]bool) // lowercase names the document uses
+	for _, dt := range doc.Tags {
+		named[strings.ToLower(dt.Name)] = true
+	}
+	for _, dt := range doc.Tasks {
+		for _, name := range dt.Tags {
+			named[strings.ToLower(name)] = true
+		}
+	}
+	trashed, err := st.ListTrashedTags()
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range trashed {
+		key := strings.ToLower(t.Name)
+		if _, ok := ids[key]; ok || !named[key] {
+			continue
+		}
+		if err := st.RestoreTag(t.ID); err != nil {
+			return nil, err
+		}
+		ids[key] = t.ID
+		sum.Restored++
+	}
+
+	for _, dt := range doc.Tags {
+		_, err := lookup(ids, dt.Name, func() (int64, error) {
+			t := model.NewTag(dt.Name)
+			if dt.Color != "" {
+				t.Color = dt.Color
+			}
+			err := st.CreateTag(t)
+			sum.NewTags = append(sum.NewTags, t.Name)
+			return t.ID, err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ids, nil
+}
+
+// lookup returns the ID of name, ignoring case, calling create to add it
+// if it's missing
+func lookup(ids map[string]int64, name string, create func() (int64, error)) (int64, error) {
+	key := strings.ToLower(name)
+	if id, ok := ids[key]; ok {
+		return id, nil
+	}
+	id, err := create()
+	if err != nil {
+		return 0, err
+	}
+	ids[key] = id
+	return id, nil
+}
@@ -0,0 +1,327 @@
+package transfer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hwanchang/tsk/internal/dates"
+	"github.com/hwanchang/tsk/internal/model"
+	"github.com/hwanchang/tsk/internal/quickadd"
+)
+
+func init() {
+	Register("csv", CSV{}, ".csv")
+}
+
+// CSVFields are the task fields CSV columns can hold, in the order they're
+// exported
+var CSVFields = []string{
+	"id", "title", "status", "priority", "project", "tags", "due_date", "description",
+	"parent_id", "blocked_by", "repeat", "created_at", "completed_at", "uid",
+}
+
+// csvTimeLayout is how dates are exported; dates without a time leave it out
+const csvTimeLayout = "2006-01-02 15:04"
+
+// CSV reads and writes a task per row, under a header naming the field of
+// each column. Dates are read like --due, so "2026-10-20", "fri 5pm", and
+// "2026-10-20 17:00" all work; tags are comma-separated. parent_id and
+// blocked_by refer to the id column.
+//
+// Rows that can't be read are left out and reported in Rejected, with
+// their line numbers, instead of failing the import.
+type CSV struct {
+	// Columns maps header names, ignoring case, to fields. Columns it
+	// doesn't name are read as the field of the same name, if there is one.
+	Columns map[string]string
+}
+
+// ParseColumnMap parses a mapping of CSV header names to fields, such as
+// "Title=title,Due=due_date,Labels=tags"
+func ParseColumnMap(s string) (map[string]string, error) {
+	columns := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		header, field, ok := strings.Cut(pair, "=")
+		header = strings.ToLower(strings.TrimSpace(header))
+		field = strings.ToLower(strings.TrimSpace(field))
+		if !ok || header == "" {
+			return nil, fmt.Errorf("invalid column mapping %q (use Header=field)", pair)
+		}
+		if !slices.Contains(CSVFields, field) {
+			return nil, fmt.Errorf("unknown field %q (use %s)", field, strings.Join(CSVFields, ", "))
+		}
+		columns[header] = field
+	}
+	return columns, nil
+}
+
+// csvRow is a task read from a row, with the references still to resolve
+type csvRow struct {
+	line      int
+	task      Task
+	id        string // in the id column
+	parent    string
+	blockedBy []string
+}
+
+func (c CSV) Decode(r io.Reader) (*Document, error) {
+	doc := &Document{Version: Version}
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return doc, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read CSV header: %w", err)
+	}
+
+	fields := make([]string, len(header)) // the field of each column, or ""
+	for i, h := range header {
+		name := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+		field, ok := c.Columns[name]
+		if !ok && slices.Contains(CSVFields, name) {
+			field = name
+		}
+		if field != "" && slices.Contains(fields, field) {
+			return nil, fmt.Errorf("more than one column for %s", field)
+		}
+		fields[i] = field
+		if field == "" && name != "" {
+			doc.unmapped("column " + strings.TrimSpace(h))
+		}
+	}
+	if !slices.Contains(fields, "title") {
+		return nil, fmt.Errorf(`no title column (map one with --map, e.g. --map "Name=title")`)
+	}
+
+	var rows []csvRow
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			doc.reject(parseErr.StartLine, parseErr.Err)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read CSV: %w", err)
+		}
+
+		line, _ := cr.FieldPos(0)
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+		row, err := csvParseRow(fields, record)
+		if err != nil {
+			doc.reject(line, err)
+			continue
+		}
+		row.line = line
+		row.task.ID = int64(len(rows) + 1)
+		rows = append(rows, *row)
+	}
+
+	for _, row := range csvResolve(doc, rows) {
+		doc.Tasks = append(doc.Tasks, row.task)
+	}
+	return doc, nil
+}
+
+// csvParseRow reads the fields of a row into a task
+func csvParseRow(fields, record []string) (*csvRow, error) {
+	row := &csvRow{task: Task{Status: string(model.StatusTodo)}}
+	t := &row.task
+	for i, value := range record {
+		value = strings.TrimSpace(value)
+		if i >= len(fields) || fields[i] == "" || value == "" {
+			continue
+		}
+
+		var err error
+		switch fields[i] {
+		case "id":
+			row.id = value
+		case "uid":
+			t.UID = value
+		case "title":
+			t.Title = value
+		case "description":
+			t.Description = value
+		case "status":
+			t.Status = strings.ToLower(value)
+			if !model.Status(t.Status).IsValid() {
+				return nil, fmt.Errorf("invalid status %q (use todo, doing, or done)", value)
+			}
+		case "priority":
+			var p model.Priority
+			p, err = model.ParsePriorityName(value)
+			t.Priority = strings.ToLower(p.String())
+		case "project":
+			t.Project = value
+		case "tags":
+			for _, tag := range strings.Split(value, ",") {
+				if tag = strings.TrimSpace(tag); tag != "" {
+					t.Tags = append(t.Tags, tag)
+				}
+			}
+		case "due_date":
+			var due time.Time
+			due, err = dates.Parse(value)
+			t.Due = &due
+		case "created_at":
+			t.Created, err = dates.Parse(value)
+		case "completed_at":
+			var completed time.Time
+			completed, err = dates.Parse(value)
+			t.Completed = &completed
+		case "parent_id":
+			row.parent = value
+		case "blocked_by":
+			for _, id := range strings.Split(value, ",") {
+				if id = strings.TrimSpace(id); id != "" {
+					row.blockedBy = append(row.blockedBy, id)
+				}
+			}
+		case "repeat":
+			var rule model.RRule
+			rule, err = quickadd.ParseRepeat(value)
+			t.Recurrence = &Recurrence{Rule: rule.String()}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", fields[i], err)
+		}
+	}
+	if t.Title == "" {
+		return nil, fmt.Errorf("missing title")
+	}
+	return row, nil
+}
+
+// csvResolve points the parents and blockers of rows at their tasks,
+// rejecting rows that refer to ids no row has, and then rows that refer
+// to those
+func csvResolve(doc *Document, rows []csvRow) []csvRow {
+	for {
+		ids := make(map[string]int64, len(rows))
+		for _, row := range rows {
+			if row.id != "" {
+				ids[row.id] = row.task.ID
+			}
+		}
+
+		var kept []csvRow
+		for _, row := range rows {
+			if err := row.resolve(ids); err != nil {
+				doc.reject(row.line, err)
+				continue
+			}
+			kept = append(kept, row)
+		}
+		if len(kept) == len(rows) {
+			return kept
+		}
+		rows = kept
+	}
+}
+
+func (row *csvRow) resolve(ids map[string]int64) error {
+	if row.parent != "" {
+		id, ok := ids[row.parent]
+		if !ok || id == row.task.ID {
+			return fmt.Errorf("unknown parent_id %s", row.parent)
+		}
+		row.task.Parent = id
+	}
+	row.task.BlockedBy = nil
+	for _, b := range row.blockedBy {
+		id, ok := ids[b]
+		if !ok || id == row.task.ID {
+			return fmt.Errorf("unknown blocked_by id %s", b)
+		}
+		row.task.BlockedBy = append(row.task.BlockedBy, id)
+	}
+	return nil
+}
+
+func (CSV) Encode(w io.Writer, doc *Document) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(CSVFields); err != nil {
+		return err
+	}
+	for _, t := range doc.Tasks {
+		values := map[string]string{
+			"id":          strconv.FormatInt(t.ID, 10),
+			"uid":         t.UID,
+			"title":       t.Title,
+			"description": t.Description,
+			"status":      t.Status,
+			"priority":    t.Priority,
+			"project":     t.Project,
+			"tags":        strings.Join(t.Tags, ", "),
+			"created_at":  csvFormatTime(t.Created),
+		}
+		if t.Due != nil {
+			values["due_date"] = csvFormatTime(*t.Due)
+		}
+		if t.Completed != nil {
+			values["completed_at"] = csvFormatTime(*t.Completed)
+		}
+		if t.Parent != 0 {
+			values["parent_id"] = strconv.FormatInt(t.Parent, 10)
+		}
+		blockers := make([]string, len(t.BlockedBy))
+		for i, b := range t.BlockedBy {
+			blockers[i] = strconv.FormatInt(b, 10)
+		}
+		values["blocked_by"] = strings.Join(blockers, ", ")
+
+		if r := t.Recurrence; r != nil {
+			values["repeat"] = r.Rule
+			if r.Anchor == string(model.AnchorCompletion) {
+				doc.unmapped("recurrence anchors")
+			}
+			if r.CatchUp != "" && r.CatchUp != string(model.CatchUpSkip) {
+				doc.unmapped("recurrence catch-up")
+			}
+		}
+		if t.Position != 0 {
+			doc.unmapped("position")
+		}
+
+		record := make([]string, len(CSVFields))
+		for i, field := range CSVFields {
+			record[i] = values[field]
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvFormatTime formats a local date, with the time unless it's the end
+// of the day
+func csvFormatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	t = t.Local()
+	if !dates.HasTime(t) {
+		return t.Format("2006-01-02")
+	}
+	return t.Format(csvTimeLayout)
+}
@@ -0,0 +1,200 @@
+package transfer
+
+import (
+	"io"
+
+	"github.com/google/uuid"
+
+	"github.com/hwanchang/tsk/internal/dates"
+	"github.com/hwanchang/tsk/internal/ical"
+	"github.com/hwanchang/tsk/internal/model"
+)
+
+func init() {
+	Register("ics", icsCodec{}, ".ics")
+}
+
+const (
+	icsProdID  = "-//tsk//tsk//EN"
+	icsProject = "X-TSK-PROJECT"
+)
+
+// icsStatuses map task statuses to VTODO ones
+var icsStatuses = map[string]string{
+	string(model.StatusTodo):  "NEEDS-ACTION",
+	string(model.StatusDoing): "IN-PROCESS",
+	string(model.StatusDone):  "COMPLETED",
+}
+
+// icsCodec reads and writes iCalendar to-dos (VTODO), which calendar and
+// reminder apps share. Tags are CATEGORIES, the parent and blockers are
+// RELATED-TO, and the project an X-TSK-PROJECT property. Tasks keep their
+// UIDs, so importing a calendar again updates the tasks it came from.
+type icsCodec struct{}
+
+func (icsCodec) Decode(r io.Reader) (*Document, error) {
+	todos, other, err := ical.ReadTodos(r)
+	if err != nil {
+		return nil, err
+	}
+	doc := &Document{Version: Version}
+	for _, c := range other {
+		doc.unmapped(c.Name)
+	}
+
+	ids := make(map[string]int64, len(todos)) // UID → document ID
+	var kept []ical.Todo
+	for _, td := range todos {
+		switch {
+		case td.Status == "CANCELLED":
+			doc.unmapped("cancelled to-dos")
+			continue
+		case icsHas(td, "RECURRENCE-ID"):
+			doc.unmapped("RECURRENCE-ID")
+			continue
+		}
+		kept = append(kept, td)
+		if td.UID != "" {
+			ids[td.UID] = int64(len(kept))
+		}
+	}
+
+	for i, td := range kept {
+		task := Task{
+			ID:          int64(i + 1),
+			UID:         td.UID,
+			Title:       td.Summary,
+			Description: td.Description,
+			Status:      string(model.StatusTodo),
+			Priority:    icsPriority(td.Priority),
+			Tags:        td.Categories,
+			Created:     td.Created,
+		}
+		switch td.Status {
+		case "IN-PROCESS":
+			task.Status = string(model.StatusDoing)
+		case "COMPLETED":
+			task.Status = string(model.StatusDone)
+			task.Completed = td.Completed
+		}
+		if td.Due != nil {
+			due := *td.Due
+			if td.DueIsDate {
+				due = dates.EndOfDay(due)
+			}
+			task.Due = &due
+		}
+		if td.RRule != "" {
+			task.Recurrence = &Recurrence{Rule: td.RRule}
+		}
+
+		if td.Parent != "" {
+			if id, ok := ids[td.Parent]; ok && id != task.ID {
+				task.Parent = id
+			} else {
+				doc.unmapped("RELATED-TO")
+			}
+		}
+		for _, uid := range td.DependsOn {
+			if id, ok := ids[uid]; ok && id != task.ID {
+				task.BlockedBy = append(task.BlockedBy, id)
+			} else {
+				doc.unmapped("RELATED-TO")
+			}
+		}
+
+		for _, p := range td.Other {
+			if p.Name == icsProject {
+				task.Project = p.Text()
+			} else {
+				doc.unmapped(p.Name)
+			}
+		}
+		for _, c := range td.Children {
+			doc.unmapped(c.Name)
+		}
+		doc.Tasks = append(doc.Tasks, task)
+	}
+	return doc, nil
+}
+
+// icsHas reports whether a to-do has a property without a field
+func icsHas(td ical.Todo, name string) bool {
+	for _, p := range td.Other {
+		if p.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// icsPriority maps 1 to 4 to high, 5 to medium, and 6 to 9 to low
+func icsPriority(p int) string {
+	switch {
+	case p == 0:
+		return ""
+	case p < 5:
+		return "high"
+	case p == 5:
+		return "medium"
+	}
+	return "low"
+}
+
+func (icsCodec) Encode(w io.Writer, doc *Document) error {
+	for _, t := range doc.Tags {
+		if t.Color != "" && t.Color != model.NewTag(t.Name).Color {
+			doc.unmapped("tag colors")
+		}
+	}
+
+	uids := make(map[int64]string, len(doc.Tasks))
+	for _, t := range doc.Tasks {
+		uids[t.ID] = t.UID
+		if t.UID == "" {
+			uids[t.ID] = uuid.NewString()
+		}
+	}
+
+	todos := make([]ical.Todo, 0, len(doc.Tasks))
+	for _, t := range doc.Tasks {
+		td := ical.Todo{
+			UID:         uids[t.ID],
+			Summary:     t.Title,
+			Description: t.Description,
+			Status:      icsStatuses[t.Status],
+			Priority:    map[string]int{"high": 1, "medium": 5, "low": 9}[t.Priority],
+			Created:     t.Created,
+			Completed:   t.Completed,
+			Categories:  t.Tags,
+		}
+		if t.Due != nil {
+			due := *t.Due
+			td.Due, td.DueIsDate = &due, !dates.HasTime(due)
+		}
+		if t.Parent != 0 {
+			td.Parent = uids[t.Parent]
+		}
+		for _, b := range t.BlockedBy {
+			td.DependsOn = append(td.DependsOn, uids[b])
+		}
+		if t.Project != "" {
+			td.Other = append(td.Other, ical.TextProperty(icsProject, t.Project))
+		}
+
+		if r := t.Recurrence; r != nil {
+			td.RRule = r.Rule
+			if r.Anchor == string(model.AnchorCompletion) {
+				doc.unmapped("recurrence anchors")
+			}
+			if r.CatchUp != "" && r.CatchUp != string(model.CatchUpSkip) {
+				doc.unmapped("recurrence catch-up")
+			}
+		}
+		if t.Position != 0 {
+			doc.unmapped("position")
+		}
+		todos = append(todos, td)
+	}
+	return ical.WriteTodos(w, icsProdID, todos)
+}
@@ -0,0 +1,104 @@
+package transfer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+func init() {
+	Register("json", jsonCodec{}, ".json")
+	Register("ndjson", ndjsonCodec{}, ".ndjson", ".jsonl")
+}
+
+// jsonCodec reads and writes a document as one JSON object
+type jsonCodec struct{}
+
+func (jsonCodec) Encode(w io.Writer, doc *Document) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+func (jsonCodec) Decode(r io.Reader) (*Document, error) {
+	var doc Document
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("parse JSON: %w", err)
+	}
+	if err := checkVersion(doc.Version); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// record is a line of NDJSON; exactly one field is set
+type record struct {
+	Version int      `json:"version,omitempty"`
+	Project *Project `json:"project,omitempty"`
+	Tag     *Tag     `json:"tag,omitempty"`
+	Task    *Task    `json:"task,omitempty"`
+}
+
+// ndjsonCodec reads and writes a document as a record per line
+type ndjsonCodec struct{}
+
+func (ndjsonCodec) Encode(w io.Writer, doc *Document) error {
+	enc := json.NewEncoder(w)
+	if err := enc.Encode(record{Version: doc.Version}); err != nil {
+		return err
+	}
+	for i := range doc.Projects {
+		if err := enc.Encode(record{Project: &doc.Projects[i]}); err != nil {
+			return err
+		}
+	}
+	for i := range doc.Tags {
+		if err := enc.Encode(record{Tag: &doc.Tags[i]}); err != nil {
+			return err
+		}
+	}
+	for i := range doc.Tasks {
+		if err := enc.Encode(record{Task: &doc.Tasks[i]}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ndjsonCodec) Decode(r io.Reader) (*Document, error) {
+	doc := &Document{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 16<<20) // descriptions can make long lines
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var rec record
+		dec := json.NewDecoder(bytes.NewReader(scanner.Bytes()))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&rec); err != nil {
+			return nil, fmt.Errorf("parse line %d: %w", line, err)
+		}
+		switch {
+		case rec.Version != 0:
+			doc.Version = rec.Version
+		case rec.Project != nil:
+			doc.Projects = append(doc.Projects, *rec.Project)
+		case rec.Tag != nil:
+			doc.Tags = append(doc.Tags, *rec.Tag)
+		case rec.Task != nil:
+			doc.Tasks = append(doc.Tasks, *rec.Task)
+		default:
+			return nil, fmt.Errorf("parse line %d: unknown record", line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read NDJSON: %w", err)
+	}
+	if err := checkVersion(doc.Version); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
@@ -0,0 +1,431 @@
+package transfer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/hwanchang/tsk/internal/dates"
+	"github.com/hwanchang/tsk/internal/model"
+)
+
+func init() {
+	Register("taskwarrior", taskwarriorCodec{})
+}
+
+// twTimeLayout is the UTC form of Taskwarrior's dates
+const twTimeLayout = "20060102T150405Z"
+
+// twNamespace derives the UUIDs of exported tasks
+var twNamespace = uuid.MustParse("6f1c5a52-2c1d-4d3e-9a4e-7f0b8e3c1d2a")
+
+// twTask is a task in Taskwarrior's "task export" JSON
+type twTask struct {
+	UUID        string         `json:"uuid"`
+	Description string         `json:"description"`
+	Status      string         `json:"status"`
+	Entry       string         `json:"entry,omitempty"`
+	Start       string         `json:"start,omitempty"`
+	End         string         `json:"end,omitempty"`
+	Due         string         `json:"due,omitempty"`
+	Recur       string         `json:"recur,omitempty"`
+	Until       string         `json:"until,omitempty"`
+	Project     string         `json:"project,omitempty"`
+	Priority    string         `json:"priority,omitempty"`
+	Tags        []string       `json:"tags,omitempty"`
+	Depends     twDepends      `json:"depends,omitempty"`
+	Annotations []twAnnotation `json:"annotations,omitempty"`
+	Parent      string         `json:"parent,omitempty"` // the template of a recurring task
+	Imask       float64        `json:"imask,omitempty"`  // the occurrence of a recurring task, from 0
+}
+
+type twAnnotation struct {
+	Entry       string `json:"entry"`
+	Description string `json:"description"`
+}
+
+// twDepends are the UUIDs a task depends on: a comma-separated string before
+// Taskwarrior 2.6, an array since
+type twDepends []string
+
+func (d *twDepends) UnmarshalJSON(data []byte) error {
+	var list []string
+	if err := json.Unmarshal(data, &list); err == nil {
+		*d = list
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("depends: %w", err)
+	}
+	*d = nil
+	for _, id := range strings.Split(s, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			*d = append(*d, id)
+		}
+	}
+	return nil
+}
+
+// twIgnored are attributes with nothing to map that aren't worth reporting
+var twIgnored = map[string]bool{
+	"id": true, "uuid": true, "modified": true, "urgency": true,
+	"mask": true, "imask": true, "rtype": true, "parent": true,
+}
+
+// twKnown are the attributes twTask maps
+var twKnown = map[string]bool{
+	"description": true, "status": true, "entry": true, "start": true, "end": true,
+	"due": true, "recur": true, "until": true, "project": true, "priority": true,
+	"tags": true, "depends": true, "annotations": true,
+}
+
+var twPriorities = map[string]model.Priority{"H": model.PriorityHigh, "M": model.PriorityMedium, "L": model.PriorityLow}
+
+// taskwarriorCodec reads the output of "task export" and writes what
+// "task import" reads. Annotations become lines of the description.
+type taskwarriorCodec struct{}
+
+func (taskwarriorCodec) Decode(r io.Reader) (*Document, error) {
+	var raws []map[string]json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raws); err != nil {
+		return nil, fmt.Errorf("parse Taskwarrior export: %w", err)
+	}
+
+	doc := &Document{Version: Version}
+	tasks := make([]twTask, len(raws))
+	for i, raw := range raws {
+		data, _ := json.Marshal(raw)
+		if err := json.Unmarshal(data, &tasks[i]); err != nil {
+			return nil, fmt.Errorf("parse Taskwarrior task %d: %w", i+1, err)
+		}
+		for key := range raw {
+			if !twKnown[key] && !twIgnored[key] {
+				doc.unmapped(key)
+			}
+		}
+	}
+
+	// A recurring task is a template, with a pending task per occurrence.
+	// The latest one carries the recurrence; the template is only imported
+	// when it has none.
+	latest := map[string]int{} // template UUID → index of its latest pending task
+	for i, t := range tasks {
+		if t.Parent == "" || t.Status != "pending" {
+			continue
+		}
+		if j, ok := latest[t.Parent]; !ok || t.Imask > tasks[j].Imask {
+			latest[t.Parent] = i
+		}
+	}
+
+	byUUID := make(map[string]twTask, len(tasks))
+	for _, t := range tasks {
+		byUUID[t.UUID] = t
+	}
+
+	ids := map[string]int64{}
+	for i, t := range tasks {
+		switch {
+		case t.Status == "deleted":
+			doc.unmapped("deleted tasks")
+			continue
+		case t.Status == "recurring":
+			if _, ok := latest[t.UUID]; ok {
+				continue
+			}
+		}
+		task, err := twToTask(doc, t)
+		if err != nil {
+			return nil, fmt.Errorf("Taskwarrior task %s: %w", t.UUID, err)
+		}
+		template := t
+		if j, ok := latest[t.Parent]; ok && j == i {
+			template = byUUID[t.Parent]
+		}
+		if template.Recur != "" {
+			rec, err := twToRecurrence(template)
+			if err != nil {
+				doc.unmapped("recur")
+			} else {
+				rec.Occurrence = int(t.Imask) + 1
+				task.Recurrence = rec
+			}
+		} else if t.Until != "" {
+			doc.unmapped("until")
+		}
+
+		task.ID = int64(len(doc.Tasks) + 1)
+		task.UID = t.UUID
+		ids[t.UUID] = task.ID
+		doc.Tasks = append(doc.Tasks, *task)
+	}
+
+	// Dependencies can point anywhere in the export
+	for _, t := range tasks {
+		id, ok := ids[t.UUID]
+		if !ok {
+			continue
+		}
+		for _, dep := range t.Depends {
+			if blocker, ok := ids[dep]; ok {
+				doc.Tasks[id-1].BlockedBy = append(doc.Tasks[id-1].BlockedBy, blocker)
+			} else {
+				doc.unmapped("depends")
+			}
+		}
+	}
+	return doc, nil
+}
+
+// twToTask maps a Taskwarrior task without its recurrence and dependencies
+func twToTask(doc *Document, t twTask) (*Task, error) {
+	task := &Task{
+		Title:   t.Description,
+		Status:  string(model.StatusTodo),
+		Project: t.Project,
+		Tags:    t.Tags,
+	}
+	if t.Start != "" {
+		task.Status = string(model.StatusDoing)
+	}
+
+	if t.Entry != "" {
+		entry, err := twParseTime(t.Entry)
+		if err != nil {
+			return nil, err
+		}
+		task.Created = entry
+	}
+	if t.Status == "completed" {
+		task.Status = string(model.StatusDone)
+		if t.End != "" {
+			end, err := twParseTime(t.End)
+			if err != nil {
+				return nil, err
+			}
+			task.Completed = &end
+		}
+	}
+	if t.Due != "" {
+		due, err := twParseTime(t.Due)
+		if err != nil {
+			return nil, err
+		}
+		// Taskwarrior dates without a time are due at the start of the day
+		due = due.Local()
+		if due.Hour() == 0 && due.Minute() == 0 && due.Second() == 0 {
+			due = dates.EndOfDay(due)
+		}
+		task.Due = &due
+	}
+	if t.Priority != "" {
+		p, ok := twPriorities[strings.ToUpper(t.Priority)]
+		if !ok {
+			doc.unmapped("priority")
+		}
+		task.Priority = strings.ToLower(p.String())
+	}
+	var notes []string
+	for _, a := range t.Annotations {
+		notes = append(notes, a.Description)
+	}
+	task.Description = strings.Join(notes, "\n")
+	return task, nil
+}
+
+// twUnits are Taskwarrior's recurrence periods, as a frequency and a
+// multiple of the interval
+var twUnits = map[string]struct {
+	freq model.RecurrencePattern
+	n    int
+}{
+	"d": {model.Daily, 1}, "day": {model.Daily, 1}, "days": {model.Daily, 1}, "daily": {model.Daily, 1},
+	"w": {model.Weekly, 1}, "wk": {model.Weekly, 1}, "wks": {model.Weekly, 1}, "week": {model.Weekly, 1},
+	"weeks": {model.Weekly, 1}, "weekly": {model.Weekly, 1},
+	"biweekly": {model.Weekly, 2}, "fortnight": {model.Weekly, 2},
+	"mo": {model.Monthly, 1}, "mth": {model.Monthly, 1}, "mths": {model.Monthly, 1}, "month": {model.Monthly, 1},
+	"months": {model.Monthly, 1}, "monthly": {model.Monthly, 1},
+	"q": {model.Monthly, 3}, "qtr": {model.Monthly, 3}, "qtrs": {model.Monthly, 3}, "quarter": {model.Monthly, 3},
+	"quarters": {model.Monthly, 3}, "quarterly": {model.Monthly, 3},
+	"semiannual": {model.Monthly, 6},
+	"y":          {model.Yearly, 1}, "yr": {model.Yearly, 1}, "yrs": {model.Yearly, 1}, "year": {model.Yearly, 1},
+	"years": {model.Yearly, 1}, "yearly": {model.Yearly, 1}, "annual": {model.Yearly, 1},
+}
+
+var twRecurRe = regexp.MustCompile(`^(\d*)\s*([a-z]+)$`)
+
+// twToRecurrence maps the recur and until of a recurring task's template
+func twToRecurrence(t twTask) (*Recurrence, error) {
+	recur := strings.ToLower(strings.TrimSpace(t.Recur))
+	var rule model.RRule
+	if recur == "weekdays" {
+		rule = model.RRule{Freq: model.Weekly, Interval: 1, ByDay: model.Weekdays}
+	} else {
+		m := twRecurRe.FindStringSubmatch(recur)
+		if m == nil {
+			return nil, fmt.Errorf("unknown recurrence %q", t.Recur)
+		}
+		unit, ok := twUnits[m[2]]
+		if !ok {
+			return nil, fmt.Errorf("unknown recurrence %q", t.Recur)
+		}
+		n := 1
+		if m[1] != "" {
+			n, _ = strconv.Atoi(m[1])
+		}
+		rule = model.RRule{Freq: unit.freq, Interval: n * unit.n}
+	}
+	if t.Until != "" {
+		until, err := twParseTime(t.Until)
+		if err != nil {
+			return nil, err
+		}
+		rule.Until = &until
+	}
+	if err := rule.Validate(); err != nil {
+		return nil, err
+	}
+	return &Recurrence{Rule: rule.String()}, nil
+}
+
+func (taskwarriorCodec) Encode(w io.Writer, doc *Document) error {
+	for _, t := range doc.Tags {
+		if t.Color != "" && t.Color != model.NewTag(t.Name).Color {
+			doc.unmapped("tag colors")
+		}
+	}
+
+	// Taskwarrior needs UUIDs, which tasks created in tsk have as UIDs
+	uuids := make(map[int64]string, len(doc.Tasks))
+	for _, t := range doc.Tasks {
+		if id, err := uuid.Parse(t.UID); err == nil {
+			uuids[t.ID] = id.String()
+			continue
+		}
+		uuids[t.ID] = uuid.NewSHA1(twNamespace, []byte(t.Created.UTC().Format(time.RFC3339Nano)+" "+strconv.FormatInt(t.ID, 10))).String()
+	}
+
+	if _, err := io.WriteString(w, "[\n"); err != nil {
+		return err
+	}
+	for i, t := range doc.Tasks {
+		tw := twTask{
+			UUID:        uuids[t.ID],
+			Description: t.Title,
+			Status:      "pending",
+			Entry:       twFormatTime(t.Created),
+			Project:     t.Project,
+			Tags:        t.Tags,
+		}
+		switch model.Status(t.Status) {
+		case model.StatusDoing:
+			tw.Start = tw.Entry // when it was started isn't known
+		case model.StatusDone:
+			tw.Status = "completed"
+			if t.Completed != nil {
+				tw.End = twFormatTime(*t.Completed)
+			}
+		}
+		if t.Due != nil {
+			due := *t.Due
+			if !dates.HasTime(due) {
+				// Taskwarrior dates without a time are due at the start of the day
+				due = time.Date(due.Year(), due.Month(), due.Day(), 0, 0, 0, 0, due.Location())
+			}
+			tw.Due = twFormatTime(due)
+		}
+		for p, name := range twPriorities {
+			if strings.EqualFold(name.String(), t.Priority) {
+				tw.Priority = p
+			}
+		}
+		for _, b := range t.BlockedBy {
+			tw.Depends = append(tw.Depends, uuids[b])
+		}
+		for _, line := range strings.Split(t.Description, "\n") {
+			if strings.TrimSpace(line) != "" {
+				tw.Annotations = append(tw.Annotations, twAnnotation{Entry: tw.Entry, Description: line})
+			}
+		}
+		if t.Recurrence != nil {
+			if err := twFromRecurrence(&tw, t.Recurrence); err != nil {
+				doc.unmapped("recurrence")
+			}
+		}
+		if t.Parent != 0 {
+			doc.unmapped("subtasks")
+		}
+		if t.Position != 0 {
+			doc.unmapped("position")
+		}
+
+		data, err := json.Marshal(tw)
+		if err != nil {
+			return err
+		}
+		if i < len(doc.Tasks)-1 {
+			data = append(data, ',')
+		}
+		if _, err := w.Write(append(data, '\n')); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(w, "]\n")
+	return err
+}
+
+// twFromRecurrence makes an open task the template of a Taskwarrior
+// recurrence, if its rule has a Taskwarrior form
+func twFromRecurrence(tw *twTask, r *Recurrence) error {
+	rule, err := model.ParseRRule(r.Rule)
+	if err != nil {
+		return err
+	}
+	if tw.Status != "pending" || tw.Due == "" {
+		return fmt.Errorf("only open tasks with a due date recur in Taskwarrior")
+	}
+
+	until := rule.Until
+	rule.Until = nil
+	switch {
+	case slices.Equal(rule.ByDay, model.Weekdays) && rule.Freq == model.Weekly && rule.Interval == 1:
+		rule.ByDay = nil
+		tw.Recur = "weekdays"
+	case !rule.IsSimple():
+		return fmt.Errorf("recurrence %s has no Taskwarrior form", r.Rule)
+	case rule.Interval <= 1:
+		tw.Recur = string(rule.Freq)
+	default:
+		unit := map[model.RecurrencePattern]string{model.Daily: "d", model.Weekly: "w", model.Monthly: "mo", model.Yearly: "y"}[rule.Freq]
+		tw.Recur = strconv.Itoa(rule.Interval) + unit
+	}
+	if rule.Count > 0 {
+		return fmt.Errorf("recurrence %s has no Taskwarrior form", r.Rule)
+	}
+	if until != nil {
+		tw.Until = twFormatTime(*until)
+	}
+	tw.Status = "recurring"
+	tw.Start = ""
+	return nil
+}
+
+func twParseTime(s string) (time.Time, error) {
+	t, err := time.Parse(twTimeLayout, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q", s)
+	}
+	return t, nil
+}
+
+func twFormatTime(t time.Time) string {
+	return t.UTC().Format(twTimeLayout)
+}
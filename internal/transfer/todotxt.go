@@ -0,0 +1,278 @@
+package transfer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hwanchang/tsk/internal/dates"
+	"github.com/hwanchang/tsk/internal/model"
+)
+
+func init() {
+	Register("todotxt", todotxtCodec{}, ".txt")
+}
+
+const todotxtDate = "2006-01-02"
+
+var (
+	todotxtPriorityRe = regexp.MustCompile(`^\(([A-Z])\)$`)
+	todotxtRecRe      = regexp.MustCompile(`^(\+?)(\d*)([dwmyb])$`)
+)
+
+// todotxtCodec reads and writes the todo.txt format, a task per line:
+//
+//	x 2026-10-17 2026-10-01 (A) Title +project @tag due:2026-10-20 rec:+1w
+//
+// (A) to (C) are high to low priority, +project the project, @context a tag,
+// and rec: a recurrence from the due date with +, or from completion
+// without. Completed tasks keep their priority as pri:A.
+type todotxtCodec struct{}
+
+func (todotxtCodec) Decode(r io.Reader) (*Document, error) {
+	doc := &Document{Version: Version}
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		task, err := todotxtParse(doc, scanner.Text())
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		task.ID = int64(len(doc.Tasks) + 1)
+		doc.Tasks = append(doc.Tasks, *task)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read todo.txt: %w", err)
+	}
+	return doc, nil
+}
+
+// todotxtParse parses a line into a task
+func todotxtParse(doc *Document, line string) (*Task, error) {
+	fields := strings.Fields(line)
+	task := &Task{Status: string(model.StatusTodo)}
+
+	date := func() (time.Time, bool) {
+		if len(fields) == 0 {
+			return time.Time{}, false
+		}
+		t, err := time.ParseInLocation(todotxtDate, fields[0], time.Local)
+		if err != nil {
+			return time.Time{}, false
+		}
+		fields = fields[1:]
+		return t, true
+	}
+
+	if fields[0] == "x" {
+		fields = fields[1:]
+		task.Status = string(model.StatusDone)
+		if completed, ok := date(); ok {
+			task.Completed = &completed
+		}
+	}
+	if len(fields) > 0 {
+		if m := todotxtPriorityRe.FindStringSubmatch(fields[0]); m != nil {
+			task.Priority = todotxtPriority(m[1][0])
+			fields = fields[1:]
+		}
+	}
+	if created, ok := date(); ok {
+		task.Created = created
+	}
+
+	var title []string
+	var rec string
+	for _, f := range fields {
+		key, value, _ := strings.Cut(f, ":")
+		switch {
+		case len(f) > 1 && f[0] == '+':
+			if task.Project == "" {
+				task.Project = f[1:]
+			} else {
+				doc.unmapped("extra projects")
+				title = append(title, f)
+			}
+		case len(f) > 1 && f[0] == '@':
+			task.Tags = append(task.Tags, f[1:])
+		case key == "due" && value != "":
+			due, err := time.ParseInLocation(todotxtDate, value, time.Local)
+			if err != nil {
+				return nil, fmt.Errorf("invalid due date %q (use YYYY-MM-DD)", value)
+			}
+			due = dates.EndOfDay(due)
+			task.Due = &due
+		case key == "rec" && value != "":
+			rec = value
+		case key == "pri" && len(value) == 1 && value[0] >= 'A' && value[0] <= 'Z':
+			task.Priority = todotxtPriority(value[0])
+		default:
+			title = append(title, f)
+		}
+	}
+	task.Title = strings.Join(title, " ")
+	if task.Title == "" {
+		return nil, fmt.Errorf("missing title")
+	}
+
+	if rec != "" {
+		r, err := todotxtRecurrence(rec)
+		if err != nil {
+			return nil, err
+		}
+		task.Recurrence = r
+	}
+	return task, nil
+}
+
+// todotxtPriority maps A to high, B to medium, and the rest to low
+func todotxtPriority(p byte) string {
+	switch p {
+	case 'A':
+		return "high"
+	case 'B':
+		return "medium"
+	}
+	return "low"
+}
+
+// todotxtRecurrence parses a rec: value such as 1w, +2m, or 1b (weekdays)
+func todotxtRecurrence(s string) (*Recurrence, error) {
+	m := todotxtRecRe.FindStringSubmatch(s)
+	if m == nil {
+		return nil, fmt.Errorf("invalid recurrence %q (use e.g. 1w or +2m)", s)
+	}
+	n := 1
+	if m[2] != "" {
+		n, _ = strconv.Atoi(m[2])
+	}
+	if n < 1 {
+		return nil, fmt.Errorf("invalid recurrence %q", s)
+	}
+
+	var rule model.RRule
+	switch m[3] {
+	case "b":
+		if n != 1 {
+			return nil, fmt.Errorf("unsupported recurrence %q: only 1b repeats on weekdays", s)
+		}
+		rule = model.RRule{Freq: model.Weekly, Interval: 1, ByDay: model.Weekdays}
+	default:
+		freq := map[string]model.RecurrencePattern{"d": model.Daily, "w": model.Weekly, "m": model.Monthly, "y": model.Yearly}[m[3]]
+		rule = model.RRule{Freq: freq, Interval: n}
+	}
+
+	anchor := model.AnchorCompletion
+	if m[1] == "+" {
+		anchor = model.AnchorDue
+	}
+	return &Recurrence{Rule: rule.String(), Anchor: string(anchor)}, nil
+}
+
+func (todotxtCodec) Encode(w io.Writer, doc *Document) error {
+	bw := bufio.NewWriter(w)
+	for _, t := range doc.Tasks {
+		fmt.Fprintln(bw, todotxtFormat(doc, t))
+	}
+	return bw.Flush()
+}
+
+// todotxtFormat formats a task as a line
+func todotxtFormat(doc *Document, t Task) string {
+	var parts []string
+	priority := ""
+	switch t.Priority {
+	case "high":
+		priority = "A"
+	case "medium":
+		priority = "B"
+	case "low":
+		priority = "C"
+	}
+
+	done := t.Status == string(model.StatusDone)
+	if done {
+		parts = append(parts, "x")
+		if t.Completed != nil {
+			parts = append(parts, t.Completed.Local().Format(todotxtDate))
+		}
+	} else if priority != "" {
+		parts = append(parts, "("+priority+")")
+	}
+	// A completed task's creation date follows its completion date
+	if !t.Created.IsZero() && (!done || t.Completed != nil) {
+		parts = append(parts, t.Created.Local().Format(todotxtDate))
+	}
+
+	parts = append(parts, t.Title)
+	if t.Project != "" {
+		parts = append(parts, "+"+todotxtWord(t.Project))
+	}
+	for _, tag := range t.Tags {
+		parts = append(parts, "@"+todotxtWord(tag))
+	}
+	if t.Due != nil {
+		parts = append(parts, "due:"+t.Due.Format(todotxtDate))
+		if dates.HasTime(*t.Due) {
+			doc.unmapped("due times")
+		}
+	}
+	if t.Recurrence != nil {
+		if rec, ok := todotxtRec(t.Recurrence); ok {
+			parts = append(parts, "rec:"+rec)
+		} else {
+			doc.unmapped("recurrences")
+		}
+	}
+	if done && priority != "" {
+		parts = append(parts, "pri:"+priority)
+	}
+
+	if t.Status == string(model.StatusDoing) {
+		doc.unmapped("doing status")
+	}
+	if t.Description != "" {
+		doc.unmapped("descriptions")
+	}
+	if t.Parent != 0 {
+		doc.unmapped("subtasks")
+	}
+	if len(t.BlockedBy) > 0 {
+		doc.unmapped("dependencies")
+	}
+	return strings.Join(parts, " ")
+}
+
+// todotxtRec formats a recurrence as a rec: value, if it has one
+func todotxtRec(r *Recurrence) (string, bool) {
+	rule, err := model.ParseRRule(r.Rule)
+	if err != nil {
+		return "", false
+	}
+	strict := "+"
+	if r.Anchor == string(model.AnchorCompletion) {
+		strict = ""
+	}
+	if rule.Freq == model.Weekly && rule.Interval <= 1 && slices.Equal(rule.ByDay, model.Weekdays) &&
+		rule.Count == 0 && rule.Until == nil {
+		return strict + "1b", true
+	}
+	if !rule.IsSimple() {
+		return "", false
+	}
+	unit := map[model.RecurrencePattern]string{model.Daily: "d", model.Weekly: "w", model.Monthly: "m", model.Yearly: "y"}[rule.Freq]
+	return strict + strconv.Itoa(max(rule.Interval, 1)) + unit, true
+}
+
+// todotxtWord joins the words of a project or tag name, which can't contain
+// spaces in todo.txt
+func todotxtWord(s string) string {
+	return strings.Join(strings.Fields(s), "_")
+}
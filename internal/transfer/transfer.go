@@ -0,0 +1,175 @@
+// Package transfer moves tasks between tsk and files. A Document holds the
+// projects, tags, and tasks to export or import; codecs read and write it in
+// a file format.
+//
+// The native format is versioned JSON:
+//
+//	{
+//	  "version": 1,
+//	  "projects": [{"name": "Work", "description": "..."}],
+//	  "tags": [{"name": "urgent", "color": "#ff0000"}],
+//	  "tasks": [{
+//	    "id": 1,
+//	    "uid": "5bb2f191-1df7-4f31-8852-7c06bd521cb1",
+//	    "parent": 0,
+//	    "title": "Write report",
+//	    "description": "...",
+//	    "status": "todo",
+//	    "priority": "high",
+//	    "project": "Work",
+//	    "tags": ["urgent"],
+//	    "due": "2026-10-23T17:00:00Z",
+//	    "created": "2026-10-17T09:00:00Z",
+//	    "completed": null,
+//	    "position": 0,
+//	    "blocked_by": [2],
+//	    "recurrence": {"rule": "FREQ=WEEKLY", "occurrence": 1,
+//	      "next_due": "2026-10-30T17:00:00Z", "anchor": "due", "catch_up": "skip"}
+//	  }]
+//	}
+//
+// Task IDs are local to the document: parent and blocked_by refer to them,
+// and tasks get new IDs when imported. A uid is kept, so importing a task
+// whose uid already exists updates that task instead of adding a copy. NDJSON holds the same records one
+// per line: {"version": 1} first, then {"project": ...}, {"tag": ...}, and
+// {"task": ...} lines.
+package transfer
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Version is the version of the document schema
+const Version = 1
+
+// Document is the data moved by an export or import
+type Document struct {
+	Version  int       `json:"version"`
+	Projects []Project `json:"projects"`
+	Tags     []Tag     `json:"tags"`
+	Tasks    []Task    `json:"tasks"`
+
+	// Unmapped counts what the codec that read or last wrote the document
+	// couldn't carry over, by field
+	Unmapped map[string]int `json:"-"`
+
+	// Rejected are the records the codec that read the document couldn't
+	// read and left out, so that the rest can still be imported
+	Rejected []error `json:"-"`
+}
+
+// reject records that the record on a line was left out
+func (d *Document) reject(line int, err error) {
+	d.Rejected = append(d.Rejected, fmt.Errorf("line %d: %w", line, err))
+}
+
+// unmapped records that a field of a task, project, or tag was dropped
+func (d *Document) unmapped(field string) {
+	if d.Unmapped == nil {
+		d.Unmapped = make(map[string]int)
+	}
+	d.Unmapped[field]++
+}
+
+type Project struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+type Tag struct {
+	Name  string `json:"name"`
+	Color string `json:"color,omitempty"`
+}
+
+type Task struct {
+	ID          int64       `json:"id"`
+	UID         string      `json:"uid,omitempty"`
+	Parent      int64       `json:"parent,omitempty"`
+	Title       string      `json:"title"`
+	Description string      `json:"description,omitempty"`
+	Status      string      `json:"status"`
+	Priority    string      `json:"priority,omitempty"`
+	Project     string      `json:"project,omitempty"`
+	Tags        []string    `json:"tags,omitempty"`
+	Due         *time.Time  `json:"due,omitempty"`
+	Created     time.Time   `json:"created"`
+	Completed   *time.Time  `json:"completed,omitempty"`
+	Position    int         `json:"position,omitempty"`
+	BlockedBy   []int64     `json:"blocked_by,omitempty"`
+	Recurrence  *Recurrence `json:"recurrence,omitempty"`
+}
+
+type Recurrence struct {
+	Rule       string    `json:"rule"`
+	Occurrence int       `json:"occurrence,omitempty"`
+	NextDue    time.Time `json:"next_due"`
+	Anchor     string    `json:"anchor,omitempty"`
+	CatchUp    string    `json:"catch_up,omitempty"`
+}
+
+// Codec reads and writes documents in a file format
+type Codec interface {
+	Encode(w io.Writer, doc *Document) error
+	Decode(r io.Reader) (*Document, error)
+}
+
+type format struct {
+	codec Codec
+	exts  []string
+}
+
+var formats = map[string]format{}
+
+// Register makes a codec available under a format name, for files with
+// the given extensions
+func Register(name string, c Codec, exts ...string) {
+	formats[name] = format{codec: c, exts: exts}
+}
+
+// Lookup returns the codec of a format
+func Lookup(name string) (Codec, error) {
+	f, ok := formats[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown format %q (use %s)", name, strings.Join(Formats(), ", "))
+	}
+	return f.codec, nil
+}
+
+// Formats lists the registered format names
+func Formats() []string {
+	names := make([]string, 0, len(formats))
+	for name := range formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// FormatOf returns the format of a file from its extension, or "" if unknown
+func FormatOf(path string) string {
+	ext := strings.ToLower(filepath.Ext(path))
+	for name, f := range formats {
+		for _, e := range f.exts {
+			if e == ext {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// checkVersion rejects documents written by a newer tsk
+func checkVersion(v int) error {
+	switch {
+	case v == 0:
+		return fmt.Errorf("not a tsk export: missing version")
+	case v > Version:
+		return fmt.Errorf("unsupported export version %d (this tsk reads up to %d)", v, Version)
+	}
+	return nil
+}
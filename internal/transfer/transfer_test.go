@@ -0,0 +1,164 @@
+package transfer
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hwanchang/tsk/internal/db"
+	"github.com/hwanchang/tsk/internal/model"
+	"github.com/hwanchang/tsk/internal/store"
+)
+
+func newTestStore(t *testing.T) *store.SQLiteStore {
+	t.Helper()
+	database, err := db.New(filepath.Join(t.TempDir(), "tsk.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close() })
+	if err := database.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	return store.New(database)
+}
+
+// populate fills a store with a bit of everything a document carries
+func populate(t *testing.T, st store.Store) {
+	t.Helper()
+	check := func(err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	at := func(day, hour int) *time.Time {
+		t := time.Date(2026, time.October, day, hour, 30, 0, 0, time.Local)
+		return &t
+	}
+
+	work := &model.Project{Name: "Work", Description: "Day job"}
+	check(st.CreateProject(work))
+	home := model.NewProject("Home")
+	check(st.CreateProject(home))
+
+	urgent := &model.Tag{Name: "urgent", Color: "#FF0000"}
+	check(st.CreateTag(urgent))
+	later := model.NewTag("later")
+	check(st.CreateTag(later))
+
+	release := model.NewTask("Ship the release")
+	release.Description = "Tag it,\nthen announce it"
+	release.ProjectID = &work.ID
+	release.Priority = model.PriorityHigh
+	release.DueDate = at(20, 17)
+	release.CreatedAt = *at(1, 9)
+	check(st.CreateTask(release))
+	check(st.AddTagToTask(release.ID, urgent.ID))
+
+	notes := model.NewTask("Write release notes")
+	notes.ParentID = &release.ID
+	notes.ProjectID = &work.ID
+	notes.Status = model.StatusDone
+	notes.CreatedAt = *at(2, 9)
+	notes.CompletedAt = at(3, 11)
+	notes.Position = 2
+	check(st.CreateTask(notes))
+
+	plants := model.NewTask("Water the plants")
+	plants.ProjectID = &home.ID
+	plants.DueDate = at(18, 8)
+	plants.CreatedAt = *at(4, 9)
+	check(st.CreateTask(plants))
+	check(st.AddTagToTask(plants.ID, later.ID))
+	rule, err := model.ParseRRule("FREQ=WEEKLY;BYDAY=SA,SU")
+	check(err)
+	rec := model.NewRecurrence(plants.ID, rule)
+	rec.Occurrence = 3
+	rec.Anchor = model.AnchorCompletion
+	rec.Schedule(plants.DueDate)
+	check(st.SetRecurrence(rec))
+
+	deploy := model.NewTask("Deploy")
+	deploy.Status = model.StatusDoing
+	deploy.CreatedAt = *at(5, 9)
+	check(st.CreateTask(deploy))
+	check(st.AddDependency(deploy.ID, release.ID))
+	check(st.AddTagToTask(deploy.ID, urgent.ID))
+	check(st.AddTagToTask(deploy.ID, later.ID))
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, format := range []string{"json", "ndjson"} {
+		t.Run(format, func(t *testing.T) {
+			codec, err := Lookup(format)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			src := newTestStore(t)
+			populate(t, src)
+			doc, err := Export(src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(doc.Tasks) != 4 {
+				t.Fatalf("exported %d tasks, want 4", len(doc.Tasks))
+			}
+			var first bytes.Buffer
+			if err := codec.Encode(&first, doc); err != nil {
+				t.Fatal(err)
+			}
+
+			decoded, err := codec.Decode(bytes.NewReader(first.Bytes()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			dst := newTestStore(t)
+			sum, err := Import(dst, decoded, Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sum.Tasks != 4 || sum.Updated != 0 {
+				t.Errorf("imported %d tasks, %d updated; want 4, none updated", sum.Tasks, sum.Updated)
+			}
+
+			doc, err = Export(dst)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var second bytes.Buffer
+			if err := codec.Encode(&second, doc); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(first.Bytes(), second.Bytes()) {
+				t.Errorf("export changed after a round trip:\nfirst:\n%s\nsecond:\n%s", first.String(), second.String())
+			}
+		})
+	}
+}
+
+func TestReimportUpdatesByUID(t *testing.T) {
+	st := newTestStore(t)
+	populate(t, st)
+	doc, err := Export(st)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sum, err := Import(st, doc, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum.Updated != len(doc.Tasks) || len(sum.NewProjects) > 0 || len(sum.NewTags) > 0 {
+		t.Errorf("got %+v, want all %d tasks updated and nothing new", sum, len(doc.Tasks))
+	}
+	tasks, err := st.ListTasks(store.TaskFilter{AllLevels: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != len(doc.Tasks) {
+		t.Errorf("got %d tasks after importing an export again, want %d", len(tasks), len(doc.Tasks))
+	}
+}